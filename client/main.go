@@ -1,41 +1,111 @@
 package main
 
 import (
+	"flag"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"github.com/dimuls/news-storage/client/web"
+	"github.com/dimuls/news-storage/config"
+	"github.com/dimuls/news-storage/storage/grpc"
 	"github.com/dimuls/news-storage/storage/nats"
 	"github.com/sirupsen/logrus"
 )
 
+// storageClient is client of storage over NATS or gRPC.
+type storageClient interface {
+	web.Storage
+	Close()
+}
+
+// newStorageClient creates storage client of configured transport.
+func newStorageClient(c *config.Config) (storageClient, error) {
+	if c.Transport == config.TransportGRPC {
+		return grpc.NewClient(c.GRPC.Addr,
+			c.GRPC.RequestTimeout.Duration(), grpc.TLSConfig{
+				CertFile: c.GRPC.TLSCertFile,
+				KeyFile:  c.GRPC.TLSKeyFile,
+				CAFile:   c.GRPC.TLSCAFile,
+			})
+	}
+	return nats.NewClient(c.NATS.URL, c.NATS.Subject,
+		c.NATS.DrainTimeout.Duration())
+}
+
+// reloadConfig reloads config and applies web settings which can be
+// changed without restart.
+func reloadConfig(c *config.Config, ws *web.Server) *config.Config {
+	return c.ReloadAndApply(web.ReloadableSettings, func(n *config.Config) {
+		ws.ApplyConfig(n.Web)
+	})
+}
+
 func main() {
 	log := logrus.WithField("subsystem", "main")
 
-	nc, err := nats.NewClient(os.Getenv("NATS_URL"),
-		os.Getenv("SUBSCRIBE_SUBJECT"))
+	c, err := config.Load(os.Args[0], os.Args[1:],
+		config.SectionNATS|config.SectionGRPC|config.SectionWeb)
+	if err != nil {
+		if err == flag.ErrHelp {
+			return
+		}
+		log.WithError(err).Fatal("failed to load config")
+	}
+
+	if c.PrintConfig {
+		err = c.Print(os.Stdout)
+		if err != nil {
+			log.WithError(err).Fatal("failed to print config")
+		}
+		return
+	}
+
+	err = c.Validate()
 	if err != nil {
-		log.WithError(err).Error("failed to create nats client")
+		log.WithError(err).Fatal("invalid config")
 	}
 
-	ws := web.NewServer(nc, os.Getenv("BIND_ADDR"))
-	ws.Start()
+	logrus.SetLevel(c.LogrusLevel())
+
+	sc, err := newStorageClient(c)
+	if err != nil {
+		log.WithError(err).WithField("transport", c.Transport).Fatal(
+			"failed to create storage client")
+	}
+
+	ws, err := web.NewServerFromConfig(c.Web, sc)
+	if err != nil {
+		log.WithError(err).Fatal("failed to create web server")
+	}
+
+	err = ws.Start()
+	if err != nil {
+		log.WithError(err).Fatal("failed to start web server")
+	}
 
 	log.Info("web server started")
 
-	ss := make(chan os.Signal)
+	ss := make(chan os.Signal, 1)
 
-	signal.Notify(ss, syscall.SIGTERM)
+	signal.Notify(ss, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
 
-	s := <-ss
+	var s os.Signal
+
+	for s = range ss {
+		if s != syscall.SIGHUP {
+			break
+		}
+		log.Info("captured SIGHUP signal, reloading config")
+		c = reloadConfig(c, ws)
+	}
 
 	log.Infof("captured %v signal, stopping", s)
 
 	st := time.Now()
 	ws.Stop()
-	nc.Close()
+	sc.Close()
 	et := time.Now()
 
 	log.Infof("stopped in %g seconds, exiting",
@@ -0,0 +1,292 @@
+package web
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/subtle"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dimuls/news-storage/entity"
+	"github.com/labstack/echo"
+)
+
+// Authenticator authenticates requests. It returns ErrNoCredentials if
+// request has no credentials of its kind.
+type Authenticator interface {
+	Authenticate(r *http.Request) (entity.Principal, error)
+}
+
+var ErrNoCredentials = errors.New("no credentials")
+
+const apiKeyHeader = "X-API-Key"
+
+// APIKeys authenticates requests by static key in X-API-Key header.
+type APIKeys map[string]entity.Principal
+
+// LoadAPIKeys reads API keys file. Every line of it is "key name role",
+// empty lines and lines starting with # are skipped.
+func LoadAPIKeys(path string) (APIKeys, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.New("failed to open API keys file: " +
+			err.Error())
+	}
+	defer f.Close()
+
+	ks := APIKeys{}
+
+	s := bufio.NewScanner(f)
+	for line := 1; s.Scan(); line++ {
+		l := strings.TrimSpace(s.Text())
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+
+		fs := strings.Fields(l)
+		if len(fs) != 3 {
+			return nil, errors.New("line " + strconv.Itoa(line) +
+				": expected key, name and role")
+		}
+
+		r, err := entity.ParseRole(fs[2])
+		if err != nil {
+			return nil, errors.New("line " + strconv.Itoa(line) + ": " +
+				err.Error())
+		}
+
+		ks[fs[0]] = entity.Principal{Name: fs[1], Role: r}
+	}
+
+	err = s.Err()
+	if err != nil {
+		return nil, errors.New("failed to read API keys file: " +
+			err.Error())
+	}
+
+	return ks, nil
+}
+
+func (ks APIKeys) Authenticate(r *http.Request) (entity.Principal, error) {
+	key := r.Header.Get(apiKeyHeader)
+	if key == "" {
+		return entity.Principal{}, ErrNoCredentials
+	}
+
+	// Keys are compared in constant time, so that they can't be guessed
+	// by response timing.
+	var (
+		p     entity.Principal
+		found bool
+	)
+	for k, kp := range ks {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			p, found = kp, true
+		}
+	}
+
+	if !found {
+		return entity.Principal{}, errors.New("invalid API key")
+	}
+
+	return p, nil
+}
+
+// JWT algorithms.
+const (
+	JWTAlgorithmHS256 = "HS256"
+	JWTAlgorithmRS256 = "RS256"
+)
+
+// JWT authenticates requests by bearer token. Principal name is token
+// subject and its role is role claim.
+type JWT struct {
+	method jwt.SigningMethod
+	key    interface{}
+}
+
+// LoadJWT creates JWT authenticator for tokens signed with algorithm alg.
+// Key file has secret for HS256, surrounding whitespace is trimmed, or
+// PEM encoded public key for RS256.
+func LoadJWT(alg, keyFile string) (*JWT, error) {
+	data, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, errors.New("failed to read JWT key file: " +
+			err.Error())
+	}
+
+	switch alg {
+	case JWTAlgorithmHS256:
+		secret := bytes.TrimSpace(data)
+		if len(secret) == 0 {
+			return nil, errors.New("JWT secret is empty")
+		}
+		return &JWT{method: jwt.SigningMethodHS256, key: secret}, nil
+	case JWTAlgorithmRS256:
+		key, err := jwt.ParseRSAPublicKeyFromPEM(data)
+		if err != nil {
+			return nil, errors.New("failed to parse JWT public key: " +
+				err.Error())
+		}
+		return &JWT{method: jwt.SigningMethodRS256, key: key}, nil
+	}
+
+	return nil, errors.New("JWT algorithm must be HS256 or RS256")
+}
+
+type jwtClaims struct {
+	Role string `json:"role"`
+	jwt.StandardClaims
+}
+
+func (j *JWT) Authenticate(r *http.Request) (entity.Principal, error) {
+	const prefix = "Bearer "
+
+	h := r.Header.Get(echo.HeaderAuthorization)
+	if !strings.HasPrefix(h, prefix) {
+		return entity.Principal{}, ErrNoCredentials
+	}
+
+	var cs jwtClaims
+
+	_, err := jwt.ParseWithClaims(strings.TrimPrefix(h, prefix), &cs,
+		func(t *jwt.Token) (interface{}, error) {
+			// Algorithm is fixed, otherwise RS256 public key could be
+			// used as HS256 secret.
+			if t.Method != j.method {
+				return nil, errors.New("unexpected signing method " +
+					t.Method.Alg())
+			}
+			return j.key, nil
+		})
+	if err != nil {
+		return entity.Principal{}, errors.New("invalid token: " +
+			err.Error())
+	}
+
+	if cs.Subject == "" {
+		return entity.Principal{}, errors.New("token has no subject")
+	}
+
+	role, err := entity.ParseRole(cs.Role)
+	if err != nil {
+		return entity.Principal{}, errors.New("invalid token role: " +
+			err.Error())
+	}
+
+	return entity.Principal{Name: cs.Subject, Role: role}, nil
+}
+
+// Authenticators tries authenticators in order, the first one which finds
+// credentials in request decides.
+type Authenticators []Authenticator
+
+func (as Authenticators) Authenticate(r *http.Request) (entity.Principal,
+	error) {
+	for _, a := range as {
+		p, err := a.Authenticate(r)
+		if err != ErrNoCredentials {
+			return p, err
+		}
+	}
+	return entity.Principal{}, ErrNoCredentials
+}
+
+// NewAuthenticator creates authenticator by API keys file and JWT
+// settings, empty ones are skipped. It returns nil if neither is set,
+// which disables authentication.
+func NewAuthenticator(apiKeysFile, jwtAlgorithm, jwtKeyFile string) (
+	Authenticator, error) {
+	var as Authenticators
+
+	if apiKeysFile != "" {
+		ks, err := LoadAPIKeys(apiKeysFile)
+		if err != nil {
+			return nil, err
+		}
+		as = append(as, ks)
+	}
+
+	if jwtAlgorithm != "" {
+		j, err := LoadJWT(jwtAlgorithm, jwtKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		as = append(as, j)
+	}
+
+	if len(as) == 0 {
+		return nil, nil
+	}
+
+	return as, nil
+}
+
+// authenticate puts principal of request to its context. Requests
+// without credentials pass anonymously, routes reject them by role.
+// Failed authentications are limited by IP address if rate limiter is
+// set.
+func (s *Server) authenticate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if s.auth == nil {
+			return next(c)
+		}
+
+		req := c.Request()
+
+		p, err := s.auth.Authenticate(req)
+		switch err {
+		case nil:
+			lerr := s.limitAuthFailures(c, false)
+			if lerr != nil {
+				return lerr
+			}
+			c.SetRequest(req.WithContext(
+				entity.ContextWithPrincipal(req.Context(), p)))
+		case ErrNoCredentials:
+		default:
+			lerr := s.limitAuthFailures(c, true)
+			if lerr != nil {
+				return lerr
+			}
+			return unauthorized(c, err.Error())
+		}
+
+		return next(c)
+	}
+}
+
+// requireRole allows route only to principals with role r or higher. All
+// routes are allowed if authentication is disabled.
+func (s *Server) requireRole(r entity.Role) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if s.auth == nil {
+				return next(c)
+			}
+
+			p, ok := entity.PrincipalFromContext(c.Request().Context())
+			if !ok {
+				return unauthorized(c, "authentication is required")
+			}
+
+			if p.Role < r {
+				return echo.NewHTTPError(http.StatusForbidden,
+					r.String()+" role is required")
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func unauthorized(c echo.Context, msg string) error {
+	c.Response().Header().Set(echo.HeaderWWWAuthenticate,
+		`Bearer realm="news"`)
+	return echo.NewHTTPError(http.StatusUnauthorized, msg)
+}
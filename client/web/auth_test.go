@@ -0,0 +1,233 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dimuls/news-storage/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTempFile(t *testing.T, data []byte) string {
+	f, err := ioutil.TempFile("", "news-storage-auth")
+	if err != nil {
+		t.Fatal("failed to create temp file: " + err.Error())
+	}
+	defer f.Close()
+
+	_, err = f.Write(data)
+	if err != nil {
+		t.Fatal("failed to write temp file: " + err.Error())
+	}
+
+	return f.Name()
+}
+
+// principalStorage remembers principal of the last request.
+type principalStorage struct {
+	principal entity.Principal
+}
+
+func (s *principalStorage) News(ctx context.Context, id int64) (
+	entity.News, error) {
+	s.principal, _ = entity.PrincipalFromContext(ctx)
+	return entity.News{ID: id}, nil
+}
+
+func initAuthServer(t *testing.T, a Authenticator) (*principalStorage,
+	*Server) {
+	ps := &principalStorage{}
+	s := NewServer(ps, "", 10*time.Second, TLSConfig{})
+	s.SetAuthenticator(a)
+	require.NoError(t, s.Start())
+	return ps, s
+}
+
+func serve(s *Server, method, target string,
+	header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(""))
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	res := httptest.NewRecorder()
+	s.echo.ServeHTTP(res, req)
+	return res
+}
+
+func TestLoadAPIKeys(t *testing.T) {
+	path := writeTempFile(t, []byte(`
+# key name role
+k1 alice reader
+
+k2 bob admin
+`))
+	defer os.Remove(path)
+
+	ks, err := LoadAPIKeys(path)
+	if assert.NoError(t, err) {
+		assert.Equal(t, APIKeys{
+			"k1": {Name: "alice", Role: entity.RoleReader},
+			"k2": {Name: "bob", Role: entity.RoleAdmin},
+		}, ks)
+	}
+
+	badPath := writeTempFile(t, []byte("k1 alice root\n"))
+	defer os.Remove(badPath)
+
+	_, err = LoadAPIKeys(badPath)
+	assert.Error(t, err)
+}
+
+func TestServer_auth_apiKeys(t *testing.T) {
+	ps, s := initAuthServer(t, APIKeys{
+		"reader-key": {Name: "alice", Role: entity.RoleReader},
+		"editor-key": {Name: "bob", Role: entity.RoleEditor},
+	})
+	defer s.Stop()
+
+	res := serve(s, http.MethodGet, "/news/1", nil)
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+	assert.NotEmpty(t, res.Header().Get("WWW-Authenticate"))
+
+	res = serve(s, http.MethodGet, "/news/1",
+		map[string]string{"X-API-Key": "wrong-key"})
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+
+	res = serve(s, http.MethodGet, "/news/1",
+		map[string]string{"X-API-Key": "reader-key"})
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, entity.Principal{Name: "alice", Role: entity.RoleReader},
+		ps.principal)
+
+	res = serve(s, http.MethodPost, "/news:import",
+		map[string]string{"X-API-Key": "reader-key"})
+	assert.Equal(t, http.StatusForbidden, res.Code)
+
+	// Editor passes authorization, storage doesn't support import.
+	res = serve(s, http.MethodPost, "/news:import",
+		map[string]string{"X-API-Key": "editor-key"})
+	assert.Equal(t, http.StatusNotImplemented, res.Code)
+}
+
+func TestServer_auth_disabled(t *testing.T) {
+	ps, s := initAuthServer(t, nil)
+	defer s.Stop()
+
+	res := serve(s, http.MethodGet, "/news/1", nil)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, entity.Principal{}, ps.principal)
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, key interface{},
+	role string, expiresAt time.Time) string {
+	tok, err := jwt.NewWithClaims(method, jwtClaims{
+		Role: role,
+		StandardClaims: jwt.StandardClaims{
+			Subject:   "carol",
+			ExpiresAt: expiresAt.Unix(),
+		},
+	}).SignedString(key)
+	if err != nil {
+		t.Fatal("failed to sign token: " + err.Error())
+	}
+	return "Bearer " + tok
+}
+
+func TestServer_auth_jwtHS256(t *testing.T) {
+	path := writeTempFile(t, []byte("secret\n"))
+	defer os.Remove(path)
+
+	a, err := LoadJWT(JWTAlgorithmHS256, path)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ps, s := initAuthServer(t, a)
+	defer s.Stop()
+
+	hour := time.Now().Add(time.Hour)
+	secret := []byte("secret")
+
+	res := serve(s, http.MethodGet, "/news/1", map[string]string{
+		"Authorization": signToken(t, jwt.SigningMethodHS256, secret,
+			"editor", hour),
+	})
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, entity.Principal{Name: "carol", Role: entity.RoleEditor},
+		ps.principal)
+
+	for name, tok := range map[string]string{
+		"expired": signToken(t, jwt.SigningMethodHS256, secret, "editor",
+			time.Now().Add(-time.Hour)),
+		"wrong secret": signToken(t, jwt.SigningMethodHS256,
+			[]byte("wrong"), "editor", hour),
+		"wrong algorithm": signToken(t, jwt.SigningMethodHS512, secret,
+			"editor", hour),
+		"unknown role": signToken(t, jwt.SigningMethodHS256, secret, "root",
+			hour),
+	} {
+		res = serve(s, http.MethodGet, "/news/1",
+			map[string]string{"Authorization": tok})
+		assert.Equal(t, http.StatusUnauthorized, res.Code, name)
+	}
+}
+
+func TestServer_auth_jwtRS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal("failed to generate key: " + err.Error())
+	}
+
+	pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal("failed to marshal public key: " + err.Error())
+	}
+
+	path := writeTempFile(t, pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: pub,
+	}))
+	defer os.Remove(path)
+
+	a, err := LoadJWT(JWTAlgorithmRS256, path)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, s := initAuthServer(t, Authenticators{APIKeys{}, a})
+	defer s.Stop()
+
+	hour := time.Now().Add(time.Hour)
+
+	res := serve(s, http.MethodGet, "/news/1", map[string]string{
+		"Authorization": signToken(t, jwt.SigningMethodRS256, key, "reader",
+			hour),
+	})
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	res = serve(s, http.MethodPost, "/news:import", map[string]string{
+		"Authorization": signToken(t, jwt.SigningMethodRS256, key, "reader",
+			hour),
+	})
+	assert.Equal(t, http.StatusForbidden, res.Code)
+
+	// Public key must not be accepted as HS256 secret.
+	res = serve(s, http.MethodGet, "/news/1", map[string]string{
+		"Authorization": signToken(t, jwt.SigningMethodHS256,
+			pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}),
+			"admin", hour),
+	})
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+}
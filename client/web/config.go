@@ -0,0 +1,88 @@
+package web
+
+import (
+	"errors"
+
+	"github.com/dimuls/news-storage/config"
+)
+
+// ReloadableSettings are settings of web section which ApplyConfig
+// changes without restart.
+var ReloadableSettings = []string{
+	"web.shutdown_timeout",
+	"web.feed_count",
+}
+
+// NewServerFromConfig creates server of storage s configured by web
+// section c, with authentication, trusted proxies and rate limits of it.
+// Limiter store created for it is closed on Stop.
+func NewServerFromConfig(c *config.Web, s Storage) (*Server, error) {
+	auth, err := NewAuthenticator(c.APIKeysFile, c.JWTAlgorithm,
+		c.JWTKeyFile)
+	if err != nil {
+		return nil, errors.New("failed to create authenticator: " +
+			err.Error())
+	}
+
+	proxies, err := ParseTrustedProxies(c.TrustedProxies)
+	if err != nil {
+		return nil, errors.New("invalid trusted proxies: " + err.Error())
+	}
+
+	limits, err := ParseRateLimits(c.RateLimits)
+	if err != nil {
+		return nil, errors.New("invalid rate limits: " + err.Error())
+	}
+
+	// Failed authentications are limited even if requests aren't.
+	if auth != nil {
+		limits[AuthFailures], err = ParseRateLimit(c.AuthFailureLimit)
+		if err != nil {
+			return nil, errors.New("invalid auth failure limit: " +
+				err.Error())
+		}
+	}
+
+	ws := NewServer(s, c.BindAddr, c.ShutdownTimeout.Duration(), TLSConfig{
+		CertFile:     c.TLSCertFile,
+		KeyFile:      c.TLSKeyFile,
+		ClientCAFile: c.TLSClientCAFile,
+		RedirectAddr: c.RedirectAddr,
+	})
+	ws.SetAuthenticator(auth)
+	ws.SetTrustedProxies(proxies)
+	ws.SetMaxStreamClients(c.StreamMaxClients)
+	ws.SetGraphQLPlayground(c.GraphQLPlayground)
+	ws.SetPublicURL(c.PublicURL)
+	ws.SetTemplateDir(c.TemplateDir)
+	ws.SetV1Deprecation(c.V1DeprecationDate())
+	ws.SetV1Sunset(c.V1SunsetDate())
+	ws.ApplyConfig(c)
+
+	if auth == nil {
+		ws.log.Warn("authentication is disabled, web API is open to anyone")
+	}
+
+	if len(limits) > 0 {
+		var ls LimiterStore = NewMemoryLimiterStore()
+		if c.RateLimitNATSURL != "" {
+			ns, err := NewNATSLimiterStore(c.RateLimitNATSURL, limits)
+			if err != nil {
+				return nil, errors.New(
+					"failed to create NATS rate limiter store: " +
+						err.Error())
+			}
+			ws.limiterStore = ns
+			ls = ns
+		}
+		ws.SetRateLimiter(NewRateLimiter(limits, ls))
+	}
+
+	return ws, nil
+}
+
+// ApplyConfig applies ReloadableSettings of web section c.
+func (s *Server) ApplyConfig(c *config.Web) {
+	s.SetShutdownTimeout(c.ShutdownTimeout.Duration())
+	s.SetFeedCount(c.FeedCount)
+}
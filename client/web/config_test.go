@@ -0,0 +1,48 @@
+package web
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dimuls/news-storage/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewServerFromConfig(t *testing.T) {
+	s, err := NewServerFromConfig(&config.Web{
+		ShutdownTimeout: config.Duration(5 * time.Second),
+		FeedCount:       7,
+		RateLimits:      []string{"GET /news/:news_id=10/s:20"},
+		TrustedProxies:  []string{"10.0.0.0/8"},
+		PublicURL:       "https://news.example.com/",
+	}, &mockStorage{})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Nil(t, s.auth)
+	assert.NotNil(t, s.limiter)
+	assert.Len(t, s.trustedProxies, 1)
+	assert.Equal(t, "https://news.example.com", s.publicURL)
+	assert.EqualValues(t, 7, s.feedCount)
+
+	s.ApplyConfig(&config.Web{
+		ShutdownTimeout: config.Duration(time.Second),
+		FeedCount:       3,
+	})
+
+	assert.EqualValues(t, time.Second, s.shutdownTimeout)
+	assert.EqualValues(t, 3, s.feedCount)
+}
+
+func TestNewServerFromConfig_invalid(t *testing.T) {
+	_, err := NewServerFromConfig(&config.Web{
+		RateLimits: []string{"bad"},
+	}, &mockStorage{})
+	assert.Error(t, err)
+
+	_, err = NewServerFromConfig(&config.Web{
+		TrustedProxies: []string{"bad"},
+	}, &mockStorage{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,120 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockExportStorage struct {
+	mockStorage
+}
+
+func (s *mockExportStorage) ExportNews(ctx context.Context,
+	f entity.NewsFilter, fn func(entity.News) error) error {
+	args := s.Called(f)
+	for _, n := range args.Get(0).([]entity.News) {
+		err := fn(n)
+		if err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+var testExportNews = []entity.News{{
+	ID:         1,
+	Header:     "first",
+	Date:       time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+	ExternalID: "a",
+}, {
+	ID:     2,
+	Header: "second, with comma",
+	Date:   time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC),
+}}
+
+func TestServer_exportNews_notImplemented(t *testing.T) {
+	s := initServerWith(t, &mockStorage{})
+	defer s.Stop()
+
+	req := httptest.NewRequest(http.MethodGet, "/news:export", nil)
+	res := httptest.NewRecorder()
+
+	s.echo.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNotImplemented, res.Code)
+}
+
+func TestServer_exportNews_badRequest(t *testing.T) {
+	s := initServerWith(t, &mockExportStorage{})
+	defer s.Stop()
+
+	for _, target := range []string{
+		"/news:export?format=xml",
+		"/news:export?from=yesterday",
+		"/news:export?to=2019-13-01",
+	} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		res := httptest.NewRecorder()
+
+		s.echo.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusBadRequest, res.Code, target)
+	}
+}
+
+func TestServer_exportNews_jsonl(t *testing.T) {
+	ms := &mockExportStorage{}
+	s := initServerWith(t, ms)
+	defer s.Stop()
+
+	ms.On("ExportNews", entity.NewsFilter{
+		From: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2019, 1, 4, 0, 0, 0, 0, time.UTC).Add(-1),
+	}).Return(testExportNews, nil)
+
+	req := httptest.NewRequest(http.MethodGet,
+		"/news:export?from=2019-01-01&to=2019-01-03", nil)
+	res := httptest.NewRecorder()
+
+	s.echo.ServeHTTP(res, req)
+
+	ms.AssertExpectations(t)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "application/x-ndjson", res.Header().Get("Content-Type"))
+	assert.Equal(t,
+		`{"id":1,"header":"first","date":"2019-01-02T00:00:00Z",`+
+			`"external_id":"a"}`+"\n"+
+			`{"id":2,"header":"second, with comma",`+
+			`"date":"2019-01-03T00:00:00Z"}`+"\n",
+		res.Body.String())
+}
+
+func TestServer_exportNews_csv(t *testing.T) {
+	ms := &mockExportStorage{}
+	s := initServerWith(t, ms)
+	defer s.Stop()
+
+	ms.On("ExportNews", entity.NewsFilter{Query: "q"}).Return(
+		testExportNews, nil)
+
+	req := httptest.NewRequest(http.MethodGet,
+		"/news:export?format=csv&query=q", nil)
+	res := httptest.NewRecorder()
+
+	s.echo.ServeHTTP(res, req)
+
+	ms.AssertExpectations(t)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "id,external_id,header,date\n"+
+		"1,a,first,2019-01-02T00:00:00Z\n"+
+		"2,,\"second, with comma\",2019-01-03T00:00:00Z\n",
+		res.Body.String())
+}
@@ -0,0 +1,258 @@
+package web
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/labstack/echo"
+)
+
+const (
+	// DefaultFeedCount is number of feed items if count parameter is
+	// missing and other default isn't set.
+	DefaultFeedCount = 20
+	// MaxFeedCount is maximum number of feed items.
+	MaxFeedCount = 1000
+
+	feedTitle = "News"
+)
+
+type feedFormat int
+
+const (
+	feedRSS feedFormat = iota
+	feedAtom
+)
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title string `xml:"title"`
+	// Self is before Link, because link elements of any namespace are
+	// decoded to the first matching field.
+	Self          atomLink  `xml:"http://www.w3.org/2005/Atom link"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title   string  `xml:"title"`
+	Link    string  `xml:"link"`
+	GUID    rssGUID `xml:"guid"`
+	PubDate string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title   string   `xml:"title"`
+	ID      string   `xml:"id"`
+	Link    atomLink `xml:"link"`
+	Updated string   `xml:"updated"`
+}
+
+// feedItems returns the latest news for feed. News are filtered by from,
+// to and query parameters and limited by count one.
+func (s *Server) feedItems(c echo.Context) ([]entity.News, error) {
+	l, ok := s.storage.(Lister)
+	if !ok {
+		return nil, echo.NewHTTPError(http.StatusNotImplemented,
+			"storage doesn't support listing")
+	}
+
+	// News have no tags, so silently ignoring tag filter would give
+	// readers unrelated news.
+	if c.QueryParam("tag") != "" {
+		return nil, echo.NewHTTPError(http.StatusBadRequest,
+			"news have no tags, tag filter isn't supported")
+	}
+
+	f, err := filterParams(c)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	f.Limit = atomic.LoadInt64(&s.feedCount)
+
+	if v := c.QueryParam("count"); v != "" {
+		f.Limit, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || f.Limit < 1 || f.Limit > MaxFeedCount {
+			return nil, echo.NewHTTPError(http.StatusBadRequest,
+				"count must be integer from 1 to "+
+					strconv.Itoa(MaxFeedCount))
+		}
+	}
+
+	ns, err := l.ListNews(c.Request().Context(), f)
+	if err != nil {
+		return nil, errors.New("failed to list news: " + err.Error())
+	}
+
+	return ns, nil
+}
+
+// getFeed responds with RSS 2.0 or Atom feed of the latest news. Feed
+// has ETag of its content and Last-Modified of its newest news, so that
+// readers get 304 Not Modified until feed changes. ETag is checked first,
+// because older storage peers send dates without time.
+func (s *Server) getFeed(format feedFormat) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ns, err := s.feedItems(c)
+		if err != nil {
+			return err
+		}
+
+		var updated time.Time
+		for _, n := range ns {
+			if n.Date.After(updated) {
+				updated = n.Date.UTC()
+			}
+		}
+
+		base := s.baseURL(c)
+		self := base + c.Request().URL.RequestURI()
+
+		var (
+			feed        interface{}
+			contentType string
+		)
+
+		switch format {
+		case feedRSS:
+			feed = newRSS(ns, base, self, updated)
+			contentType = "application/rss+xml; charset=utf-8"
+		case feedAtom:
+			feed = newAtomFeed(ns, base, self, updated)
+			contentType = "application/atom+xml; charset=utf-8"
+		}
+
+		data, err := xml.Marshal(feed)
+		if err != nil {
+			return errors.New("failed to marshal feed: " + err.Error())
+		}
+
+		data = append([]byte(xml.Header), data...)
+
+		h := fnv.New64a()
+		h.Write(data)
+
+		res := c.Response()
+		res.Header().Set(echo.HeaderContentType, contentType)
+		res.Header().Set("ETag",
+			`"`+strconv.FormatUint(h.Sum64(), 16)+`"`)
+
+		// ServeContent answers If-None-Match and If-Modified-Since
+		// conditional requests.
+		http.ServeContent(res, c.Request(), "", updated,
+			bytes.NewReader(data))
+
+		return nil
+	}
+}
+
+func newsLink(base string, n entity.News) string {
+	return base + "/news/" + strconv.FormatInt(n.ID, 10)
+}
+
+func newRSS(ns []entity.News, base, self string, updated time.Time) rss {
+	r := rss{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       feedTitle,
+			Link:        base + "/",
+			Description: "The latest news",
+			Self: atomLink{
+				Href: self,
+				Rel:  "self",
+				Type: "application/rss+xml",
+			},
+		},
+	}
+
+	if !updated.IsZero() {
+		r.Channel.LastBuildDate = updated.Format(time.RFC1123Z)
+	}
+
+	for _, n := range ns {
+		link := newsLink(base, n)
+		r.Channel.Items = append(r.Channel.Items, rssItem{
+			Title:   n.Header,
+			Link:    link,
+			GUID:    rssGUID{IsPermaLink: true, Value: link},
+			PubDate: n.Date.UTC().Format(time.RFC1123Z),
+		})
+	}
+
+	return r
+}
+
+func newAtomFeed(ns []entity.News, base, self string,
+	updated time.Time) atomFeed {
+	// Atom requires feed update time even if feed is empty.
+	if updated.IsZero() {
+		updated = time.Unix(0, 0).UTC()
+	}
+
+	f := atomFeed{
+		Title:   feedTitle,
+		ID:      self,
+		Updated: updated.Format(time.RFC3339),
+		Author:  atomAuthor{Name: feedTitle},
+		Links: []atomLink{{
+			Href: self,
+			Rel:  "self",
+			Type: "application/atom+xml",
+		}, {
+			Href: base + "/",
+			Rel:  "alternate",
+		}},
+	}
+
+	for _, n := range ns {
+		link := newsLink(base, n)
+		f.Entries = append(f.Entries, atomEntry{
+			Title:   n.Header,
+			ID:      link,
+			Link:    atomLink{Href: link},
+			Updated: n.Date.UTC().Format(time.RFC3339),
+		})
+	}
+
+	return f
+}
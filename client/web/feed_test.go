@@ -0,0 +1,210 @@
+package web
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockListStorage struct {
+	mockStorage
+}
+
+func (s *mockListStorage) ListNews(ctx context.Context,
+	f entity.NewsFilter) ([]entity.News, error) {
+	args := s.Called(f)
+	return args.Get(0).([]entity.News), args.Error(1)
+}
+
+// testFeedNews have dates without time, as older storage peers send
+// them.
+var testFeedNews = []entity.News{{
+	ID:     2,
+	Header: "second & last",
+	Date:   time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC),
+}, {
+	ID:     1,
+	Header: "first",
+	Date:   time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+}}
+
+func TestServer_getFeed_rss(t *testing.T) {
+	ms := &mockListStorage{}
+	s := initServerWith(t, ms)
+	defer s.Stop()
+
+	ms.On("ListNews", entity.NewsFilter{Limit: DefaultFeedCount}).Return(
+		testFeedNews, nil)
+
+	res := serve(s, http.MethodGet, "/feed.rss", nil)
+
+	ms.AssertExpectations(t)
+
+	if !assert.Equal(t, http.StatusOK, res.Code) {
+		return
+	}
+
+	assert.Equal(t, "application/rss+xml; charset=utf-8",
+		res.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, "Thu, 03 Jan 2019 00:00:00 GMT",
+		res.Header().Get("Last-Modified"))
+	assert.NotEmpty(t, res.Header().Get("ETag"))
+
+	var r rss
+	err := xml.Unmarshal(res.Body.Bytes(), &r)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "2.0", r.Version)
+	assert.Equal(t, "http://example.com/feed.rss", r.Channel.Self.Href)
+	assert.Equal(t, []rssItem{{
+		Title: "second & last",
+		Link:  "http://example.com/news/2",
+		GUID: rssGUID{
+			IsPermaLink: true,
+			Value:       "http://example.com/news/2",
+		},
+		PubDate: "Thu, 03 Jan 2019 00:00:00 +0000",
+	}, {
+		Title: "first",
+		Link:  "http://example.com/news/1",
+		GUID: rssGUID{
+			IsPermaLink: true,
+			Value:       "http://example.com/news/1",
+		},
+		PubDate: "Wed, 02 Jan 2019 00:00:00 +0000",
+	}}, r.Channel.Items)
+}
+
+func TestServer_getFeed_atom(t *testing.T) {
+	ms := &mockListStorage{}
+	s := initServerWith(t, ms)
+	defer s.Stop()
+
+	s.SetFeedCount(5)
+
+	ms.On("ListNews", entity.NewsFilter{
+		From:  time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:    time.Date(2019, 1, 4, 0, 0, 0, 0, time.UTC).Add(-1),
+		Query: "first",
+		Limit: 5,
+	}).Return(testFeedNews[1:], nil)
+
+	res := serve(s, http.MethodGet,
+		"/feed.atom?from=2019-01-01&to=2019-01-03&query=first", nil)
+
+	ms.AssertExpectations(t)
+
+	if !assert.Equal(t, http.StatusOK, res.Code) {
+		return
+	}
+
+	assert.Equal(t, "application/atom+xml; charset=utf-8",
+		res.Header().Get(echo.HeaderContentType))
+
+	var f atomFeed
+	err := xml.Unmarshal(res.Body.Bytes(), &f)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "2019-01-02T00:00:00Z", f.Updated)
+	assert.Equal(t, []atomEntry{{
+		Title:   "first",
+		ID:      "http://example.com/news/1",
+		Link:    atomLink{Href: "http://example.com/news/1"},
+		Updated: "2019-01-02T00:00:00Z",
+	}}, f.Entries)
+}
+
+func TestServer_getFeed_count(t *testing.T) {
+	ms := &mockListStorage{}
+	s := initServerWith(t, ms)
+	defer s.Stop()
+
+	ms.On("ListNews", entity.NewsFilter{Limit: 1}).Return(
+		testFeedNews[:1], nil)
+
+	res := serve(s, http.MethodGet, "/feed.rss?count=1", nil)
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	for _, target := range []string{
+		"/feed.rss?count=0",
+		"/feed.rss?count=1001",
+		"/feed.rss?count=x",
+		"/feed.rss?from=yesterday",
+		"/feed.atom?tag=sport",
+	} {
+		res = serve(s, http.MethodGet, target, nil)
+		assert.Equal(t, http.StatusBadRequest, res.Code, target)
+	}
+
+	ms.AssertExpectations(t)
+}
+
+func TestServer_getFeed_notModified(t *testing.T) {
+	ms := &mockListStorage{}
+	s := initServerWith(t, ms)
+	defer s.Stop()
+
+	ms.On("ListNews", entity.NewsFilter{Limit: DefaultFeedCount}).Return(
+		testFeedNews, nil)
+
+	res := serve(s, http.MethodGet, "/feed.atom", nil)
+	if !assert.Equal(t, http.StatusOK, res.Code) {
+		return
+	}
+
+	etag := res.Header().Get("ETag")
+
+	res = serve(s, http.MethodGet, "/feed.atom",
+		map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, res.Code)
+	assert.Empty(t, res.Body.String())
+
+	res = serve(s, http.MethodGet, "/feed.atom",
+		map[string]string{"If-None-Match": `"other"`})
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	res = serve(s, http.MethodGet, "/feed.atom", map[string]string{
+		"If-Modified-Since": "Thu, 03 Jan 2019 00:00:00 GMT",
+	})
+	assert.Equal(t, http.StatusNotModified, res.Code)
+	assert.Empty(t, res.Body.String())
+
+	res = serve(s, http.MethodGet, "/feed.atom", map[string]string{
+		"If-Modified-Since": "Wed, 02 Jan 2019 23:59:59 GMT",
+	})
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	// ETag takes precedence, so feed changed within the same second is
+	// sent to readers which have sent both.
+	res = serve(s, http.MethodGet, "/feed.atom", map[string]string{
+		"If-None-Match":     `"other"`,
+		"If-Modified-Since": "Thu, 03 Jan 2019 00:00:00 GMT",
+	})
+	assert.Equal(t, http.StatusOK, res.Code)
+}
+
+func TestServer_getFeed_notImplemented(t *testing.T) {
+	ms := &mockStorage{}
+	s := initServerWith(t, ms)
+	defer s.Stop()
+
+	req := httptest.NewRequest(http.MethodGet, "/feed.rss", nil)
+	res := httptest.NewRecorder()
+
+	s.echo.ServeHTTP(res, req)
+
+	ms.AssertExpectations(t)
+
+	assert.Equal(t, http.StatusNotImplemented, res.Code)
+}
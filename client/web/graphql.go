@@ -0,0 +1,615 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/labstack/echo"
+)
+
+// BatchGetter is implemented by storages which get many news by one
+// request. GraphQL queries get news one by one from other storages.
+type BatchGetter interface {
+	NewsByIDs(ctx context.Context, ids []int64) ([]entity.News, error)
+}
+
+const (
+	// maxGraphQLDepth is maximum nesting of fields in GraphQL query.
+	maxGraphQLDepth = 5
+	// maxGraphQLComplexity is maximum estimated number of fields resolved
+	// by GraphQL query.
+	maxGraphQLComplexity = 5000
+
+	// defaultGraphQLListLimit and maxGraphQLListLimit are default and
+	// maximum limits of newsList query.
+	defaultGraphQLListLimit = 20
+	maxGraphQLListLimit     = 100
+	// maxGraphQLBatchIDs is maximum number of ids of newsBatch query and
+	// of news got by one storage request.
+	maxGraphQLBatchIDs = 1000
+)
+
+// newsLoaderKey is context key of newsLoader of GraphQL request.
+type newsLoaderKey struct{}
+
+// newsLoader collects news ids requested by resolvers of GraphQL query
+// and gets them by one storage request, so that query of many news
+// doesn't make request per news. It's made per GraphQL request.
+type newsLoader struct {
+	storage Storage
+
+	mu      sync.Mutex
+	pending []int64
+	news    map[int64]entity.News
+	errs    map[int64]error
+}
+
+func newNewsLoader(s Storage) *newsLoader {
+	return &newsLoader{
+		storage: s,
+		news:    map[int64]entity.News{},
+		errs:    map[int64]error{},
+	}
+}
+
+// load requests news id. Returned thunk gives news or nil if there is
+// no such news. Executor calls thunks after resolvers of sibling fields,
+// so news requested by them are got together.
+func (l *newsLoader) load(ctx context.Context, id int64) func() (
+	interface{}, error) {
+	l.mu.Lock()
+	l.pending = append(l.pending, id)
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if len(l.pending) > 0 {
+			l.fetch(ctx)
+		}
+
+		if err, ok := l.errs[id]; ok {
+			return nil, err
+		}
+		if n, ok := l.news[id]; ok {
+			return n, nil
+		}
+		return nil, nil
+	}
+}
+
+// fetch gets pending news which aren't got yet. Loader must be locked.
+func (l *newsLoader) fetch(ctx context.Context) {
+	var ids []int64
+
+	seen := map[int64]bool{}
+	for _, id := range l.pending {
+		_, got := l.news[id]
+		_, failed := l.errs[id]
+		if !got && !failed && !seen[id] {
+			ids = append(ids, id)
+			seen[id] = true
+		}
+	}
+
+	l.pending = nil
+
+	// Executor resolves fields in no particular order, sorting makes
+	// storage requests the same for the same query.
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	bg, ok := l.storage.(BatchGetter)
+	if !ok {
+		for _, id := range ids {
+			n, err := l.storage.News(ctx, id)
+			if err != nil {
+				if err != entity.ErrNewsNotFound {
+					l.errs[id] = errors.New("failed to get news: " +
+						err.Error())
+				}
+				continue
+			}
+			l.news[id] = n
+		}
+		return
+	}
+
+	for len(ids) > 0 {
+		batch := ids
+		if len(batch) > maxGraphQLBatchIDs {
+			batch = batch[:maxGraphQLBatchIDs]
+		}
+		ids = ids[len(batch):]
+
+		ns, err := bg.NewsByIDs(ctx, batch)
+		if err != nil {
+			err = errors.New("failed to get news: " + err.Error())
+			for _, id := range batch {
+				l.errs[id] = err
+			}
+			continue
+		}
+		for _, n := range ns {
+			l.news[n.ID] = n
+		}
+	}
+}
+
+func loaderFromContext(ctx context.Context) *newsLoader {
+	return ctx.Value(newsLoaderKey{}).(*newsLoader)
+}
+
+func parseNewsID(v interface{}) (int64, error) {
+	s, _ := v.(string)
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, errors.New("news id must be integer")
+	}
+	return id, nil
+}
+
+// newsPage is page of news listed by newsList query.
+type newsPage struct {
+	Items   []entity.News
+	Offset  int
+	Limit   int
+	HasMore bool
+}
+
+// newGraphQLSchema makes GraphQL schema which gets news from storage of
+// s.
+func (s *Server) newGraphQLSchema() (graphql.Schema, error) {
+	newsType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "News",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.ID),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return strconv.FormatInt(p.Source.(entity.News).ID, 10),
+						nil
+				},
+			},
+			"header": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(entity.News).Header, nil
+				},
+			},
+			"date": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.DateTime),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(entity.News).Date, nil
+				},
+			},
+			"externalId": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if id := p.Source.(entity.News).ExternalID; id != "" {
+						return id, nil
+					}
+					return nil, nil
+				},
+			},
+		},
+	})
+
+	newsPageType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "NewsPage",
+		Fields: graphql.Fields{
+			"items": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(
+					graphql.NewNonNull(newsType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(newsPage).Items, nil
+				},
+			},
+			"offset": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(newsPage).Offset, nil
+				},
+			},
+			"limit": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(newsPage).Limit, nil
+				},
+			},
+			"hasMore": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(newsPage).HasMore, nil
+				},
+			},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"news": &graphql.Field{
+				Type:        newsType,
+				Description: "News by id, null if there is no such news.",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.ID),
+					},
+				},
+				Resolve: s.resolveNews,
+			},
+			"newsBatch": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(newsType)),
+				Description: "News by ids in order of ids, null for " +
+					"missing ones.",
+				Args: graphql.FieldConfigArgument{
+					"ids": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.NewList(
+							graphql.NewNonNull(graphql.ID))),
+					},
+				},
+				Resolve: s.resolveNewsBatch,
+			},
+			"newsList": &graphql.Field{
+				Type: graphql.NewNonNull(newsPageType),
+				Description: "The latest news matched by dates in " +
+					"2006-01-02 format and header query.",
+				Args: graphql.FieldConfigArgument{
+					"from":  &graphql.ArgumentConfig{Type: graphql.String},
+					"to":    &graphql.ArgumentConfig{Type: graphql.String},
+					"query": &graphql.ArgumentConfig{Type: graphql.String},
+					"offset": &graphql.ArgumentConfig{
+						Type:         graphql.Int,
+						DefaultValue: 0,
+					},
+					"limit": &graphql.ArgumentConfig{
+						Type:         graphql.Int,
+						DefaultValue: defaultGraphQLListLimit,
+					},
+				},
+				Resolve: s.resolveNewsList,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
+
+func (s *Server) resolveNews(p graphql.ResolveParams) (interface{}, error) {
+	id, err := parseNewsID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+	return loaderFromContext(p.Context).load(p.Context, id), nil
+}
+
+func (s *Server) resolveNewsBatch(p graphql.ResolveParams) (interface{},
+	error) {
+	rawIDs, _ := p.Args["ids"].([]interface{})
+	if len(rawIDs) > maxGraphQLBatchIDs {
+		return nil, errors.New("ids must have at most " +
+			strconv.Itoa(maxGraphQLBatchIDs) + " items")
+	}
+
+	l := loaderFromContext(p.Context)
+
+	thunks := make([]func() (interface{}, error), 0, len(rawIDs))
+	for _, rawID := range rawIDs {
+		id, err := parseNewsID(rawID)
+		if err != nil {
+			return nil, err
+		}
+		thunks = append(thunks, l.load(p.Context, id))
+	}
+
+	return func() (interface{}, error) {
+		ns := make([]interface{}, 0, len(thunks))
+		for _, t := range thunks {
+			n, err := t()
+			if err != nil {
+				return nil, err
+			}
+			ns = append(ns, n)
+		}
+		return ns, nil
+	}, nil
+}
+
+func (s *Server) resolveNewsList(p graphql.ResolveParams) (interface{},
+	error) {
+	lst, ok := s.storage.(Lister)
+	if !ok {
+		return nil, errors.New("storage doesn't support listing")
+	}
+
+	from, _ := p.Args["from"].(string)
+	to, _ := p.Args["to"].(string)
+	query, _ := p.Args["query"].(string)
+
+	f, err := newsFilter(from, to, query)
+	if err != nil {
+		return nil, err
+	}
+
+	offset, _ := p.Args["offset"].(int)
+	if offset < 0 {
+		return nil, errors.New("offset must be non-negative")
+	}
+
+	limit, _ := p.Args["limit"].(int)
+	if limit < 1 || limit > maxGraphQLListLimit {
+		return nil, errors.New("limit must be from 1 to " +
+			strconv.Itoa(maxGraphQLListLimit))
+	}
+
+	// One more news tells whether there are more news.
+	f.Offset = int64(offset)
+	f.Limit = int64(limit + 1)
+
+	ns, err := lst.ListNews(p.Context, f)
+	if err != nil {
+		return nil, errors.New("failed to list news: " + err.Error())
+	}
+
+	page := newsPage{Items: ns, Offset: offset, Limit: limit}
+	if len(ns) > limit {
+		page.Items = ns[:limit]
+		page.HasMore = true
+	}
+
+	return page, nil
+}
+
+// queryCost estimates depth and complexity of operations of GraphQL
+// query doc. Complexity is number of resolved fields, fields of lists
+// are multiplied by limit or number of ids. Introspection fields are
+// free, so that playground and other tools work.
+type queryCost struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	// defaults are default values of variables of checked operation.
+	defaults map[string]ast.Value
+}
+
+func newQueryCost(doc *ast.Document,
+	variables map[string]interface{}) *queryCost {
+	qc := &queryCost{
+		fragments: map[string]*ast.FragmentDefinition{},
+		variables: variables,
+	}
+	for _, d := range doc.Definitions {
+		if fd, ok := d.(*ast.FragmentDefinition); ok {
+			qc.fragments[fd.Name.Value] = fd
+		}
+	}
+	return qc
+}
+
+// check returns error if any operation of doc exceeds limits.
+func (qc *queryCost) check(doc *ast.Document) error {
+	for _, d := range doc.Definitions {
+		od, ok := d.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+
+		qc.defaults = map[string]ast.Value{}
+		for _, vd := range od.VariableDefinitions {
+			if vd.DefaultValue != nil {
+				qc.defaults[vd.Variable.Name.Value] = vd.DefaultValue
+			}
+		}
+
+		depth, complexity := qc.selectionSet(od.SelectionSet)
+
+		if depth > maxGraphQLDepth {
+			return errors.New("query depth must be at most " +
+				strconv.Itoa(maxGraphQLDepth))
+		}
+		if complexity > maxGraphQLComplexity {
+			return errors.New("query complexity must be at most " +
+				strconv.Itoa(maxGraphQLComplexity))
+		}
+	}
+	return nil
+}
+
+// selectionSet returns depth and complexity of ss. Fragments are
+// expanded, query is validated before, so they have no cycles.
+func (qc *queryCost) selectionSet(ss *ast.SelectionSet) (depth,
+	complexity int) {
+	if ss == nil {
+		return 0, 0
+	}
+
+	for _, sel := range ss.Selections {
+		var d, c int
+
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(sel.Name.Value, "__") {
+				continue
+			}
+			d, c = qc.selectionSet(sel.SelectionSet)
+			d++
+			c = 1 + qc.multiplier(sel)*c
+		case *ast.InlineFragment:
+			d, c = qc.selectionSet(sel.SelectionSet)
+		case *ast.FragmentSpread:
+			if fd, ok := qc.fragments[sel.Name.Value]; ok {
+				d, c = qc.selectionSet(fd.SelectionSet)
+			}
+		}
+
+		if d > depth {
+			depth = d
+		}
+		complexity += c
+	}
+
+	return depth, complexity
+}
+
+// multiplier returns number of news resolved by field f.
+func (qc *queryCost) multiplier(f *ast.Field) int {
+	var name string
+	switch f.Name.Value {
+	case "newsList":
+		name = "limit"
+	case "newsBatch":
+		name = "ids"
+	default:
+		return 1
+	}
+
+	n := 0
+	if name == "limit" {
+		n = defaultGraphQLListLimit
+	}
+
+	for _, a := range f.Arguments {
+		if a.Name.Value == name {
+			n = qc.count(a.Value, n)
+		}
+	}
+
+	// Limits are checked by resolvers, they only mustn't overflow here.
+	if n < 1 {
+		n = 1
+	} else if n > maxGraphQLBatchIDs {
+		n = maxGraphQLBatchIDs
+	}
+
+	return n
+}
+
+// count returns int value or length of list value v. Variables which
+// aren't given by request have their default values. It returns n if v
+// is neither.
+func (qc *queryCost) count(v ast.Value, n int) int {
+	switch v := v.(type) {
+	case *ast.IntValue:
+		n, _ = strconv.Atoi(v.Value)
+	case *ast.ListValue:
+		n = len(v.Values)
+	case *ast.Variable:
+		vv, ok := qc.variables[v.Name.Value]
+		if !ok {
+			if d, ok := qc.defaults[v.Name.Value]; ok {
+				return qc.count(d, n)
+			}
+		}
+		switch vv := vv.(type) {
+		case float64:
+			n = int(vv)
+		case []interface{}:
+			n = len(vv)
+		}
+	}
+	return n
+}
+
+// graphQLRequest is GraphQL request given by JSON body of POST request
+// or by parameters of GET request.
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// graphQL executes GraphQL query. Query which can't be parsed, isn't
+// valid or exceeds limits is responded with 400 Bad Request, errors of
+// resolvers are responded along with data.
+func (s *Server) graphQL(c echo.Context) error {
+	var r graphQLRequest
+
+	if c.Request().Method == http.MethodGet {
+		r.Query = c.QueryParam("query")
+		r.OperationName = c.QueryParam("operationName")
+		if v := c.QueryParam("variables"); v != "" {
+			err := json.Unmarshal([]byte(v), &r.Variables)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest,
+					"failed to parse variables: "+err.Error())
+			}
+		}
+	} else {
+		err := json.NewDecoder(c.Request().Body).Decode(&r)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				"failed to parse request: "+err.Error())
+		}
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{Source: r.Query})
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &graphql.Result{
+			Errors: gqlerrors.FormatErrors(err),
+		})
+	}
+
+	vr := graphql.ValidateDocument(&s.graphQLSchema, doc, nil)
+	if !vr.IsValid {
+		return c.JSON(http.StatusBadRequest, &graphql.Result{
+			Errors: vr.Errors,
+		})
+	}
+
+	err = newQueryCost(doc, r.Variables).check(doc)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &graphql.Result{
+			Errors: gqlerrors.FormatErrors(err),
+		})
+	}
+
+	ctx := context.WithValue(c.Request().Context(), newsLoaderKey{},
+		newNewsLoader(s.storage))
+
+	res := graphql.Execute(graphql.ExecuteParams{
+		Schema:        s.graphQLSchema,
+		AST:           doc,
+		OperationName: r.OperationName,
+		Args:          r.Variables,
+		Context:       ctx,
+	})
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// graphQLPlaygroundPage is GraphQL Playground loaded from CDN. HTTP
+// headers of queries, like X-API-Key, are set in its settings.
+const graphQLPlaygroundPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>News GraphQL Playground</title>
+<link rel="stylesheet" href="https://cdn.jsdelivr.net/npm/graphql-playground-react@1.7.22/build/static/css/index.css">
+<script src="https://cdn.jsdelivr.net/npm/graphql-playground-react@1.7.22/build/static/js/middleware.js"></script>
+</head>
+<body>
+<div id="root"></div>
+<script>
+window.addEventListener("load", function () {
+	GraphQLPlayground.init(document.getElementById("root"), {
+		endpoint: "/graphql"
+	});
+});
+</script>
+</body>
+</html>
+`
+
+func (s *Server) graphQLPlayground(c echo.Context) error {
+	return c.HTML(http.StatusOK, graphQLPlaygroundPage)
+}
@@ -0,0 +1,230 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockBatchStorage struct {
+	mockStorage
+}
+
+func (s *mockBatchStorage) NewsByIDs(ctx context.Context, ids []int64) (
+	[]entity.News, error) {
+	args := s.Called(ids)
+	return args.Get(0).([]entity.News), args.Error(1)
+}
+
+func postGraphQL(s *Server, query string,
+	variables map[string]interface{}) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(graphQLRequest{
+		Query:     query,
+		Variables: variables,
+	})
+	req := httptest.NewRequest(http.MethodPost, "/graphql",
+		strings.NewReader(string(body)))
+	res := httptest.NewRecorder()
+	s.echo.ServeHTTP(res, req)
+	return res
+}
+
+func TestServer_graphQL_batch(t *testing.T) {
+	ms := &mockBatchStorage{}
+	s := initServerWith(t, ms)
+	defer s.Stop()
+
+	date := time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	// All news of query are got by one request.
+	ms.On("NewsByIDs", []int64{1, 2, 3}).Return([]entity.News{
+		{ID: 2, Header: "second", Date: date},
+		{ID: 1, Header: "first", Date: date, ExternalID: "ext-1"},
+	}, nil).Once()
+
+	res := postGraphQL(s, `{
+		a: news(id: "1") { id header date externalId }
+		b: news(id: "2") { id externalId }
+		newsBatch(ids: ["3", "2"]) { id }
+	}`, nil)
+
+	ms.AssertExpectations(t)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.JSONEq(t, `{"data": {
+		"a": {"id": "1", "header": "first", "date": "2019-01-02T00:00:00Z",
+			"externalId": "ext-1"},
+		"b": {"id": "2", "externalId": null},
+		"newsBatch": [null, {"id": "2"}]
+	}}`, res.Body.String())
+}
+
+func TestServer_graphQL_news(t *testing.T) {
+	ms := &mockStorage{}
+	s := initServerWith(t, ms)
+	defer s.Stop()
+
+	// Storage which isn't BatchGetter is requested news by news.
+	ms.On("News", int64(1)).Return(entity.News{ID: 1, Header: "first"},
+		nil)
+	ms.On("News", int64(2)).Return(entity.News{}, entity.ErrNewsNotFound)
+
+	res := postGraphQL(s, `query($id: ID!) {
+		a: news(id: $id) { header }
+		b: news(id: "2") { header }
+	}`, map[string]interface{}{"id": "1"})
+
+	ms.AssertExpectations(t)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.JSONEq(t, `{"data": {"a": {"header": "first"}, "b": null}}`,
+		res.Body.String())
+}
+
+func TestServer_graphQL_newsList(t *testing.T) {
+	ms := &mockListStorage{}
+	s := initServerWith(t, ms)
+	defer s.Stop()
+
+	ms.On("ListNews", entity.NewsFilter{
+		From:   time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+		Query:  "news",
+		Offset: 1,
+		Limit:  2,
+	}).Return(testFeedNews, nil)
+
+	res := serve(s, http.MethodGet, `/graphql?query=`+
+		`{newsList(from:"2019-01-02",query:"news",offset:1,limit:1)`+
+		`{items{id}offset,limit,hasMore}}`, nil)
+
+	ms.AssertExpectations(t)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.JSONEq(t, `{"data": {"newsList": {"items": [{"id": "2"}],
+		"offset": 1, "limit": 1, "hasMore": true}}}`, res.Body.String())
+}
+
+func TestServer_graphQL_badRequest(t *testing.T) {
+	ms := &mockStorage{}
+	s := initServerWith(t, ms)
+	defer s.Stop()
+
+	ids := make([]interface{}, maxGraphQLBatchIDs)
+	for i := range ids {
+		ids[i] = strconv.Itoa(i)
+	}
+
+	for _, c := range []struct {
+		query     string
+		variables map[string]interface{}
+	}{
+		{query: `{news(id: "1") {`},
+		{query: `{news(id: "1") { body }}`},
+		// Complexity of each field is about 1000 * 3.
+		{
+			query: `query($ids: [ID!]!) {
+				a: newsBatch(ids: $ids) { id header date }
+				b: newsBatch(ids: $ids) { id header date }
+			}`,
+			variables: map[string]interface{}{"ids": ids},
+		},
+	} {
+		res := postGraphQL(s, c.query, c.variables)
+		assert.Equal(t, http.StatusBadRequest, res.Code, c.query)
+		assert.Contains(t, res.Body.String(), `"errors"`, c.query)
+	}
+
+	ms.AssertExpectations(t)
+}
+
+func TestQueryCost_check(t *testing.T) {
+	ids := make([]string, 1000)
+	for i := range ids {
+		ids[i] = strconv.Itoa(i + 1)
+	}
+
+	// Aliases of batch which get many ids from default of variable.
+	batches := "query($ids: [ID!] = [" + strings.Join(ids, ", ") + "]) {"
+	for i := 0; i < 6; i++ {
+		batches += " b" + strconv.Itoa(i) + ": newsBatch(ids: $ids) { id }"
+	}
+	batches += " }"
+
+	for _, c := range []struct {
+		query     string
+		variables map[string]interface{}
+		err       string
+	}{
+		{query: `{ newsList(limit: 100) { items { id header } } }`},
+		{query: `{ __schema { types { fields { type { ofType {
+			ofType { name } } } } } } }`},
+		{
+			query: `{ a { b { c { d { e { f } } } } } }`,
+			err:   "query depth must be at most 5",
+		},
+		{
+			query: `{ a { ...f } }
+				fragment f on A { b { c { d { e { f } } } } }`,
+			err: "query depth must be at most 5",
+		},
+		{
+			query: `{ a: newsList(limit: 1000) { items { id header } }
+				b: newsList(limit: 1000) { items { id header } } }`,
+			err: "query complexity must be at most 5000",
+		},
+		{
+			query: batches,
+			err:   "query complexity must be at most 5000",
+		},
+		{
+			query: `query($n: Int = 1000) {
+				a: newsList(limit: $n) { items { id header } }
+				b: newsList(limit: $n) { items { id header } } }`,
+			err: "query complexity must be at most 5000",
+		},
+		{
+			query: `query($n: Int = 1000) {
+				a: newsList(limit: $n) { items { id header } }
+				b: newsList(limit: $n) { items { id header } } }`,
+			variables: map[string]interface{}{"n": 10.0},
+		},
+	} {
+		doc, err := parser.Parse(parser.ParseParams{Source: c.query})
+		if !assert.NoError(t, err, c.query) {
+			continue
+		}
+
+		err = newQueryCost(doc, c.variables).check(doc)
+		if c.err == "" {
+			assert.NoError(t, err, c.query)
+		} else if assert.Error(t, err, c.query) {
+			assert.Equal(t, c.err, err.Error(), c.query)
+		}
+	}
+}
+
+func TestServer_graphQLPlayground(t *testing.T) {
+	s := initServerWith(t, &mockStorage{})
+	res := serve(s, http.MethodGet, "/graphql/playground", nil)
+	s.Stop()
+	assert.Equal(t, http.StatusNotFound, res.Code)
+
+	s = NewServer(&mockStorage{}, "", 10*time.Second, TLSConfig{})
+	s.SetGraphQLPlayground(true)
+	require.NoError(t, s.Start())
+	defer s.Stop()
+
+	res = serve(s, http.MethodGet, "/graphql/playground", nil)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Contains(t, res.Body.String(), "GraphQLPlayground.init")
+}
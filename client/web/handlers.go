@@ -2,14 +2,24 @@ package web
 
 import (
 	"errors"
+	"mime"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/newsio"
 	"github.com/labstack/echo"
 )
 
 func (s *Server) getNews(c echo.Context) error {
+	c.Response().Header().Add(echo.HeaderVary, echo.HeaderAccept)
+
+	mt, err := negotiateNews(c)
+	if err != nil {
+		return err
+	}
+
 	id, err := strconv.ParseInt(c.Param("news_id"), 10, 64)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest,
@@ -24,5 +34,227 @@ func (s *Server) getNews(c echo.Context) error {
 		return errors.New("failed to get news from storage: " + err.Error())
 	}
 
-	return c.JSON(http.StatusOK, news)
+	return s.writeNews(c, news, mt)
+}
+
+// postNewsOp dispatches custom methods of news collection.
+func (s *Server) postNewsOp(c echo.Context) error {
+	switch c.Param("op") {
+	case ":import":
+		return s.importNews(c)
+	}
+	return echo.ErrNotFound
+}
+
+// importFormat returns format of imported news from format query
+// parameter or request content type.
+func importFormat(c echo.Context) (string, error) {
+	if f := c.QueryParam("format"); f != "" {
+		if f != newsio.FormatJSONL && f != newsio.FormatCSV {
+			return "", errors.New("format must be jsonl or csv")
+		}
+		return f, nil
+	}
+
+	ct := c.Request().Header.Get(echo.HeaderContentType)
+	if mt, _, err := mime.ParseMediaType(ct); err == nil && mt == "text/csv" {
+		return newsio.FormatCSV, nil
+	}
+
+	return newsio.FormatJSONL, nil
+}
+
+// boolParam parses boolean query parameter, missing one is false.
+func boolParam(c echo.Context, name string) (bool, error) {
+	v := c.QueryParam(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, errors.New(name + " must be boolean")
+	}
+	return b, nil
+}
+
+// importNews imports news streamed in request body as JSONL or CSV.
+// Options are given by dry_run, atomic and upsert query parameters.
+func (s *Server) importNews(c echo.Context) error {
+	imp, ok := s.storage.(Importer)
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotImplemented,
+			"storage doesn't support import")
+	}
+
+	format, err := importFormat(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	var o entity.ImportOptions
+
+	for name, v := range map[string]*bool{
+		"dry_run": &o.DryRun,
+		"atomic":  &o.Atomic,
+		"upsert":  &o.Upsert,
+	} {
+		*v, err = boolParam(c, name)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+
+	src, err := newsio.NewReader(c.Request().Body, format)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	res, err := imp.ImportNews(c.Request().Context(), src, o)
+	if err != nil {
+		return errors.New("failed to import news: " + err.Error())
+	}
+
+	if o.Atomic && res.Failed > 0 {
+		return c.JSON(http.StatusUnprocessableEntity, res)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// getNewsOp dispatches custom methods of news collection.
+func (s *Server) getNewsOp(c echo.Context) error {
+	switch c.Param("op") {
+	case ":export":
+		return s.exportNews(c)
+	}
+	return echo.ErrNotFound
+}
+
+// exportFlushInterval is number of exported news after which response
+// is flushed to client.
+const exportFlushInterval = 1000
+
+// parseDate parses date v of parameter name, empty v is zero time.
+func parseDate(name, v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", v, time.UTC)
+	if err != nil {
+		return time.Time{}, errors.New(name +
+			" must be date in 2006-01-02 format")
+	}
+	return t, nil
+}
+
+// newsFilter makes news filter from from and to dates and query.
+func newsFilter(from, to, query string) (entity.NewsFilter, error) {
+	var (
+		f   entity.NewsFilter
+		err error
+	)
+
+	f.From, err = parseDate("from", from)
+	if err != nil {
+		return entity.NewsFilter{}, err
+	}
+
+	f.To, err = parseDate("to", to)
+	if err != nil {
+		return entity.NewsFilter{}, err
+	}
+
+	// To is a day, so it must include news from the whole day.
+	if !f.To.IsZero() {
+		f.To = f.To.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	f.Query = query
+
+	return f, nil
+}
+
+// filterParams parses news filter from from, to and query parameters.
+func filterParams(c echo.Context) (entity.NewsFilter, error) {
+	return newsFilter(c.QueryParam("from"), c.QueryParam("to"),
+		c.QueryParam("query"))
+}
+
+// exportNews streams news matched by from, to and query parameters as
+// JSONL, CSV or length-delimited protobuf selected by format parameter.
+func (s *Server) exportNews(c echo.Context) error {
+	exp, ok := s.storage.(Exporter)
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotImplemented,
+			"storage doesn't support export")
+	}
+
+	format := c.QueryParam("format")
+	switch format {
+	case "":
+		format = newsio.FormatJSONL
+	case newsio.FormatJSONL, newsio.FormatCSV, newsio.FormatProto:
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest,
+			"format must be jsonl, csv or protobuf")
+	}
+
+	f, err := filterParams(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	res := c.Response()
+
+	w, err := newsio.NewWriter(res, format)
+	if err != nil {
+		return err
+	}
+
+	res.Header().Set(echo.HeaderContentType, newsio.ContentType(format))
+	res.Header().Set(echo.HeaderContentDisposition,
+		`attachment; filename="news.`+format+`"`)
+	res.WriteHeader(http.StatusOK)
+
+	var n int
+
+	err = exp.ExportNews(c.Request().Context(), f,
+		func(news entity.News) error {
+			err := w.Write(news)
+			if err != nil {
+				return err
+			}
+			n++
+			if n%exportFlushInterval == 0 {
+				err = w.Flush()
+				if err != nil {
+					return err
+				}
+				res.Flush()
+			}
+			return nil
+		})
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		s.log.WithError(err).Error("failed to export news")
+		abortResponse(res)
+	}
+
+	return nil
+}
+
+// abortResponse closes connection of started response, so that client
+// sees it is cut short instead of getting truncated, but complete one.
+func abortResponse(res *echo.Response) {
+	hj, ok := res.Writer.(http.Hijacker)
+	if !ok {
+		return
+	}
+	conn, _, err := hj.Hijack()
+	if err != nil {
+		return
+	}
+	conn.Close()
 }
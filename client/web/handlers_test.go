@@ -16,6 +16,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMain(m *testing.M) {
@@ -32,15 +33,16 @@ func (s *mockStorage) News(ctx context.Context, id int64) (entity.News, error) {
 	return args.Get(0).(entity.News), args.Error(1)
 }
 
-func initServer() (*mockStorage, *Server) {
-	ms := &mockStorage{}
-	s := NewServer(ms, "")
-	s.Start()
-	return ms, s
+// initServerWith starts server of storage with default settings.
+func initServerWith(t *testing.T, storage Storage) *Server {
+	s := NewServer(storage, "", 10*time.Second, TLSConfig{})
+	require.NoError(t, s.Start())
+	return s
 }
 
 func TestServer_getNews_badRequest(t *testing.T) {
-	ms, s := initServer()
+	ms := &mockStorage{}
+	s := initServerWith(t, ms)
 	defer s.Stop()
 
 	req := httptest.NewRequest(http.MethodGet, "/news/asd", nil)
@@ -54,7 +56,8 @@ func TestServer_getNews_badRequest(t *testing.T) {
 }
 
 func TestServer_getNews_internalError(t *testing.T) {
-	ms, s := initServer()
+	ms := &mockStorage{}
+	s := initServerWith(t, ms)
 	defer s.Stop()
 
 	ms.On("News", int64(123)).Return(entity.News{},
@@ -71,7 +74,8 @@ func TestServer_getNews_internalError(t *testing.T) {
 }
 
 func TestServer_getNews_notFound(t *testing.T) {
-	ms, s := initServer()
+	ms := &mockStorage{}
+	s := initServerWith(t, ms)
 	defer s.Stop()
 
 	ms.On("News", int64(123)).Return(entity.News{},
@@ -88,7 +92,8 @@ func TestServer_getNews_notFound(t *testing.T) {
 }
 
 func TestServer_getNews_success(t *testing.T) {
-	ms, s := initServer()
+	ms := &mockStorage{}
+	s := initServerWith(t, ms)
 	defer s.Stop()
 
 	testTime, _ := time.Parse("2006-01-02", "2006-01-02")
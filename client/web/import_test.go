@@ -0,0 +1,175 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockImportStorage struct {
+	mockStorage
+}
+
+// ImportNews reads all records from src so that tests can check them.
+func (s *mockImportStorage) ImportNews(ctx context.Context,
+	src entity.ImportSource, o entity.ImportOptions) (entity.ImportResult,
+	error) {
+	var (
+		recs []entity.ImportRecord
+		r    entity.ImportResult
+	)
+	for {
+		rec, err := src.Next()
+		if err == io.EOF {
+			break
+		}
+		r.Total++
+		if ie, ok := err.(*entity.ImportError); ok {
+			r.AddError(*ie)
+			continue
+		}
+		if err != nil {
+			return r, err
+		}
+		recs = append(recs, rec)
+	}
+	args := s.Called(recs, o)
+	res := args.Get(0).(entity.ImportResult)
+	res.Total, res.Failed, res.Errors = r.Total, r.Failed, r.Errors
+	return res, args.Error(1)
+}
+
+func TestServer_importNews_notImplemented(t *testing.T) {
+	s := initServerWith(t, &mockStorage{})
+	defer s.Stop()
+
+	req := httptest.NewRequest(http.MethodPost, "/news:import",
+		strings.NewReader(""))
+	res := httptest.NewRecorder()
+
+	s.echo.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNotImplemented, res.Code)
+}
+
+func TestServer_postNewsOp_unknown(t *testing.T) {
+	s := initServerWith(t, &mockImportStorage{})
+	defer s.Stop()
+
+	req := httptest.NewRequest(http.MethodPost, "/news:unknown",
+		strings.NewReader(""))
+	res := httptest.NewRecorder()
+
+	s.echo.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNotFound, res.Code)
+}
+
+func TestServer_importNews_badRequest(t *testing.T) {
+	s := initServerWith(t, &mockImportStorage{})
+	defer s.Stop()
+
+	for _, target := range []string{
+		"/news:import?format=xml",
+		"/news:import?dry_run=maybe",
+		"/news:import?format=csv",
+	} {
+		req := httptest.NewRequest(http.MethodPost, target,
+			strings.NewReader("id,name\n"))
+		res := httptest.NewRecorder()
+
+		s.echo.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusBadRequest, res.Code, target)
+	}
+}
+
+func TestServer_importNews_jsonl(t *testing.T) {
+	ms := &mockImportStorage{}
+	s := initServerWith(t, ms)
+	defer s.Stop()
+
+	date := time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	ms.On("ImportNews", []entity.ImportRecord{{
+		Line: 1,
+		News: entity.News{ExternalID: "a", Header: "first", Date: date},
+	}, {
+		Line: 4,
+		News: entity.News{Header: "second", Date: date},
+	}}, entity.ImportOptions{DryRun: true, Upsert: true}).Return(
+		entity.ImportResult{Created: 1, Updated: 1}, nil)
+
+	req := httptest.NewRequest(http.MethodPost,
+		"/news:import?dry_run=true&upsert=1", strings.NewReader(
+			`{"external_id":"a","header":"first","date":"2019-01-02"}`+"\n"+
+				`{"header":"","date":"2019-01-02"}`+"\n"+
+				"\n"+
+				`{"header":"second","date":"2019-01-02T00:00:00Z"}`+"\n"+
+				`{"header":`+"\n"))
+	res := httptest.NewRecorder()
+
+	s.echo.ServeHTTP(res, req)
+
+	ms.AssertExpectations(t)
+
+	if !assert.Equal(t, http.StatusOK, res.Code) {
+		return
+	}
+
+	var got entity.ImportResult
+
+	err := json.NewDecoder(res.Body).Decode(&got)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, 4, got.Total)
+	assert.Equal(t, 1, got.Created)
+	assert.Equal(t, 1, got.Updated)
+	assert.Equal(t, 2, got.Failed)
+	if assert.Len(t, got.Errors, 2) {
+		assert.Equal(t, entity.ImportError{
+			Line:    2,
+			Message: "header is required",
+		}, got.Errors[0])
+		assert.Equal(t, 5, got.Errors[1].Line)
+	}
+}
+
+func TestServer_importNews_csvAtomic(t *testing.T) {
+	ms := &mockImportStorage{}
+	s := initServerWith(t, ms)
+	defer s.Stop()
+
+	ms.On("ImportNews", []entity.ImportRecord{{
+		Line: 2,
+		News: entity.News{
+			ExternalID: "a",
+			Header:     "first, quoted",
+			Date:       time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+	}}, entity.ImportOptions{Atomic: true}).Return(
+		entity.ImportResult{}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/news:import?atomic=true",
+		strings.NewReader("Date,Header,External_ID\n"+
+			"2019-01-02,\"first, quoted\",a\n"+
+			"yesterday,second,b\n"))
+	req.Header.Set("Content-Type", "text/csv; charset=utf-8")
+	res := httptest.NewRecorder()
+
+	s.echo.ServeHTTP(res, req)
+
+	ms.AssertExpectations(t)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+}
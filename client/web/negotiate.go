@@ -0,0 +1,194 @@
+package web
+
+import (
+	"encoding/xml"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/storage/pb"
+	"github.com/golang/protobuf/proto"
+	"github.com/labstack/echo"
+)
+
+// Representations of news negotiated by getNews.
+const (
+	newsJSON  = "json"
+	newsXML   = "xml"
+	newsProto = "protobuf"
+	newsHTML  = "html"
+)
+
+// newsMediaType is media type of news representation. Media types are
+// in order of preference, so that JSON is given to clients which
+// accept anything.
+type newsMediaType struct {
+	mediaType string
+	format    string
+}
+
+var newsMediaTypes = []newsMediaType{
+	{"application/json", newsJSON},
+	{"application/xml", newsXML},
+	{"text/xml", newsXML},
+	{"application/x-protobuf", newsProto},
+	{"application/protobuf", newsProto},
+	{"text/html", newsHTML},
+}
+
+// acceptRange is media range of Accept header.
+type acceptRange struct {
+	typ     string
+	subtype string
+	q       float64
+}
+
+// parseAccept parses media ranges of Accept header h. Invalid ranges
+// are skipped.
+func parseAccept(h string) []acceptRange {
+	var rs []acceptRange
+
+	for _, part := range strings.Split(h, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		i := strings.Index(mt, "/")
+		if i < 0 {
+			continue
+		}
+
+		r := acceptRange{typ: mt[:i], subtype: mt[i+1:], q: 1}
+
+		if v, ok := params["q"]; ok {
+			r.q, err = strconv.ParseFloat(v, 64)
+			if err != nil || r.q < 0 || r.q > 1 {
+				continue
+			}
+		}
+
+		rs = append(rs, r)
+	}
+
+	return rs
+}
+
+// quality returns quality of media type mt given by the most specific
+// of ranges rs which matches it, 0 if none matches.
+func quality(rs []acceptRange, mt string) float64 {
+	i := strings.Index(mt, "/")
+	typ, subtype := mt[:i], mt[i+1:]
+
+	var (
+		q           float64
+		specificity = -1
+	)
+
+	for _, r := range rs {
+		var s int
+		switch {
+		case r.typ == typ && r.subtype == subtype:
+			s = 2
+		case r.typ == typ && r.subtype == "*":
+			s = 1
+		case r.typ == "*" && r.subtype == "*":
+			s = 0
+		default:
+			continue
+		}
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+
+	return q
+}
+
+// negotiateNews selects news representation by format parameter or
+// Accept header. Missing Accept header accepts anything.
+func negotiateNews(c echo.Context) (newsMediaType, error) {
+	if f := c.QueryParam("format"); f != "" {
+		for _, mt := range newsMediaTypes {
+			if mt.format == f {
+				return mt, nil
+			}
+		}
+		return newsMediaType{}, echo.NewHTTPError(http.StatusBadRequest,
+			"format must be json, xml, protobuf or html")
+	}
+
+	accept := c.Request().Header.Get(echo.HeaderAccept)
+	if strings.TrimSpace(accept) == "" {
+		return newsMediaTypes[0], nil
+	}
+
+	rs := parseAccept(accept)
+
+	var (
+		best  newsMediaType
+		bestQ float64
+	)
+
+	for _, mt := range newsMediaTypes {
+		if q := quality(rs, mt.mediaType); q > bestQ {
+			best, bestQ = mt, q
+		}
+	}
+
+	if bestQ == 0 {
+		return newsMediaType{}, echo.NewHTTPError(http.StatusNotAcceptable,
+			"news are available as application/json, application/xml, "+
+				"application/x-protobuf or text/html")
+	}
+
+	return best, nil
+}
+
+// xmlNews is XML representation of news.
+type xmlNews struct {
+	XMLName    xml.Name  `xml:"news"`
+	ID         int64     `xml:"id"`
+	Header     string    `xml:"header"`
+	Date       time.Time `xml:"date"`
+	ExternalID string    `xml:"external_id,omitempty"`
+}
+
+// writeNews responds with news n in representation mt.
+func (s *Server) writeNews(c echo.Context, n entity.News,
+	mt newsMediaType) error {
+	var (
+		data []byte
+		err  error
+	)
+
+	contentType := mt.mediaType
+
+	switch mt.format {
+	case newsJSON:
+		return c.JSON(http.StatusOK, s.newsJSON(c, n))
+	case newsXML:
+		data, err = xml.Marshal(xmlNews{
+			ID:         n.ID,
+			Header:     n.Header,
+			Date:       n.Date,
+			ExternalID: n.ExternalID,
+		})
+		data = append([]byte(xml.Header), data...)
+		contentType += "; charset=UTF-8"
+	case newsProto:
+		data, err = proto.Marshal(pb.FromNews(n))
+	case newsHTML:
+		return s.writeArticlePage(c, n)
+	}
+	if err != nil {
+		return errors.New("failed to render news as " + mt.format + ": " +
+			err.Error())
+	}
+
+	return c.Blob(http.StatusOK, contentType, data)
+}
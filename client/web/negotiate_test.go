@@ -0,0 +1,118 @@
+package web
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/storage/pb"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_getNews_negotiation(t *testing.T) {
+	ms := &mockStorage{}
+	s := initServerWith(t, ms)
+	defer s.Stop()
+
+	n := entity.News{
+		ID:     123,
+		Header: "<b>header</b>",
+		Date:   time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	ms.On("News", int64(123)).Return(n, nil)
+
+	for _, c := range []struct {
+		target      string
+		accept      string
+		contentType string
+	}{
+		{"/news/123", "", "application/json; charset=UTF-8"},
+		{"/news/123", "*/*", "application/json; charset=UTF-8"},
+		{"/news/123", "application/xml", "application/xml; charset=UTF-8"},
+		{"/news/123", "text/*", "text/xml; charset=UTF-8"},
+		// Browsers prefer HTML to XML.
+		{"/news/123",
+			"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+			"text/html; charset=UTF-8"},
+		{"/news/123", "application/json;q=0.5, application/x-protobuf",
+			"application/x-protobuf"},
+		{"/news/123", "application/*;q=0.1, application/json;q=0",
+			"application/xml; charset=UTF-8"},
+		// Format parameter overrides Accept header.
+		{"/news/123?format=html", "application/json",
+			"text/html; charset=UTF-8"},
+	} {
+		res := serve(s, http.MethodGet, c.target,
+			map[string]string{"Accept": c.accept})
+		if assert.Equal(t, http.StatusOK, res.Code, c.accept) {
+			assert.Equal(t, c.contentType, res.Header().Get("Content-Type"),
+				c.accept)
+			assert.Equal(t, "Accept", res.Header().Get("Vary"), c.accept)
+		}
+	}
+}
+
+func TestServer_getNews_representations(t *testing.T) {
+	ms := &mockStorage{}
+	s := initServerWith(t, ms)
+	defer s.Stop()
+
+	n := entity.News{
+		ID:         123,
+		Header:     "<b>header</b>",
+		Date:       time.Date(2019, 1, 2, 12, 30, 0, 0, time.UTC),
+		ExternalID: "ext",
+	}
+
+	ms.On("News", int64(123)).Return(n, nil)
+
+	res := serve(s, http.MethodGet, "/news/123?format=xml", nil)
+	var xn xmlNews
+	if assert.NoError(t, xml.Unmarshal(res.Body.Bytes(), &xn)) {
+		assert.Equal(t, "news", xn.XMLName.Local)
+		assert.Equal(t, n, entity.News{
+			ID:         xn.ID,
+			Header:     xn.Header,
+			Date:       xn.Date,
+			ExternalID: xn.ExternalID,
+		})
+	}
+
+	res = serve(s, http.MethodGet, "/news/123?format=protobuf", nil)
+	var pn pb.News
+	if assert.NoError(t, proto.Unmarshal(res.Body.Bytes(), &pn)) {
+		assert.Equal(t, int64(123), pn.Id)
+		assert.Equal(t, "2019-01-02", pn.Date)
+		assert.Equal(t, "2019-01-02T12:30:00Z", pn.PublishedAt)
+		assert.Equal(t, "ext", pn.ExternalId)
+	}
+
+	res = serve(s, http.MethodGet, "/news/123?format=html", nil)
+	body := res.Body.String()
+	assert.True(t, strings.HasPrefix(body, "<!DOCTYPE html>"))
+	assert.Contains(t, body, "<h1>&lt;b&gt;header&lt;/b&gt;</h1>")
+	assert.Contains(t, body,
+		`<time datetime="2019-01-02T12:30:00Z">2 January 2019</time>`)
+}
+
+func TestServer_getNews_notAcceptable(t *testing.T) {
+	ms := &mockStorage{}
+	s := initServerWith(t, ms)
+	defer s.Stop()
+
+	res := serve(s, http.MethodGet, "/news/123",
+		map[string]string{"Accept": "image/png, application/json;q=0"})
+	assert.Equal(t, http.StatusNotAcceptable, res.Code)
+	assert.Equal(t, "Accept", res.Header().Get("Vary"))
+
+	res = serve(s, http.MethodGet, "/news/123?format=yaml", nil)
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+
+	// Storage isn't requested for unacceptable news.
+	ms.AssertExpectations(t)
+}
@@ -0,0 +1,763 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+)
+
+// openAPISpec is OpenAPI 3 specification of web API. Contract test
+// checks that it describes every route and responses of handlers, so it
+// must be changed along with them.
+const openAPISpec = `{
+  "openapi": "3.0.3",
+  "info": {
+    "title": "News web API",
+    "version": "2.0.0",
+    "description": "Errors of versioned routes are responded as RFC 7807 problem details, other routes respond with plain text errors. Routes are open if authentication is disabled, otherwise reader role is required for reading and editor role for writing. News routes are versioned, v2 ones are described here. Deprecated v1 routes under /v1 and the same routes without version prefix give news JSON with date instead of published_at and url fields. They are answered with Deprecation header, Sunset one if their removal date is set and Link header with successor-version of the same resource."
+  },
+  "security": [
+    {"apiKey": []},
+    {"bearerAuth": []}
+  ],
+  "paths": {
+    "/news": {
+      "get": {
+        "operationId": "getNewsList",
+        "summary": "HTML page of the latest news",
+        "parameters": [
+          {"$ref": "#/components/parameters/From"},
+          {"$ref": "#/components/parameters/To"},
+          {"$ref": "#/components/parameters/Query"},
+          {
+            "name": "page",
+            "in": "query",
+            "description": "Number of page, 20 news each.",
+            "schema": {"type": "integer", "minimum": 1, "default": 1}
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "Page with canonical URL, OpenGraph and Twitter card metadata and links to newer and older pages.",
+            "content": {
+              "text/html": {}
+            }
+          },
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "429": {"$ref": "#/components/responses/TooManyRequests"},
+          "500": {"$ref": "#/components/responses/InternalError"},
+          "501": {"$ref": "#/components/responses/NotImplemented"}
+        }
+      }
+    },
+    "/v2/news/{news_id}": {
+      "get": {
+        "operationId": "getNews",
+        "summary": "News by id",
+        "description": "Representation is negotiated by Accept header, format parameter overrides it. JSON is responded if Accept header is missing or accepts anything.",
+        "parameters": [
+          {
+            "name": "news_id",
+            "in": "path",
+            "required": true,
+            "schema": {"type": "integer", "format": "int64"}
+          },
+          {
+            "name": "format",
+            "in": "query",
+            "schema": {
+              "type": "string",
+              "enum": ["json", "xml", "protobuf", "html"]
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "News as JSON, XML news element with id, header, date and optional external_id elements, News message of NATS protocol with date in 2006-01-02 format or HTML page with canonical URL, OpenGraph and Twitter card metadata and schema.org NewsArticle in JSON-LD.",
+            "headers": {
+              "Vary": {"$ref": "#/components/headers/Vary"}
+            },
+            "content": {
+              "application/json": {
+                "schema": {"$ref": "#/components/schemas/News"}
+              },
+              "application/xml": {},
+              "text/xml": {},
+              "application/x-protobuf": {},
+              "application/protobuf": {},
+              "text/html": {}
+            }
+          },
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"},
+          "406": {"$ref": "#/components/responses/NotAcceptable"},
+          "429": {"$ref": "#/components/responses/TooManyRequests"},
+          "500": {"$ref": "#/components/responses/InternalError"}
+        }
+      }
+    },
+    "/v2/news:export": {
+      "get": {
+        "operationId": "exportNews",
+        "summary": "Export news as a stream",
+        "parameters": [
+          {"$ref": "#/components/parameters/From"},
+          {"$ref": "#/components/parameters/To"},
+          {"$ref": "#/components/parameters/Query"},
+          {
+            "name": "format",
+            "in": "query",
+            "schema": {
+              "type": "string",
+              "enum": ["jsonl", "csv", "protobuf"],
+              "default": "jsonl"
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "News as JSON lines, CSV with id, header, date and external_id columns or length-delimited protobuf messages. Connection is closed before the end if export fails.",
+            "content": {
+              "application/x-ndjson": {},
+              "text/csv": {},
+              "application/x-protobuf": {}
+            }
+          },
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "429": {"$ref": "#/components/responses/TooManyRequests"},
+          "500": {"$ref": "#/components/responses/InternalError"},
+          "501": {"$ref": "#/components/responses/NotImplemented"}
+        }
+      }
+    },
+    "/v2/news:import": {
+      "post": {
+        "operationId": "importNews",
+        "summary": "Import news in bulk",
+        "parameters": [
+          {
+            "name": "format",
+            "in": "query",
+            "description": "Format of body, content type selects it if it is missing.",
+            "schema": {"type": "string", "enum": ["jsonl", "csv"]}
+          },
+          {
+            "name": "dry_run",
+            "in": "query",
+            "description": "Validate news without saving them.",
+            "schema": {"type": "boolean", "default": false}
+          },
+          {
+            "name": "atomic",
+            "in": "query",
+            "description": "Save no news if any of them fails.",
+            "schema": {"type": "boolean", "default": false}
+          },
+          {
+            "name": "upsert",
+            "in": "query",
+            "description": "Update news with the same external_id.",
+            "schema": {"type": "boolean", "default": false}
+          }
+        ],
+        "requestBody": {
+          "required": true,
+          "content": {
+            "application/x-ndjson": {},
+            "text/csv": {}
+          }
+        },
+        "responses": {
+          "200": {
+            "description": "Import result.",
+            "content": {
+              "application/json": {
+                "schema": {"$ref": "#/components/schemas/ImportResult"}
+              }
+            }
+          },
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "422": {
+            "description": "Atomic import failed, no news are saved.",
+            "content": {
+              "application/json": {
+                "schema": {"$ref": "#/components/schemas/ImportResult"}
+              }
+            }
+          },
+          "429": {"$ref": "#/components/responses/TooManyRequests"},
+          "500": {"$ref": "#/components/responses/InternalError"},
+          "501": {"$ref": "#/components/responses/NotImplemented"}
+        }
+      }
+    },
+    "/v2/news/stream": {
+      "get": {
+        "operationId": "streamNews",
+        "summary": "Stream news events as server-sent events",
+        "parameters": [
+          {"$ref": "#/components/parameters/From"},
+          {"$ref": "#/components/parameters/To"},
+          {"$ref": "#/components/parameters/Query"},
+          {"$ref": "#/components/parameters/EventTypes"},
+          {"$ref": "#/components/parameters/LastEventIDHeader"},
+          {"$ref": "#/components/parameters/LastEventID"}
+        ],
+        "responses": {
+          "200": {
+            "description": "Events with type created or updated and news JSON as data.",
+            "content": {
+              "text/event-stream": {}
+            }
+          },
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "429": {"$ref": "#/components/responses/TooManyRequests"},
+          "501": {"$ref": "#/components/responses/NotImplemented"},
+          "503": {"$ref": "#/components/responses/ServiceUnavailable"}
+        }
+      }
+    },
+    "/v2/news/stream/ws": {
+      "get": {
+        "operationId": "streamNewsWS",
+        "summary": "Stream news events over WebSocket",
+        "description": "Events are sent as JSON text messages with id, type and news fields.",
+        "parameters": [
+          {"$ref": "#/components/parameters/From"},
+          {"$ref": "#/components/parameters/To"},
+          {"$ref": "#/components/parameters/Query"},
+          {"$ref": "#/components/parameters/EventTypes"},
+          {"$ref": "#/components/parameters/LastEventIDHeader"},
+          {"$ref": "#/components/parameters/LastEventID"}
+        ],
+        "responses": {
+          "101": {"description": "WebSocket handshake."},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "429": {"$ref": "#/components/responses/TooManyRequests"},
+          "501": {"$ref": "#/components/responses/NotImplemented"},
+          "503": {"$ref": "#/components/responses/ServiceUnavailable"}
+        }
+      }
+    },
+    "/feed.rss": {
+      "get": {
+        "operationId": "getRSSFeed",
+        "summary": "RSS 2.0 feed of the latest news",
+        "parameters": [
+          {"$ref": "#/components/parameters/From"},
+          {"$ref": "#/components/parameters/To"},
+          {"$ref": "#/components/parameters/Query"},
+          {"$ref": "#/components/parameters/FeedCount"}
+        ],
+        "responses": {
+          "200": {
+            "description": "RSS feed.",
+            "headers": {
+              "ETag": {"$ref": "#/components/headers/ETag"}
+            },
+            "content": {
+              "application/rss+xml": {}
+            }
+          },
+          "304": {"description": "Feed isn't changed."},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "429": {"$ref": "#/components/responses/TooManyRequests"},
+          "500": {"$ref": "#/components/responses/InternalError"},
+          "501": {"$ref": "#/components/responses/NotImplemented"}
+        }
+      }
+    },
+    "/feed.atom": {
+      "get": {
+        "operationId": "getAtomFeed",
+        "summary": "Atom feed of the latest news",
+        "parameters": [
+          {"$ref": "#/components/parameters/From"},
+          {"$ref": "#/components/parameters/To"},
+          {"$ref": "#/components/parameters/Query"},
+          {"$ref": "#/components/parameters/FeedCount"}
+        ],
+        "responses": {
+          "200": {
+            "description": "Atom feed.",
+            "headers": {
+              "ETag": {"$ref": "#/components/headers/ETag"}
+            },
+            "content": {
+              "application/atom+xml": {}
+            }
+          },
+          "304": {"description": "Feed isn't changed."},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "429": {"$ref": "#/components/responses/TooManyRequests"},
+          "500": {"$ref": "#/components/responses/InternalError"},
+          "501": {"$ref": "#/components/responses/NotImplemented"}
+        }
+      }
+    },
+    "/sitemap.xml": {
+      "get": {
+        "operationId": "getSitemapIndex",
+        "summary": "Sitemap index of day sitemaps",
+        "description": "Lists sitemap of every day which has news. Days with more than 50000 news have many sitemaps numbered from 2 after the first one.",
+        "responses": {
+          "200": {
+            "description": "Sitemap index.",
+            "content": {
+              "application/xml": {}
+            }
+          },
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "429": {"$ref": "#/components/responses/TooManyRequests"},
+          "500": {"$ref": "#/components/responses/InternalError"},
+          "501": {"$ref": "#/components/responses/NotImplemented"}
+        }
+      }
+    },
+    "/sitemaps/{name}": {
+      "get": {
+        "operationId": "getDaySitemap",
+        "summary": "Sitemap of news of day",
+        "parameters": [
+          {
+            "name": "name",
+            "in": "path",
+            "required": true,
+            "description": "Day in 2006-01-02 format with page number starting from 2 for pages after the first one.",
+            "schema": {
+              "type": "string",
+              "pattern": "^\\d{4}-\\d{2}-\\d{2}(-[1-9]\\d*)?\\.xml$"
+            },
+            "example": "2019-01-02-2.xml"
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "Sitemap of up to 50000 news in date order.",
+            "content": {
+              "application/xml": {}
+            }
+          },
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"},
+          "429": {"$ref": "#/components/responses/TooManyRequests"},
+          "500": {"$ref": "#/components/responses/InternalError"},
+          "501": {"$ref": "#/components/responses/NotImplemented"}
+        }
+      }
+    },
+    "/news-sitemap.xml": {
+      "get": {
+        "operationId": "getNewsSitemap",
+        "summary": "Google News sitemap of the last 48 hours",
+        "responses": {
+          "200": {
+            "description": "Google News sitemap of up to 1000 the newest news.",
+            "content": {
+              "application/xml": {}
+            }
+          },
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "429": {"$ref": "#/components/responses/TooManyRequests"},
+          "500": {"$ref": "#/components/responses/InternalError"},
+          "501": {"$ref": "#/components/responses/NotImplemented"}
+        }
+      }
+    },
+    "/graphql": {
+      "get": {
+        "operationId": "getGraphQL",
+        "summary": "Execute GraphQL query",
+        "parameters": [
+          {
+            "name": "query",
+            "in": "query",
+            "required": true,
+            "schema": {"type": "string"}
+          },
+          {
+            "name": "operationName",
+            "in": "query",
+            "schema": {"type": "string"}
+          },
+          {
+            "name": "variables",
+            "in": "query",
+            "description": "Variables as JSON object.",
+            "schema": {"type": "string"}
+          }
+        ],
+        "responses": {
+          "200": {"$ref": "#/components/responses/GraphQLResult"},
+          "400": {"$ref": "#/components/responses/GraphQLBadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "429": {"$ref": "#/components/responses/TooManyRequests"}
+        }
+      },
+      "post": {
+        "operationId": "postGraphQL",
+        "summary": "Execute GraphQL query",
+        "requestBody": {
+          "required": true,
+          "content": {
+            "application/json": {
+              "schema": {"$ref": "#/components/schemas/GraphQLRequest"}
+            }
+          }
+        },
+        "responses": {
+          "200": {"$ref": "#/components/responses/GraphQLResult"},
+          "400": {"$ref": "#/components/responses/GraphQLBadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "429": {"$ref": "#/components/responses/TooManyRequests"}
+        }
+      }
+    },
+    "/graphql/playground": {
+      "get": {
+        "operationId": "getGraphQLPlayground",
+        "summary": "GraphQL Playground page if it is enabled",
+        "security": [],
+        "responses": {
+          "200": {
+            "description": "Playground page.",
+            "content": {
+              "text/html": {}
+            }
+          },
+          "404": {"$ref": "#/components/responses/NotFound"},
+          "429": {"$ref": "#/components/responses/TooManyRequests"}
+        }
+      }
+    },
+    "/openapi.json": {
+      "get": {
+        "operationId": "getOpenAPI",
+        "summary": "This specification",
+        "security": [],
+        "responses": {
+          "200": {
+            "description": "OpenAPI 3 specification.",
+            "content": {
+              "application/json": {}
+            }
+          },
+          "429": {"$ref": "#/components/responses/TooManyRequests"}
+        }
+      }
+    }
+  },
+  "components": {
+    "securitySchemes": {
+      "apiKey": {
+        "type": "apiKey",
+        "in": "header",
+        "name": "X-API-Key"
+      },
+      "bearerAuth": {
+        "type": "http",
+        "scheme": "bearer",
+        "bearerFormat": "JWT"
+      }
+    },
+    "parameters": {
+      "From": {
+        "name": "from",
+        "in": "query",
+        "description": "The first day of news dates.",
+        "schema": {"type": "string", "format": "date"}
+      },
+      "To": {
+        "name": "to",
+        "in": "query",
+        "description": "The last day of news dates.",
+        "schema": {"type": "string", "format": "date"}
+      },
+      "Query": {
+        "name": "query",
+        "in": "query",
+        "description": "Text to search in news header.",
+        "schema": {"type": "string"}
+      },
+      "FeedCount": {
+        "name": "count",
+        "in": "query",
+        "description": "Number of feed items, configured one by default.",
+        "schema": {"type": "integer", "minimum": 1, "maximum": 1000}
+      },
+      "EventTypes": {
+        "name": "types",
+        "in": "query",
+        "description": "Comma separated event types, any by default.",
+        "schema": {"type": "string", "pattern": "^(created|updated)(,(created|updated))*$"}
+      },
+      "LastEventIDHeader": {
+        "name": "Last-Event-ID",
+        "in": "header",
+        "description": "Id of the last got event to resume stream after.",
+        "schema": {"type": "string"}
+      },
+      "LastEventID": {
+        "name": "last_event_id",
+        "in": "query",
+        "description": "Last-Event-ID for clients which can't set headers.",
+        "schema": {"type": "string"}
+      }
+    },
+    "headers": {
+      "ETag": {
+        "schema": {"type": "string"}
+      },
+      "Vary": {
+        "description": "Accept, because representation depends on it.",
+        "schema": {"type": "string"}
+      },
+      "RetryAfter": {
+        "description": "Seconds until request is allowed.",
+        "schema": {"type": "integer"}
+      }
+    },
+    "responses": {
+      "BadRequest": {
+        "description": "Request is invalid.",
+        "content": {
+          "application/problem+json": {
+            "schema": {"$ref": "#/components/schemas/Problem"}
+          },
+          "text/plain": {
+            "schema": {"type": "string"}
+          }
+        }
+      },
+      "Unauthorized": {
+        "description": "Credentials are missing or invalid.",
+        "content": {
+          "application/problem+json": {
+            "schema": {"$ref": "#/components/schemas/Problem"}
+          },
+          "text/plain": {
+            "schema": {"type": "string"}
+          }
+        }
+      },
+      "Forbidden": {
+        "description": "Role of principal isn't enough.",
+        "content": {
+          "application/problem+json": {
+            "schema": {"$ref": "#/components/schemas/Problem"}
+          },
+          "text/plain": {
+            "schema": {"type": "string"}
+          }
+        }
+      },
+      "NotFound": {
+        "description": "Resource isn't found.",
+        "content": {
+          "application/problem+json": {
+            "schema": {"$ref": "#/components/schemas/Problem"}
+          },
+          "text/plain": {
+            "schema": {"type": "string"}
+          }
+        }
+      },
+      "NotAcceptable": {
+        "description": "None of news representations is acceptable.",
+        "content": {
+          "application/problem+json": {
+            "schema": {"$ref": "#/components/schemas/Problem"}
+          },
+          "text/plain": {
+            "schema": {"type": "string"}
+          }
+        }
+      },
+      "TooManyRequests": {
+        "description": "Rate limit is exceeded.",
+        "headers": {
+          "Retry-After": {"$ref": "#/components/headers/RetryAfter"}
+        },
+        "content": {
+          "application/problem+json": {
+            "schema": {"$ref": "#/components/schemas/Problem"}
+          },
+          "text/plain": {
+            "schema": {"type": "string"}
+          }
+        }
+      },
+      "InternalError": {
+        "description": "Storage failed.",
+        "content": {
+          "application/problem+json": {
+            "schema": {"$ref": "#/components/schemas/Problem"}
+          },
+          "text/plain": {
+            "schema": {"type": "string"}
+          }
+        }
+      },
+      "NotImplemented": {
+        "description": "Storage doesn't support operation.",
+        "content": {
+          "application/problem+json": {
+            "schema": {"$ref": "#/components/schemas/Problem"}
+          },
+          "text/plain": {
+            "schema": {"type": "string"}
+          }
+        }
+      },
+      "ServiceUnavailable": {
+        "description": "Too many stream clients or server is stopping.",
+        "content": {
+          "application/problem+json": {
+            "schema": {"$ref": "#/components/schemas/Problem"}
+          },
+          "text/plain": {
+            "schema": {"type": "string"}
+          }
+        }
+      },
+      "GraphQLResult": {
+        "description": "Query result, errors of fields are given along with data.",
+        "content": {
+          "application/json": {
+            "schema": {"$ref": "#/components/schemas/GraphQLResponse"}
+          }
+        }
+      },
+      "GraphQLBadRequest": {
+        "description": "Query can't be parsed, isn't valid or exceeds depth or complexity limits.",
+        "content": {
+          "application/json": {
+            "schema": {"$ref": "#/components/schemas/GraphQLResponse"}
+          },
+          "application/problem+json": {
+            "schema": {"$ref": "#/components/schemas/Problem"}
+          },
+          "text/plain": {
+            "schema": {"type": "string"}
+          }
+        }
+      }
+    },
+    "schemas": {
+      "News": {
+        "type": "object",
+        "required": ["id", "header", "published_at", "url"],
+        "properties": {
+          "id": {"type": "integer", "format": "int64"},
+          "header": {"type": "string"},
+          "published_at": {
+            "type": "string",
+            "format": "date-time",
+            "description": "Publication time in UTC with fraction of second if it has one."
+          },
+          "url": {"type": "string", "format": "uri"},
+          "external_id": {
+            "type": "string",
+            "description": "Unique id of news in system which it is imported from."
+          }
+        }
+      },
+      "ImportResult": {
+        "type": "object",
+        "required": ["total", "created", "updated", "failed", "committed"],
+        "properties": {
+          "total": {
+            "type": "integer",
+            "description": "Number of read records including failed ones."
+          },
+          "created": {"type": "integer"},
+          "updated": {"type": "integer"},
+          "failed": {"type": "integer"},
+          "committed": {
+            "type": "boolean",
+            "description": "Whether news are saved."
+          },
+          "errors": {
+            "type": "array",
+            "description": "The first errors of failed records.",
+            "items": {"$ref": "#/components/schemas/ImportError"}
+          }
+        }
+      },
+      "ImportError": {
+        "type": "object",
+        "required": ["line", "message"],
+        "properties": {
+          "line": {"type": "integer"},
+          "message": {"type": "string"}
+        }
+      },
+      "Problem": {
+        "type": "object",
+        "description": "RFC 7807 problem details.",
+        "required": ["type", "title", "status"],
+        "properties": {
+          "type": {"type": "string", "format": "uri-reference"},
+          "title": {"type": "string"},
+          "status": {"type": "integer"},
+          "detail": {"type": "string"}
+        }
+      },
+      "GraphQLRequest": {
+        "type": "object",
+        "required": ["query"],
+        "properties": {
+          "query": {"type": "string"},
+          "operationName": {"type": "string"},
+          "variables": {"type": "object", "additionalProperties": true}
+        }
+      },
+      "GraphQLResponse": {
+        "type": "object",
+        "properties": {
+          "data": {"type": "object", "nullable": true},
+          "errors": {
+            "type": "array",
+            "items": {
+              "type": "object",
+              "required": ["message"],
+              "properties": {
+                "message": {"type": "string"},
+                "locations": {"type": "array", "items": {"type": "object"}},
+                "path": {"type": "array", "items": {}}
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}
+`
+
+// getOpenAPI responds with OpenAPI 3 specification of web API.
+func (s *Server) getOpenAPI(c echo.Context) error {
+	return c.Blob(http.StatusOK, echo.MIMEApplicationJSONCharsetUTF8,
+		[]byte(openAPISpec))
+}
@@ -0,0 +1,364 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadOpenAPI(t *testing.T) (*openapi3.T, routers.Router) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(openAPISpec))
+	if err != nil {
+		t.Fatal("failed to load OpenAPI spec: " + err.Error())
+	}
+
+	err = doc.Validate(context.Background())
+	if err != nil {
+		t.Fatal("OpenAPI spec is invalid: " + err.Error())
+	}
+
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		t.Fatal("failed to create OpenAPI router: " + err.Error())
+	}
+
+	return doc, router
+}
+
+// contractStorage supports every optional storage interface with fixed
+// data: news 1 exists, news 2 doesn't and news 3 fails.
+type contractStorage struct{}
+
+var errContractStorage = errors.New("storage failed")
+
+func (s *contractStorage) News(ctx context.Context, id int64) (
+	entity.News, error) {
+	switch id {
+	case 1:
+		return testExportNews[0], nil
+	case 2:
+		return entity.News{}, entity.ErrNewsNotFound
+	}
+	return entity.News{}, errContractStorage
+}
+
+func (s *contractStorage) NewsByIDs(ctx context.Context, ids []int64) (
+	[]entity.News, error) {
+	return testExportNews[:1], nil
+}
+
+func (s *contractStorage) NewsDays(ctx context.Context) (
+	[]entity.NewsDay, error) {
+	return []entity.NewsDay{{Date: testExportNews[0].Date, Count: 1}}, nil
+}
+
+func (s *contractStorage) ListNews(ctx context.Context,
+	f entity.NewsFilter) ([]entity.News, error) {
+	return testFeedNews, nil
+}
+
+func (s *contractStorage) ExportNews(ctx context.Context,
+	f entity.NewsFilter, fn func(entity.News) error) error {
+	for _, n := range testExportNews {
+		err := fn(n)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *contractStorage) SubscribeNews(fn func(entity.NewsEvent)) (
+	func() error, error) {
+	return func() error { return nil }, nil
+}
+
+func (s *contractStorage) ImportNews(ctx context.Context,
+	src entity.ImportSource, o entity.ImportOptions) (entity.ImportResult,
+	error) {
+	var r entity.ImportResult
+	for {
+		_, err := src.Next()
+		if err == io.EOF {
+			break
+		}
+		r.Total++
+		if ie, ok := err.(*entity.ImportError); ok {
+			r.AddError(*ie)
+			continue
+		}
+		if err != nil {
+			return r, err
+		}
+		r.Created++
+	}
+	r.Committed = !o.DryRun && (!o.Atomic || r.Failed == 0)
+	return r, nil
+}
+
+// echoPath converts OpenAPI path to echo one: parameters are prefixed by
+// colon and custom methods like /news:import are routed by op parameter.
+var (
+	openAPIParam        = regexp.MustCompile(`{(\w+)}`)
+	openAPICustomMethod = regexp.MustCompile(`^((?:/\w+)+):\w+$`)
+)
+
+func echoPath(p string) string {
+	p = openAPIParam.ReplaceAllString(p, ":$1")
+	return openAPICustomMethod.ReplaceAllString(p, "$1:op")
+}
+
+func TestOpenAPI_routes(t *testing.T) {
+	doc, _ := loadOpenAPI(t)
+
+	s := NewServer(&contractStorage{}, "", 10*time.Second, TLSConfig{})
+	s.SetGraphQLPlayground(true)
+	require.NoError(t, s.Start())
+	defer s.Stop()
+
+	var specRoutes []string
+	for p, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			r := method + " " + echoPath(p)
+			// Custom methods of the same method share route.
+			if i := sort.SearchStrings(specRoutes, r); i == len(specRoutes) ||
+				specRoutes[i] != r {
+				specRoutes = append(specRoutes, r)
+				sort.Strings(specRoutes)
+			}
+		}
+	}
+
+	all := map[string]bool{}
+	for _, r := range s.echo.Routes() {
+		all[r.Method+" "+r.Path] = true
+	}
+
+	// Spec describes current version only, routes of v1 and unversioned
+	// ones are its aliases.
+	var routes []string
+	for r := range all {
+		i := strings.Index(r, " ")
+		method, path := r[:i], r[i+1:]
+		alias := method + " " + apiV2.prefix +
+			strings.TrimPrefix(path, apiV1.prefix)
+		if alias != r && all[alias] {
+			continue
+		}
+		routes = append(routes, r)
+	}
+	sort.Strings(routes)
+
+	assert.Equal(t, specRoutes, routes)
+}
+
+func TestServer_getOpenAPI(t *testing.T) {
+	s := initServerWith(t, &mockStorage{})
+	defer s.Stop()
+
+	res := serve(s, http.MethodGet, "/openapi.json", nil)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, openAPISpec, res.Body.String())
+}
+
+type contractCase struct {
+	method string
+	target string
+	header map[string]string
+	body   string
+	// invalid tells that request doesn't conform to spec, only response
+	// is validated then.
+	invalid bool
+	status  int
+}
+
+// checkContract validates request of c and response of s to it against
+// spec.
+func checkContract(t *testing.T, router routers.Router, s *Server,
+	c contractCase) {
+	name := c.method + " " + c.target
+
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(c.method, c.target,
+			strings.NewReader(c.body))
+		for k, v := range c.header {
+			req.Header.Set(k, v)
+		}
+		return req
+	}
+
+	req := newRequest()
+
+	route, pathParams, err := router.FindRoute(req)
+	if !assert.NoError(t, err, name) {
+		return
+	}
+
+	reqInput := &openapi3filter.RequestValidationInput{
+		Request:    req,
+		PathParams: pathParams,
+		Route:      route,
+		Options: &openapi3filter.Options{
+			AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+			IncludeResponseStatus: true,
+		},
+	}
+
+	err = openapi3filter.ValidateRequest(context.Background(), reqInput)
+	if c.invalid {
+		assert.Error(t, err, name)
+	} else {
+		assert.NoError(t, err, name)
+	}
+
+	res := httptest.NewRecorder()
+	s.echo.ServeHTTP(res, newRequest())
+
+	if !assert.Equal(t, c.status, res.Code, name) {
+		return
+	}
+
+	body := ioutil.NopCloser(bytes.NewReader(res.Body.Bytes()))
+
+	err = openapi3filter.ValidateResponse(context.Background(),
+		&openapi3filter.ResponseValidationInput{
+			RequestValidationInput: reqInput,
+			Status:                 res.Code,
+			Header:                 res.Header(),
+			Body:                   body,
+			Options:                reqInput.Options,
+		})
+	assert.NoError(t, err, name)
+}
+
+func TestOpenAPI_contract(t *testing.T) {
+	_, router := loadOpenAPI(t)
+
+	s := NewServer(&contractStorage{}, "", 10*time.Second, TLSConfig{})
+	s.SetGraphQLPlayground(true)
+	require.NoError(t, s.Start())
+	defer s.Stop()
+
+	jsonl := map[string]string{"Content-Type": "application/x-ndjson"}
+
+	for _, c := range []contractCase{
+		{method: "GET", target: "/v2/news/1", status: 200},
+		{method: "GET", target: "/v2/news/2", status: 404},
+		{method: "GET", target: "/v2/news/3", status: 500},
+		{method: "GET", target: "/v2/news/abc", invalid: true, status: 400},
+		{method: "GET", target: "/v2/news/1",
+			header: map[string]string{"Accept": "text/xml"}, status: 200},
+		{method: "GET", target: "/v2/news/1?format=protobuf", status: 200},
+		{method: "GET", target: "/v2/news/1?format=html", status: 200},
+		{method: "GET", target: "/news?query=news&page=2", status: 200},
+		{method: "GET", target: "/news?page=0", invalid: true, status: 400},
+		{method: "GET", target: "/v2/news/1",
+			header: map[string]string{"Accept": "image/png"}, status: 406},
+		{method: "GET", target: "/v2/news:export?format=csv&from=2019-01-02",
+			status: 200},
+		{method: "GET", target: "/v2/news:export?format=xml", invalid: true,
+			status: 400},
+		{method: "POST", target: "/v2/news:import", header: jsonl,
+			body:   `{"header":"first","date":"2019-01-02T00:00:00Z"}`,
+			status: 200},
+		{method: "POST", target: "/v2/news:import?atomic=true", header: jsonl,
+			body: "{}\n", status: 422},
+		{method: "POST", target: "/v2/news:import?dry_run=maybe",
+			header: jsonl, body: "{}\n", invalid: true, status: 400},
+		{method: "GET", target: "/v2/news/stream?types=deleted",
+			invalid: true, status: 400},
+		{method: "GET", target: "/feed.rss?query=news", status: 200},
+		{method: "GET", target: "/feed.atom?count=1", status: 200},
+		{method: "GET", target: "/feed.atom?count=0", invalid: true,
+			status: 400},
+		{method: "GET", target: "/sitemap.xml", status: 200},
+		{method: "GET", target: "/sitemaps/2019-01-02.xml", status: 200},
+		{method: "GET", target: "/sitemaps/2019-01-02-2.xml", status: 404},
+		{method: "GET", target: "/sitemaps/news.xml", invalid: true,
+			status: 404},
+		{method: "GET", target: "/news-sitemap.xml", status: 200},
+		{method: "GET", target: `/graphql?query={news(id:"1"){id,header}}`,
+			status: 200},
+		{method: "POST", target: "/graphql",
+			header: map[string]string{"Content-Type": "application/json"},
+			body:   `{"query":"{newsList(limit:1){items{id},hasMore}}"}`,
+			status: 200},
+		{method: "POST", target: "/graphql",
+			header: map[string]string{"Content-Type": "application/json"},
+			body:   `{"query":"{news(id:\"1\"){body}}"}`, status: 400},
+		{method: "GET", target: "/graphql/playground", status: 200},
+		{method: "GET", target: "/openapi.json", status: 200},
+	} {
+		checkContract(t, router, s, c)
+	}
+}
+
+func TestOpenAPI_contract_notImplemented(t *testing.T) {
+	_, router := loadOpenAPI(t)
+
+	s := initServerWith(t, &mockStorage{})
+	defer s.Stop()
+
+	for _, c := range []contractCase{
+		{method: "GET", target: "/v2/news:export", status: 501},
+		{method: "POST", target: "/v2/news:import",
+			header: map[string]string{"Content-Type": "text/csv"},
+			body:   "header,date\n", status: 501},
+		{method: "GET", target: "/v2/news/stream", status: 501},
+		{method: "GET", target: "/v2/news/stream/ws", status: 501},
+		{method: "GET", target: "/feed.rss", status: 501},
+		{method: "GET", target: "/news", status: 501},
+		{method: "GET", target: "/sitemap.xml", status: 501},
+		{method: "GET", target: "/sitemaps/2019-01-02.xml", status: 501},
+		{method: "GET", target: "/news-sitemap.xml", status: 501},
+	} {
+		checkContract(t, router, s, c)
+	}
+}
+
+func TestOpenAPI_contract_auth(t *testing.T) {
+	_, router := loadOpenAPI(t)
+
+	_, s := initAuthServer(t, APIKeys{
+		"reader": {Name: "alice", Role: entity.RoleReader},
+	})
+	defer s.Stop()
+
+	for _, c := range []contractCase{
+		{method: "GET", target: "/v2/news/1", status: 401},
+		{method: "POST", target: "/v2/news:import",
+			header: map[string]string{
+				"Content-Type": "text/csv",
+				apiKeyHeader:   "reader",
+			},
+			body: "header,date\n", status: 403},
+	} {
+		checkContract(t, router, s, c)
+	}
+
+	rs, _ := initRateLimitServer(t, nil,
+		[]string{"GET /news/:news_id=1/s:1"}, NewMemoryLimiterStore())
+	defer rs.Stop()
+
+	checkContract(t, router, rs, contractCase{
+		method: "GET", target: "/v2/news/1", status: 200})
+	checkContract(t, router, rs, contractCase{
+		method: "GET", target: "/v2/news/1", status: 429})
+}
@@ -0,0 +1,292 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/gobuffalo/packr"
+	"github.com/labstack/echo"
+)
+
+//go:generate packr
+
+// templatesBox keeps HTML templates in binary, so that production server
+// needs no files besides it.
+var templatesBox = packr.NewBox("./templates")
+
+// listPageSize is number of news on news list page.
+const listPageSize = 20
+
+// Names of pages. Page template is its file with layout.html.
+const (
+	articlePageName = "article"
+	listPageName    = "list"
+)
+
+var templateFuncs = template.FuncMap{
+	"rfc3339": func(t time.Time) string {
+		return t.UTC().Format(time.RFC3339)
+	},
+	"humanDate": func(t time.Time) string {
+		return t.UTC().Format("2 January 2006")
+	},
+}
+
+// pageTemplates parses page templates embedded in binary or reparses
+// them from directory on every render, so that changes of templates are
+// seen without restart in development.
+type pageTemplates struct {
+	dir   string
+	pages map[string]*template.Template
+}
+
+func parsePage(dir, name string) (*template.Template, error) {
+	t := template.New(name).Funcs(templateFuncs)
+
+	files := []string{"layout.html", name + ".html"}
+
+	if dir != "" {
+		for i, f := range files {
+			files[i] = filepath.Join(dir, f)
+		}
+		t, err := t.ParseFiles(files...)
+		if err != nil {
+			return nil, errors.New("failed to parse " + name + " page: " +
+				err.Error())
+		}
+		return t, nil
+	}
+
+	for _, f := range files {
+		text, err := templatesBox.FindString(f)
+		if err == nil {
+			_, err = t.New(f).Parse(text)
+		}
+		if err != nil {
+			return nil, errors.New("failed to parse " + name + " page: " +
+				err.Error())
+		}
+	}
+
+	return t, nil
+}
+
+// newPageTemplates returns templates reloaded from dir or embedded ones
+// if dir is empty.
+func newPageTemplates(dir string) (*pageTemplates, error) {
+	pt := &pageTemplates{dir: dir}
+
+	if dir != "" {
+		return pt, nil
+	}
+
+	pt.pages = map[string]*template.Template{}
+
+	for _, name := range []string{articlePageName, listPageName} {
+		t, err := parsePage("", name)
+		if err != nil {
+			return nil, err
+		}
+		pt.pages[name] = t
+	}
+
+	return pt, nil
+}
+
+func (pt *pageTemplates) render(name string, data interface{}) ([]byte,
+	error) {
+	t := pt.pages[name]
+	if pt.dir != "" {
+		var err error
+		t, err = parsePage(pt.dir, name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var b bytes.Buffer
+
+	err := t.ExecuteTemplate(&b, "layout", data)
+	if err != nil {
+		return nil, errors.New("failed to render " + name + " page: " +
+			err.Error())
+	}
+
+	return b.Bytes(), nil
+}
+
+// baseURL returns public URL of server without trailing slash. It's
+// taken from request if it isn't set. Forwarded scheme is taken only
+// from trusted proxies, so that clients can't make links to other
+// scheme.
+func (s *Server) baseURL(c echo.Context) string {
+	if s.publicURL != "" {
+		return s.publicURL
+	}
+
+	r := c.Request()
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	if s.trustedProxy(ip) {
+		if p := r.Header.Get(echo.HeaderXForwardedProto); p != "" {
+			scheme = p
+		} else if r.Header.Get(echo.HeaderXForwardedSsl) == "on" {
+			scheme = "https"
+		}
+	}
+
+	return scheme + "://" + r.Host
+}
+
+// newsArticleLD is schema.org NewsArticle in JSON-LD.
+type newsArticleLD struct {
+	Context          string `json:"@context"`
+	Type             string `json:"@type"`
+	Headline         string `json:"headline"`
+	DatePublished    string `json:"datePublished"`
+	URL              string `json:"url"`
+	MainEntityOfPage string `json:"mainEntityOfPage"`
+	Identifier       string `json:"identifier"`
+}
+
+type articlePage struct {
+	Title     string
+	Canonical string
+	News      entity.News
+	JSONLD    newsArticleLD
+}
+
+// writeArticlePage responds with HTML page of news n. Its canonical URL
+// is news URL without parameters.
+func (s *Server) writeArticlePage(c echo.Context, n entity.News) error {
+	canonical := newsLink(s.baseURL(c), n)
+
+	data, err := s.pages.render(articlePageName, articlePage{
+		Title:     n.Header,
+		Canonical: canonical,
+		News:      n,
+		JSONLD: newsArticleLD{
+			Context:          "https://schema.org",
+			Type:             "NewsArticle",
+			Headline:         n.Header,
+			DatePublished:    n.Date.UTC().Format(time.RFC3339),
+			URL:              canonical,
+			MainEntityOfPage: canonical,
+			Identifier:       strconv.FormatInt(n.ID, 10),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.HTMLBlob(http.StatusOK, data)
+}
+
+type listPage struct {
+	Title     string
+	Canonical string
+	News      []entity.News
+	// Prev and Next are URLs of newer and older news pages, empty if
+	// there are no such pages.
+	Prev string
+	Next string
+}
+
+// getNewsList responds with HTML page of the latest news matched by
+// from, to and query parameters. Pages are numbered from 1 by page
+// parameter.
+func (s *Server) getNewsList(c echo.Context) error {
+	lst, ok := s.storage.(Lister)
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotImplemented,
+			"storage doesn't support listing")
+	}
+
+	f, err := filterParams(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	page := 1
+	if v := c.QueryParam("page"); v != "" {
+		page, err = strconv.Atoi(v)
+		if err != nil || page < 1 {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				"page must be positive integer")
+		}
+	}
+
+	// One more news tells whether there is next page.
+	f.Offset = int64((page - 1) * listPageSize)
+	f.Limit = listPageSize + 1
+
+	ns, err := lst.ListNews(c.Request().Context(), f)
+	if err != nil {
+		return errors.New("failed to list news: " + err.Error())
+	}
+
+	// Canonical URLs keep filter parameters, because they select other
+	// news, and drop the others.
+	params := url.Values{}
+	for _, name := range []string{"from", "to", "query"} {
+		if v := c.QueryParam(name); v != "" {
+			params.Set(name, v)
+		}
+	}
+
+	pageURL := func(p int) string {
+		if p > 1 {
+			params.Set("page", strconv.Itoa(p))
+		} else {
+			params.Del("page")
+		}
+		u := s.baseURL(c) + "/news"
+		if len(params) > 0 {
+			u += "?" + params.Encode()
+		}
+		return u
+	}
+
+	lp := listPage{
+		Title:     "News",
+		Canonical: pageURL(page),
+		News:      ns,
+	}
+
+	if f.Query != "" {
+		lp.Title = "News about " + strings.TrimSpace(f.Query)
+	}
+	if page > 1 {
+		lp.Title += ", page " + strconv.Itoa(page)
+		lp.Prev = pageURL(page - 1)
+	}
+	if len(ns) > listPageSize {
+		lp.News = ns[:listPageSize]
+		lp.Next = pageURL(page + 1)
+	}
+
+	data, err := s.pages.render(listPageName, lp)
+	if err != nil {
+		return err
+	}
+
+	return c.HTMLBlob(http.StatusOK, data)
+}
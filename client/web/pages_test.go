@@ -0,0 +1,165 @@
+package web
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var jsonLDScript = regexp.MustCompile(
+	`<script type="application/ld\+json">(.*)</script>`)
+
+func TestServer_getNews_articlePage(t *testing.T) {
+	ms := &mockStorage{}
+	s := NewServer(ms, "", 10*time.Second, TLSConfig{})
+	s.SetPublicURL("https://news.example.com/")
+	require.NoError(t, s.Start())
+	defer s.Stop()
+
+	n := entity.News{
+		ID:     123,
+		Header: "</script><script>alert(1)</script>",
+		Date:   time.Date(2019, 1, 2, 12, 0, 0, 0, time.UTC),
+	}
+
+	ms.On("News", int64(123)).Return(n, nil)
+
+	res := serve(s, http.MethodGet, "/news/123?format=html", nil)
+	if !assert.Equal(t, http.StatusOK, res.Code) {
+		return
+	}
+
+	body := res.Body.String()
+
+	const canonical = "https://news.example.com/news/123"
+
+	assert.Contains(t, body, `<link rel="canonical" href="`+canonical+`">`)
+	assert.Contains(t, body, `<meta property="og:type" content="article">`)
+	assert.Contains(t, body, `<meta property="og:url" content="`+canonical+
+		`">`)
+	assert.Contains(t, body, `<meta name="twitter:card" content="summary">`)
+	assert.Contains(t, body, `<meta property="article:published_time" `+
+		`content="2019-01-02T12:00:00Z">`)
+	assert.NotContains(t, body, "<script>alert")
+
+	m := jsonLDScript.FindStringSubmatch(body)
+	if !assert.Len(t, m, 2) {
+		return
+	}
+
+	var ld map[string]string
+	if assert.NoError(t, json.Unmarshal([]byte(m[1]), &ld)) {
+		assert.Equal(t, map[string]string{
+			"@context":         "https://schema.org",
+			"@type":            "NewsArticle",
+			"headline":         n.Header,
+			"datePublished":    "2019-01-02T12:00:00Z",
+			"url":              canonical,
+			"mainEntityOfPage": canonical,
+			"identifier":       "123",
+		}, ld)
+	}
+}
+
+func TestServer_getNewsList(t *testing.T) {
+	ms := &mockListStorage{}
+	s := initServerWith(t, ms)
+	defer s.Stop()
+
+	ns := make([]entity.News, listPageSize+1)
+	for i := range ns {
+		ns[i] = entity.News{
+			ID:     int64(i + 1),
+			Header: "news " + strconv.Itoa(i+1),
+			Date:   time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+		}
+	}
+
+	ms.On("ListNews", entity.NewsFilter{
+		Query:  "news",
+		Offset: listPageSize,
+		Limit:  listPageSize + 1,
+	}).Return(ns, nil)
+
+	res := serve(s, http.MethodGet, "/news?query=news&page=2&utm=x", nil)
+
+	ms.AssertExpectations(t)
+
+	if !assert.Equal(t, http.StatusOK, res.Code) {
+		return
+	}
+
+	body := res.Body.String()
+
+	assert.Contains(t, body, "<title>News about news, page 2</title>")
+	assert.Contains(t, body, `<link rel="canonical" `+
+		`href="http://example.com/news?page=2&amp;query=news">`)
+	assert.Contains(t, body, `<link rel="prev" `+
+		`href="http://example.com/news?query=news">`)
+	assert.Contains(t, body, `<link rel="next" `+
+		`href="http://example.com/news?page=3&amp;query=news">`)
+	assert.Contains(t, body, `<a href="/news/20">news 20</a>`)
+	// The extra news only tells that there is next page.
+	assert.NotContains(t, body, `<a href="/news/21">`)
+}
+
+func TestServer_getNewsList_badRequest(t *testing.T) {
+	s := initServerWith(t, &mockListStorage{})
+	defer s.Stop()
+
+	for _, target := range []string{
+		"/news?page=0",
+		"/news?page=x",
+		"/news?from=x",
+	} {
+		res := serve(s, http.MethodGet, target, nil)
+		assert.Equal(t, http.StatusBadRequest, res.Code, target)
+	}
+}
+
+func TestServer_SetTemplateDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "news-storage-templates")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	writeTemplate := func(name, content string) {
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content),
+			0600)
+		if err != nil {
+			t.Fatal("failed to write template: " + err.Error())
+		}
+	}
+
+	writeTemplate("layout.html",
+		`{{define "layout"}}{{template "content" .}}{{end}}`)
+	writeTemplate("article.html", `{{define "content"}}old{{end}}`)
+
+	ms := &mockStorage{}
+	s := NewServer(ms, "", 10*time.Second, TLSConfig{})
+	s.SetTemplateDir(dir)
+	require.NoError(t, s.Start())
+	defer s.Stop()
+
+	ms.On("News", int64(1)).Return(entity.News{ID: 1}, nil)
+
+	res := serve(s, http.MethodGet, "/news/1?format=html", nil)
+	assert.Equal(t, "old", res.Body.String())
+
+	// Changed template is used without restart.
+	writeTemplate("article.html", `{{define "content"}}new{{end}}`)
+
+	res = serve(s, http.MethodGet, "/news/1?format=html", nil)
+	assert.Equal(t, "new", res.Body.String())
+}
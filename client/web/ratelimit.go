@@ -0,0 +1,336 @@
+package web
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/labstack/echo"
+)
+
+// RateLimit is token bucket limit: bucket holds up to Burst tokens and is
+// refilled by Rate tokens per second. Every request takes one token.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// AnyRoute is key of rate limits of routes without their own ones.
+const AnyRoute = "*"
+
+// ParseRateLimits parses limits in "ROUTE=N/UNIT[:BURST]" form, e.g.
+// "GET /news/:news_id=10/s:20". Route is method and path as registered
+// without API version prefix, so that limit is shared by all versions,
+// or * for other routes. Unit is s, m or h. Burst is N by default.
+func ParseRateLimits(specs []string) (map[string]RateLimit, error) {
+	ls := map[string]RateLimit{}
+
+	for _, spec := range specs {
+		i := strings.LastIndex(spec, "=")
+		if i < 0 {
+			return nil, errors.New("rate limit " + spec +
+				" must be ROUTE=N/UNIT[:BURST]")
+		}
+
+		route, limit := strings.TrimSpace(spec[:i]), spec[i+1:]
+
+		l, err := ParseRateLimit(limit)
+		if err != nil {
+			return nil, errors.New("invalid rate limit of " + route + ": " +
+				err.Error())
+		}
+
+		ls[route] = l
+	}
+
+	return ls, nil
+}
+
+// ParseRateLimit parses limit in "N/UNIT[:BURST]" form, see
+// ParseRateLimits.
+func ParseRateLimit(s string) (RateLimit, error) {
+	var burst string
+
+	if i := strings.Index(s, ":"); i >= 0 {
+		s, burst = s[:i], s[i+1:]
+	}
+
+	i := strings.Index(s, "/")
+	if i < 0 {
+		return RateLimit{}, errors.New("expected N/UNIT")
+	}
+
+	n, err := strconv.Atoi(s[:i])
+	if err != nil || n <= 0 {
+		return RateLimit{}, errors.New("N must be positive integer")
+	}
+
+	var period time.Duration
+
+	switch s[i+1:] {
+	case "s":
+		period = time.Second
+	case "m":
+		period = time.Minute
+	case "h":
+		period = time.Hour
+	default:
+		return RateLimit{}, errors.New("unit must be s, m or h")
+	}
+
+	l := RateLimit{
+		Rate:  float64(n) / period.Seconds(),
+		Burst: n,
+	}
+
+	if burst != "" {
+		l.Burst, err = strconv.Atoi(burst)
+		if err != nil || l.Burst <= 0 {
+			return RateLimit{}, errors.New("burst must be positive integer")
+		}
+	}
+
+	return l, nil
+}
+
+// Bucket is state of token bucket.
+type Bucket struct {
+	Tokens  float64   `json:"tokens"`
+	Updated time.Time `json:"updated"`
+}
+
+// Refill refills bucket by time now. Zero bucket is full.
+func (b Bucket) Refill(l RateLimit, now time.Time) Bucket {
+	if b.Updated.IsZero() {
+		b.Tokens = float64(l.Burst)
+	} else if elapsed := now.Sub(b.Updated); elapsed > 0 {
+		b.Tokens = math.Min(float64(l.Burst),
+			b.Tokens+elapsed.Seconds()*l.Rate)
+	}
+	b.Updated = now
+	return b
+}
+
+// Take refills bucket by time now and takes token from it if there is
+// one.
+func (b Bucket) Take(l RateLimit, now time.Time) (Bucket, bool) {
+	b = b.Refill(l, now)
+
+	if b.Tokens < 1 {
+		return b, false
+	}
+
+	b.Tokens--
+
+	return b, true
+}
+
+// wait returns time after update until bucket has n tokens.
+func (b Bucket) wait(l RateLimit, n float64) time.Duration {
+	if b.Tokens >= n {
+		return 0
+	}
+	return time.Duration((n - b.Tokens) / l.Rate * float64(time.Second))
+}
+
+// LimiterStore keeps token buckets of rate limiter.
+type LimiterStore interface {
+	// Take takes token from bucket key of limit l at time now. It returns
+	// bucket state after it and whether token is taken.
+	Take(key string, l RateLimit, now time.Time) (Bucket, bool, error)
+	// Peek returns state of bucket key of limit l at time now without
+	// taking token.
+	Peek(key string, l RateLimit, now time.Time) (Bucket, error)
+}
+
+// memorySweepInterval is number of takes after which full buckets are
+// removed from memory store.
+const memorySweepInterval = 10000
+
+// MemoryLimiterStore keeps buckets in memory of one web server.
+type MemoryLimiterStore struct {
+	mu      sync.Mutex
+	buckets map[string]Bucket
+	limits  map[string]RateLimit
+	takes   int
+}
+
+func NewMemoryLimiterStore() *MemoryLimiterStore {
+	return &MemoryLimiterStore{
+		buckets: map[string]Bucket{},
+		limits:  map[string]RateLimit{},
+	}
+}
+
+func (s *MemoryLimiterStore) Take(key string, l RateLimit, now time.Time) (
+	Bucket, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.takes++
+	if s.takes%memorySweepInterval == 0 {
+		s.sweep(now)
+	}
+
+	b, taken := s.buckets[key].Take(l, now)
+
+	s.buckets[key] = b
+	s.limits[key] = l
+
+	return b, taken, nil
+}
+
+func (s *MemoryLimiterStore) Peek(key string, l RateLimit, now time.Time) (
+	Bucket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.buckets[key].Refill(l, now), nil
+}
+
+// sweep removes buckets which are full, they are the same as missing.
+func (s *MemoryLimiterStore) sweep(now time.Time) {
+	for k, b := range s.buckets {
+		l := s.limits[k]
+		if b.Updated.Add(b.wait(l, float64(l.Burst))).Before(now) {
+			delete(s.buckets, k)
+			delete(s.limits, k)
+		}
+	}
+}
+
+// AuthFailures is key of limit of failed authentications from one IP
+// address.
+const AuthFailures = "auth failures"
+
+// DefaultAuthFailureLimit is limit of failed authentications if limits
+// have no AuthFailures one.
+var DefaultAuthFailureLimit = RateLimit{Rate: 10.0 / 60, Burst: 10}
+
+// RateLimiter limits requests of every client to every route. Clients are
+// identified by authenticated principal or by IP address. It limits
+// failed authentications from every IP address too.
+type RateLimiter struct {
+	limits       map[string]RateLimit
+	authFailures RateLimit
+	store        LimiterStore
+	now          func() time.Time
+}
+
+// NewRateLimiter creates limiter with limits by routes, see
+// ParseRateLimits, and AuthFailures limit. Routes without limits and
+// without AnyRoute limit are not limited.
+func NewRateLimiter(limits map[string]RateLimit,
+	store LimiterStore) *RateLimiter {
+	af, ok := limits[AuthFailures]
+	if !ok {
+		af = DefaultAuthFailureLimit
+	}
+	return &RateLimiter{
+		limits:       limits,
+		authFailures: af,
+		store:        store,
+		now:          time.Now,
+	}
+}
+
+// seconds returns d in whole seconds rounded up.
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
+
+// rateLimit rejects requests exceeding rate limit of their route with 429
+// Too Many Requests. Limiter store failures are logged and requests are
+// passed.
+func (s *Server) rateLimit(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		rl := s.limiter
+		if rl == nil {
+			return next(c)
+		}
+
+		route := c.Request().Method + " " + unversionedPath(c.Path())
+
+		l, ok := rl.limits[route]
+		if !ok {
+			l, ok = rl.limits[AnyRoute]
+			if !ok {
+				return next(c)
+			}
+		}
+
+		client := "ip:" + s.realIP(c.Request())
+		if p, ok := entity.PrincipalFromContext(c.Request().Context()); ok {
+			client = "principal:" + p.Name
+		}
+
+		now := rl.now()
+
+		b, taken, err := rl.store.Take(route+" "+client, l, now)
+		if err != nil {
+			s.log.WithError(err).Error("failed to take rate limit token")
+			return next(c)
+		}
+
+		h := c.Response().Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(l.Burst))
+		h.Set("RateLimit-Remaining", strconv.Itoa(int(b.Tokens)))
+		h.Set("RateLimit-Reset", seconds(b.wait(l, float64(l.Burst))))
+
+		if !taken {
+			h.Set("Retry-After", seconds(b.wait(l, 1)))
+			return echo.NewHTTPError(http.StatusTooManyRequests,
+				"rate limit exceeded")
+		}
+
+		return next(c)
+	}
+}
+
+// limitAuthFailures rejects authentication from IP address which has
+// exceeded limit of failed authentications with 429 Too Many Requests, so
+// that API keys and tokens can't be brute forced. Only failures take
+// tokens, but successful authentications are rejected too while bucket is
+// empty, so that guesses aren't confirmed. Limiter store failures are
+// logged and requests are passed.
+func (s *Server) limitAuthFailures(c echo.Context, failed bool) error {
+	rl := s.limiter
+	if rl == nil {
+		return nil
+	}
+
+	key := AuthFailures + " ip:" + s.realIP(c.Request())
+	l := rl.authFailures
+	now := rl.now()
+
+	var (
+		b     Bucket
+		taken bool
+		err   error
+	)
+
+	if failed {
+		b, taken, err = rl.store.Take(key, l, now)
+	} else {
+		b, err = rl.store.Peek(key, l, now)
+		taken = b.Tokens >= 1
+	}
+	if err != nil {
+		s.log.WithError(err).Error(
+			"failed to take authentication failures token")
+		return nil
+	}
+
+	if !taken {
+		c.Response().Header().Set("Retry-After", seconds(b.wait(l, 1)))
+		return echo.NewHTTPError(http.StatusTooManyRequests,
+			"too many failed authentications")
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package web
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	// limiterBucket is NATS key-value bucket of rate limiter buckets.
+	limiterBucket = "news_rate_limits"
+	// limiterUpdateAttempts is number of attempts to update bucket which
+	// is concurrently updated by other web servers.
+	limiterUpdateAttempts = 5
+	// minLimiterTTL is minimal time to keep idle buckets.
+	minLimiterTTL = time.Minute
+)
+
+// NATSLimiterStore keeps buckets in NATS key-value bucket, so that web
+// servers share rate limits. NATS server must have JetStream enabled.
+type NATSLimiterStore struct {
+	connection *nats.Conn
+	kv         nats.KeyValue
+}
+
+// NewNATSLimiterStore connects to NATS and creates key-value bucket if
+// there is none. Idle buckets expire when they are full by limits.
+func NewNATSLimiterStore(natsURL string, limits map[string]RateLimit) (
+	*NATSLimiterStore, error) {
+	conn, err := nats.Connect(natsURL)
+	if err != nil {
+		return nil, errors.New("failed to connect to nats: " + err.Error())
+	}
+
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, errors.New("failed to get jetstream context: " +
+			err.Error())
+	}
+
+	kv, err := js.KeyValue(limiterBucket)
+	if err == nats.ErrBucketNotFound {
+		ttl := minLimiterTTL
+		for _, l := range limits {
+			if t := (Bucket{}).wait(l, float64(l.Burst)); t > ttl {
+				ttl = t
+			}
+		}
+		kv, err = js.CreateKeyValue(&nats.KeyValueConfig{
+			Bucket:  limiterBucket,
+			History: 1,
+			TTL:     ttl,
+		})
+	}
+	if err != nil {
+		conn.Close()
+		return nil, errors.New("failed to get key-value bucket: " +
+			err.Error())
+	}
+
+	return &NATSLimiterStore{
+		connection: conn,
+		kv:         kv,
+	}, nil
+}
+
+// Close closes NATS connection.
+func (s *NATSLimiterStore) Close() {
+	s.connection.Close()
+}
+
+// get returns bucket key and its revision, zero if there is none.
+func (s *NATSLimiterStore) get(k string) (Bucket, uint64, error) {
+	var b Bucket
+
+	e, err := s.kv.Get(k)
+	switch err {
+	case nil:
+		err = json.Unmarshal(e.Value(), &b)
+		if err != nil {
+			return Bucket{}, 0, errors.New(
+				"failed to unmarshal bucket: " + err.Error())
+		}
+		return b, e.Revision(), nil
+	case nats.ErrKeyNotFound:
+		return b, 0, nil
+	}
+
+	return Bucket{}, 0, errors.New("failed to get bucket: " + err.Error())
+}
+
+// natsLimiterKey returns key of bucket key. Keys can't have arbitrary
+// characters, IPv6 addresses have colons.
+func natsLimiterKey(key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+func (s *NATSLimiterStore) Peek(key string, l RateLimit, now time.Time) (
+	Bucket, error) {
+	b, _, err := s.get(natsLimiterKey(key))
+	if err != nil {
+		return Bucket{}, err
+	}
+	return b.Refill(l, now), nil
+}
+
+// Take updates bucket only if token is taken. Concurrent updates are
+// detected by revision and retried.
+func (s *NATSLimiterStore) Take(key string, l RateLimit, now time.Time) (
+	Bucket, bool, error) {
+	k := natsLimiterKey(key)
+
+	var updateErr error
+
+	for i := 0; i < limiterUpdateAttempts; i++ {
+		b, rev, err := s.get(k)
+		if err != nil {
+			return Bucket{}, false, err
+		}
+
+		b, taken := b.Take(l, now)
+		if !taken {
+			return b, false, nil
+		}
+
+		data, err := json.Marshal(b)
+		if err != nil {
+			return Bucket{}, false, errors.New(
+				"failed to marshal bucket: " + err.Error())
+		}
+
+		if rev == 0 {
+			_, err = s.kv.Create(k, data)
+		} else {
+			_, err = s.kv.Update(k, data, rev)
+		}
+		if err == nil {
+			return b, true, nil
+		}
+
+		updateErr = err
+	}
+
+	return Bucket{}, false, errors.New("failed to update bucket: " +
+		updateErr.Error())
+}
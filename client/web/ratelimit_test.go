@@ -0,0 +1,231 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/storage/nats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRateLimits(t *testing.T) {
+	ls, err := ParseRateLimits([]string{
+		"GET /news/:news_id=10/s:20",
+		"POST /news:op=60/m",
+		"*=3600/h",
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]RateLimit{
+			"GET /news/:news_id": {Rate: 10, Burst: 20},
+			"POST /news:op":      {Rate: 1, Burst: 60},
+			AnyRoute:             {Rate: 1, Burst: 3600},
+		}, ls)
+	}
+
+	for _, spec := range []string{
+		"GET /news/:news_id",
+		"*=10",
+		"*=0/s",
+		"*=10/d",
+		"*=10/s:0",
+		"*=10/s:x",
+	} {
+		_, err = ParseRateLimits([]string{spec})
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestBucket_Take(t *testing.T) {
+	l := RateLimit{Rate: 1, Burst: 2}
+	now := time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	b, taken := Bucket{}.Take(l, now)
+	assert.True(t, taken)
+	assert.Equal(t, Bucket{Tokens: 1, Updated: now}, b)
+
+	b, taken = b.Take(l, now)
+	assert.True(t, taken)
+
+	b, taken = b.Take(l, now)
+	assert.False(t, taken)
+	assert.Equal(t, time.Second, b.wait(l, 1))
+
+	b, taken = b.Take(l, now.Add(500*time.Millisecond))
+	assert.False(t, taken)
+	assert.Equal(t, 500*time.Millisecond, b.wait(l, 1))
+
+	// Bucket isn't refilled over its burst.
+	b, taken = b.Take(l, now.Add(time.Hour))
+	assert.True(t, taken)
+	assert.Equal(t, 1.0, b.Tokens)
+}
+
+func initRateLimitServer(t *testing.T, a Authenticator, limits []string,
+	store LimiterStore) (*Server, *time.Time) {
+	ls, err := ParseRateLimits(limits)
+	if err != nil {
+		t.Fatal("failed to parse rate limits: " + err.Error())
+	}
+
+	now := time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	rl := NewRateLimiter(ls, store)
+	rl.now = func() time.Time { return now }
+
+	s := NewServer(&principalStorage{}, "", 10*time.Second, TLSConfig{})
+	s.SetAuthenticator(a)
+	s.SetRateLimiter(rl)
+	require.NoError(t, s.Start())
+
+	return s, &now
+}
+
+func TestServer_rateLimit(t *testing.T) {
+	s, now := initRateLimitServer(t, nil,
+		[]string{"GET /news/:news_id=1/s:2"}, NewMemoryLimiterStore())
+	defer s.Stop()
+
+	for i := 0; i < 2; i++ {
+		res := serve(s, http.MethodGet, "/news/1", nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, "2", res.Header().Get("RateLimit-Limit"))
+	}
+
+	res := serve(s, http.MethodGet, "/news/1", nil)
+	assert.Equal(t, http.StatusTooManyRequests, res.Code)
+	assert.Equal(t, "1", res.Header().Get("Retry-After"))
+	assert.Equal(t, "0", res.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2", res.Header().Get("RateLimit-Reset"))
+
+	// Other clients have their own buckets.
+	req := httptest.NewRequest(http.MethodGet, "/news/1", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	res = httptest.NewRecorder()
+	s.echo.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	// Proxy headers of untrusted clients are ignored.
+	res = serve(s, http.MethodGet, "/news/1",
+		map[string]string{"X-Real-IP": "10.0.0.2"})
+	assert.Equal(t, http.StatusTooManyRequests, res.Code)
+
+	// Routes without limits aren't limited.
+	res = serve(s, http.MethodGet, "/news:export", nil)
+	assert.Empty(t, res.Header().Get("RateLimit-Limit"))
+
+	*now = now.Add(time.Second)
+
+	res = serve(s, http.MethodGet, "/news/1", nil)
+	assert.Equal(t, http.StatusOK, res.Code)
+}
+
+func TestServer_rateLimit_principal(t *testing.T) {
+	s, _ := initRateLimitServer(t, APIKeys{
+		"k1": {Name: "alice", Role: entity.RoleReader},
+		"k2": {Name: "bob", Role: entity.RoleReader},
+	}, []string{"*=1/m"}, NewMemoryLimiterStore())
+	defer s.Stop()
+
+	res := serve(s, http.MethodGet, "/news/1",
+		map[string]string{"X-API-Key": "k1"})
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	// The same IP address, but other principal.
+	res = serve(s, http.MethodGet, "/news/1",
+		map[string]string{"X-API-Key": "k2"})
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	res = serve(s, http.MethodGet, "/news/1",
+		map[string]string{"X-API-Key": "k1"})
+	assert.Equal(t, http.StatusTooManyRequests, res.Code)
+	assert.Equal(t, "60", res.Header().Get("Retry-After"))
+}
+
+func TestServer_limitAuthFailures(t *testing.T) {
+	s, now := initRateLimitServer(t, APIKeys{
+		"k1": {Name: "alice", Role: entity.RoleReader},
+	}, []string{AuthFailures + "=2/m"}, NewMemoryLimiterStore())
+	defer s.Stop()
+
+	for i := 0; i < 2; i++ {
+		res := serve(s, http.MethodGet, "/news/1",
+			map[string]string{"X-API-Key": "k2"})
+		assert.Equal(t, http.StatusUnauthorized, res.Code)
+	}
+
+	res := serve(s, http.MethodGet, "/news/1",
+		map[string]string{"X-API-Key": "k3"})
+	assert.Equal(t, http.StatusTooManyRequests, res.Code)
+	assert.Equal(t, "30", res.Header().Get("Retry-After"))
+
+	// Valid key isn't confirmed while failures are limited.
+	res = serve(s, http.MethodGet, "/news/1",
+		map[string]string{"X-API-Key": "k1"})
+	assert.Equal(t, http.StatusTooManyRequests, res.Code)
+
+	// Requests without credentials aren't authentications.
+	res = serve(s, http.MethodGet, "/news/1", nil)
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+
+	*now = now.Add(30 * time.Second)
+
+	res = serve(s, http.MethodGet, "/news/1",
+		map[string]string{"X-API-Key": "k1"})
+	assert.Equal(t, http.StatusOK, res.Code)
+}
+
+func TestNATSLimiterStore(t *testing.T) {
+	es := nats.NewEmbeddedServer("127.0.0.1:0")
+	if !assert.NoError(t, es.Start()) {
+		return
+	}
+	defer es.Stop()
+
+	limits := map[string]RateLimit{AnyRoute: {Rate: 1, Burst: 2}}
+
+	// Two stores are two web servers sharing limits.
+	s1, err := NewNATSLimiterStore(es.URL(), limits)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer s1.Close()
+
+	s2, err := NewNATSLimiterStore(es.URL(), limits)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer s2.Close()
+
+	now := time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)
+	l := limits[AnyRoute]
+
+	_, taken, err := s1.Take("ip:[::1]", l, now)
+	if assert.NoError(t, err) {
+		assert.True(t, taken)
+	}
+
+	b, taken, err := s2.Take("ip:[::1]", l, now)
+	if assert.NoError(t, err) {
+		assert.True(t, taken)
+		assert.Equal(t, 0.0, b.Tokens)
+	}
+
+	_, taken, err = s1.Take("ip:[::1]", l, now)
+	if assert.NoError(t, err) {
+		assert.False(t, taken)
+	}
+
+	b, err = s2.Peek("ip:[::1]", l, now.Add(time.Second))
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1.0, b.Tokens)
+	}
+
+	_, taken, err = s1.Take("ip:[::1]", l, now.Add(time.Second))
+	if assert.NoError(t, err) {
+		assert.True(t, taken)
+	}
+}
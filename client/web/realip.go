@@ -0,0 +1,95 @@
+package web
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo"
+)
+
+// ParseTrustedProxies parses IP addresses and CIDR networks of trusted
+// proxies.
+func ParseTrustedProxies(specs []string) ([]*net.IPNet, error) {
+	var ns []*net.IPNet
+
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+
+		if !strings.Contains(spec, "/") {
+			ip := net.ParseIP(spec)
+			if ip == nil {
+				return nil, errors.New("invalid trusted proxy " + spec)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			ns = append(ns, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, n, err := net.ParseCIDR(spec)
+		if err != nil {
+			return nil, errors.New("invalid trusted proxy " + spec)
+		}
+		ns = append(ns, n)
+	}
+
+	return ns, nil
+}
+
+// SetTrustedProxies makes server take client IP address from
+// X-Forwarded-For and X-Real-IP headers of requests of proxies ps.
+// Headers are ignored by default, since any client can set them. It must
+// be called before Start.
+func (s *Server) SetTrustedProxies(ps []*net.IPNet) {
+	s.trustedProxies = ps
+}
+
+func (s *Server) trustedProxy(ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, n := range s.trustedProxies {
+		if n.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// realIP returns IP address of client of r. It's remote address of
+// connection unless it's trusted proxy. X-Forwarded-For is walked from
+// the end, so that addresses appended by trusted proxies are skipped and
+// the ones forged by client are not reached.
+func (s *Server) realIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	if !s.trustedProxy(ip) {
+		return ip
+	}
+
+	xff := strings.Join(r.Header[echo.HeaderXForwardedFor], ",")
+	if xff == "" {
+		if xri := strings.TrimSpace(r.Header.Get(echo.HeaderXRealIP)); xri != "" {
+			return xri
+		}
+		return ip
+	}
+
+	fs := strings.Split(xff, ",")
+	for i := len(fs) - 1; i >= 0; i-- {
+		ip = strings.TrimSpace(fs[i])
+		if !s.trustedProxy(ip) {
+			break
+		}
+	}
+
+	return ip
+}
@@ -0,0 +1,122 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	ns, err := ParseTrustedProxies([]string{"10.0.0.1", "192.168.0.0/16",
+		"::1"})
+	if assert.NoError(t, err) && assert.Len(t, ns, 3) {
+		assert.Equal(t, "10.0.0.1/32", ns[0].String())
+		assert.Equal(t, "192.168.0.0/16", ns[1].String())
+		assert.Equal(t, "::1/128", ns[2].String())
+	}
+
+	for _, spec := range []string{"", "10.0.0", "10.0.0.0/33"} {
+		_, err = ParseTrustedProxies([]string{spec})
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestServer_realIP(t *testing.T) {
+	s := NewServer(&mockStorage{}, "", 10*time.Second, TLSConfig{})
+
+	ps, err := ParseTrustedProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatal("failed to parse trusted proxies: " + err.Error())
+	}
+	s.SetTrustedProxies(ps)
+
+	for _, c := range []struct {
+		remoteAddr string
+		header     map[string]string
+		want       string
+	}{{
+		remoteAddr: "192.0.2.1:1234",
+		header:     map[string]string{"X-Forwarded-For": "198.51.100.1"},
+		want:       "192.0.2.1",
+	}, {
+		remoteAddr: "192.0.2.1:1234",
+		header:     map[string]string{"X-Real-IP": "198.51.100.1"},
+		want:       "192.0.2.1",
+	}, {
+		remoteAddr: "10.0.0.1:1234",
+		want:       "10.0.0.1",
+	}, {
+		remoteAddr: "10.0.0.1:1234",
+		header:     map[string]string{"X-Real-IP": "198.51.100.1"},
+		want:       "198.51.100.1",
+	}, {
+		// Address forged by client precedes the one appended by proxy.
+		remoteAddr: "10.0.0.1:1234",
+		header: map[string]string{
+			"X-Forwarded-For": "203.0.113.1, 198.51.100.1, 10.0.0.2",
+		},
+		want: "198.51.100.1",
+	}} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = c.remoteAddr
+		for k, v := range c.header {
+			r.Header.Set(k, v)
+		}
+		assert.Equal(t, c.want, s.realIP(r), c)
+	}
+}
+
+func TestServer_baseURL(t *testing.T) {
+	s := NewServer(&mockStorage{}, "", 10*time.Second, TLSConfig{})
+
+	ps, err := ParseTrustedProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatal("failed to parse trusted proxies: " + err.Error())
+	}
+	s.SetTrustedProxies(ps)
+
+	e := echo.New()
+
+	for _, c := range []struct {
+		remoteAddr string
+		header     map[string]string
+		want       string
+	}{{
+		remoteAddr: "192.0.2.1:1234",
+		want:       "http://example.com",
+	}, {
+		remoteAddr: "192.0.2.1:1234",
+		header:     map[string]string{"X-Forwarded-Proto": "https"},
+		want:       "http://example.com",
+	}, {
+		remoteAddr: "192.0.2.1:1234",
+		header:     map[string]string{"X-Forwarded-Ssl": "on"},
+		want:       "http://example.com",
+	}, {
+		remoteAddr: "10.0.0.1:1234",
+		header:     map[string]string{"X-Forwarded-Proto": "https"},
+		want:       "https://example.com",
+	}, {
+		remoteAddr: "10.0.0.1:1234",
+		header:     map[string]string{"X-Forwarded-Ssl": "on"},
+		want:       "https://example.com",
+	}} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = c.remoteAddr
+		for k, v := range c.header {
+			r.Header.Set(k, v)
+		}
+		assert.Equal(t, c.want,
+			s.baseURL(e.NewContext(r, httptest.NewRecorder())), c)
+	}
+
+	s.SetPublicURL("https://news.example.com/")
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.Equal(t, "https://news.example.com",
+		s.baseURL(e.NewContext(r, httptest.NewRecorder())))
+}
@@ -0,0 +1,54 @@
+package web
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/labstack/echo"
+)
+
+// maxRequestIDLen is maximum length of request id accepted from client.
+const maxRequestIDLen = 128
+
+// validRequestID tells whether id given by client can be logged as is.
+// Only printable ASCII without spaces is allowed, so that id can't
+// forge log lines or headers.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	// Reading of random bytes doesn't fail on supported systems.
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// requestID takes request id from X-Request-ID header or generates one
+// if it's missing or invalid. Id is responded in the same header and is
+// put to request context, so that storages pass it to their logs.
+func requestID(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+
+		id := req.Header.Get(echo.HeaderXRequestID)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		c.Response().Header().Set(echo.HeaderXRequestID, id)
+		c.SetRequest(req.WithContext(
+			entity.ContextWithRequestID(req.Context(), id)))
+
+		return next(c)
+	}
+}
@@ -0,0 +1,80 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/labstack/echo"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// requestIDStorage remembers request id of the last getting request.
+type requestIDStorage struct {
+	mockStorage
+	requestID chan string
+}
+
+func (s *requestIDStorage) News(ctx context.Context, id int64) (
+	entity.News, error) {
+	rid, _ := entity.RequestIDFromContext(ctx)
+	s.requestID <- rid
+	return entity.News{ID: id}, nil
+}
+
+func TestValidRequestID(t *testing.T) {
+	for id, want := range map[string]bool{
+		"":                       false,
+		"abc-123":                true,
+		"a b":                    false,
+		"a\nb":                   false,
+		"абв":                    false,
+		strings.Repeat("a", 128): true,
+		strings.Repeat("a", 129): false,
+	} {
+		assert.Equal(t, want, validRequestID(id), id)
+	}
+}
+
+func TestServer_requestID(t *testing.T) {
+	hook := test.NewGlobal()
+	defer logrus.StandardLogger().ReplaceHooks(logrus.LevelHooks{})
+
+	rs := &requestIDStorage{requestID: make(chan string, 1)}
+	s := initServerWith(t, rs)
+	defer s.Stop()
+
+	generated := regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+	res := serve(s, http.MethodGet, "/v2/news/1", nil)
+	id := res.Header().Get(echo.HeaderXRequestID)
+	assert.Regexp(t, generated, id)
+	assert.Equal(t, id, <-rs.requestID)
+
+	e := hook.LastEntry()
+	if assert.NotNil(t, e) {
+		assert.Equal(t, id, e.Data["request_id"])
+	}
+
+	res = serve(s, http.MethodGet, "/v2/news/1",
+		map[string]string{echo.HeaderXRequestID: "req-1"})
+	assert.Equal(t, "req-1", res.Header().Get(echo.HeaderXRequestID))
+	assert.Equal(t, "req-1", <-rs.requestID)
+
+	e = hook.LastEntry()
+	if assert.NotNil(t, e) {
+		assert.Equal(t, "req-1", e.Data["request_id"])
+	}
+
+	// Invalid id is replaced.
+	res = serve(s, http.MethodGet, "/v2/news/1",
+		map[string]string{echo.HeaderXRequestID: "req 1"})
+	id = res.Header().Get(echo.HeaderXRequestID)
+	assert.Regexp(t, generated, id)
+	assert.Equal(t, id, <-rs.requestID)
+}
@@ -2,13 +2,19 @@ package web
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dimuls/news-storage/entity"
+	"github.com/graphql-go/graphql"
 	"github.com/labstack/echo"
 	"github.com/labstack/echo/middleware"
 	"github.com/sirupsen/logrus"
@@ -18,23 +24,202 @@ type Storage interface {
 	News(ctx context.Context, id int64) (entity.News, error)
 }
 
+// Importer is implemented by storages which import news in bulk. News
+// import endpoint responds with 501 Not Implemented for other storages.
+type Importer interface {
+	ImportNews(ctx context.Context, src entity.ImportSource,
+		o entity.ImportOptions) (entity.ImportResult, error)
+}
+
+// Exporter is implemented by storages which export news as a stream.
+// News export endpoint responds with 501 Not Implemented for other
+// storages.
+type Exporter interface {
+	ExportNews(ctx context.Context, f entity.NewsFilter,
+		fn func(entity.News) error) error
+}
+
+// Lister is implemented by storages which list the latest news. News
+// feeds respond with 501 Not Implemented for other storages.
+type Lister interface {
+	ListNews(ctx context.Context, f entity.NewsFilter) ([]entity.News,
+		error)
+}
+
+// problem is error response body in RFC 7807 problem details format.
+type problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// writeProblem responds with problem of status code and detail msg.
+// Detail is omitted if it's just status text.
+func writeProblem(c echo.Context, code int, msg string) error {
+	p := problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(code),
+		Status: code,
+	}
+	if msg != p.Title {
+		p.Detail = msg
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	return c.Blob(code, "application/problem+json", data)
+}
+
 type Server struct {
+	// shutdownTimeout is time.Duration accessed atomically, because it
+	// can be changed while server is running.
+	shutdownTimeout int64
+	// feedCount is default number of feed items accessed atomically for
+	// the same reason.
+	feedCount int64
+
 	storage  Storage
 	bindAddr string
-	echo     *echo.Echo
-	wg       sync.WaitGroup
-	log      *logrus.Entry
+	auth     Authenticator
+	limiter  *RateLimiter
+	// limiterStore is store of limiter created by NewServerFromConfig,
+	// it's closed on stop.
+	limiterStore *NATSLimiterStore
+	// trustedProxies are networks of proxies which client IP address is
+	// taken from headers of.
+	trustedProxies []*net.IPNet
+
+	// tls is HTTPS config, tlsFiles is nil and server serves plain HTTP
+	// if it isn't enabled.
+	tls      TLSConfig
+	tlsFiles *tlsReloader
+	// redirect is plain HTTP server which redirects to HTTPS, it's nil
+	// if redirect address isn't set.
+	redirect *http.Server
+
+	// hub fans out news events of storage which is Subscriber.
+	hub              *newsHub
+	maxStreamClients int
+	unsubscribe      func() error
+
+	graphQLSchema graphql.Schema
+	playground    bool
+
+	pages       *pageTemplates
+	templateDir string
+	publicURL   string
+
+	// v1Deprecation is date since which v1 API is deprecated and
+	// v1Sunset is date when it's removed, they are zero if they aren't
+	// announced.
+	v1Deprecation time.Time
+	v1Sunset      time.Time
+
+	// now returns current time, it's replaced in tests.
+	now func() time.Time
+
+	echo *echo.Echo
+	wg   sync.WaitGroup
+	log  *logrus.Entry
 }
 
-func NewServer(s Storage, bindAddr string) *Server {
-	return &Server{
-		storage:  s,
-		bindAddr: bindAddr,
-		log:      logrus.WithField("subsystem", "web_server"),
+// NewServer creates server of storage s which listens bindAddr. It
+// serves HTTPS and HTTP/2 if t is enabled and plain HTTP otherwise.
+func NewServer(s Storage, bindAddr string, shutdownTimeout time.Duration,
+	t TLSConfig) *Server {
+	ws := &Server{
+		shutdownTimeout:  int64(shutdownTimeout),
+		feedCount:        DefaultFeedCount,
+		maxStreamClients: DefaultMaxStreamClients,
+		storage:          s,
+		bindAddr:         bindAddr,
+		tls:              t,
+		now:              time.Now,
+		log:              logrus.WithField("subsystem", "web_server"),
+	}
+	if t.enabled() {
+		ws.tlsFiles = newTLSReloader(t, ws.log)
 	}
+	return ws
+}
+
+// SetAuthenticator enables authentication of requests by a. It must be
+// called before Start.
+func (s *Server) SetAuthenticator(a Authenticator) {
+	s.auth = a
+}
+
+// SetRateLimiter enables rate limiting of requests by rl. It must be
+// called before Start.
+func (s *Server) SetRateLimiter(rl *RateLimiter) {
+	s.limiter = rl
+}
+
+// SetGraphQLPlayground enables GraphQL Playground page. It must be
+// called before Start.
+func (s *Server) SetGraphQLPlayground(enabled bool) {
+	s.playground = enabled
+}
+
+// SetTemplateDir makes server reparse HTML templates from dir on every
+// render instead of using embedded ones. It's for development and must
+// be called before Start.
+func (s *Server) SetTemplateDir(dir string) {
+	s.templateDir = dir
+}
+
+// SetPublicURL sets URL which server is reachable by, like
+// https://news.example.com. It's base of canonical URLs of pages and
+// links of feeds, request host is used if it isn't set. It must be
+// called before Start.
+func (s *Server) SetPublicURL(u string) {
+	s.publicURL = strings.TrimSuffix(u, "/")
+}
+
+// SetV1Deprecation announces date since which v1 and unversioned API
+// are deprecated in Deprecation header of their responses. It must be
+// called before Start.
+func (s *Server) SetV1Deprecation(t time.Time) {
+	s.v1Deprecation = t
 }
 
-func (s *Server) Start() {
+// SetV1Sunset announces date when deprecated v1 and unversioned API are
+// removed in Sunset header of their responses. It must be called before
+// Start.
+func (s *Server) SetV1Sunset(t time.Time) {
+	s.v1Sunset = t
+}
+
+// Start starts serving in background. It fails if TLS files can't be
+// loaded, so that misconfigured server doesn't look like a running one.
+func (s *Server) Start() error {
+	var err error
+
+	if s.tlsFiles != nil {
+		// Files are reloaded on handshakes, they are loaded here to fail
+		// fast.
+		_, err = s.tlsFiles.current()
+		if err != nil {
+			return errors.New("failed to load TLS files: " + err.Error())
+		}
+	}
+
+	s.graphQLSchema, err = s.newGraphQLSchema()
+	if err != nil {
+		// Schema is static, so it fails only by programming error.
+		panic("failed to create GraphQL schema: " + err.Error())
+	}
+
+	s.pages, err = newPageTemplates(s.templateDir)
+	if err != nil {
+		// Embedded templates fail only by programming error.
+		panic("failed to parse page templates: " + err.Error())
+	}
+
 	e := echo.New()
 
 	e.HideBanner = true
@@ -60,9 +245,14 @@ func (s *Server) Start() {
 
 		// Send response
 		if !c.Response().Committed {
-			if c.Request().Method == http.MethodHead { // Issue #608
+			// Errors of versioned API are problem details, other routes
+			// keep plain text errors which their clients expect.
+			switch {
+			case c.Request().Method == http.MethodHead: // Issue #608
 				err = c.NoContent(code)
-			} else {
+			case versionedPath(c.Request().URL.Path):
+				err = writeProblem(c, code, msg.(string))
+			default:
 				err = c.String(code, msg.(string))
 			}
 			if err != nil {
@@ -71,17 +261,65 @@ func (s *Server) Start() {
 		}
 	}
 
-	e.Use(middleware.Recover(), logrusLogger)
+	// Rate limits are applied after authentication, so that authenticated
+	// clients are limited by principal instead of IP address. Failed
+	// authentications are limited by IP address in authentication.
+	e.Use(middleware.Recover(), requestID, s.logrusLogger,
+		s.authenticate, s.rateLimit)
 
-	e.GET("/news/:news_id", s.getNews)
+	reader := s.requireRole(entity.RoleReader)
+
+	// News API is versioned, unversioned routes are aliases of v1.
+	for _, v := range apiVersions {
+		s.newsRoutes(e, v)
+	}
+
+	e.GET("/news", s.getNewsList, reader)
+	e.GET("/feed.rss", s.getFeed(feedRSS), reader)
+	e.GET("/feed.atom", s.getFeed(feedAtom), reader)
+	e.GET("/sitemap.xml", s.getSitemapIndex, reader)
+	e.GET("/sitemaps/:name", s.getDaySitemap, reader)
+	e.GET("/news-sitemap.xml", s.getNewsSitemap, reader)
+	e.GET("/graphql", s.graphQL, reader)
+	e.POST("/graphql", s.graphQL, reader)
+	e.GET("/openapi.json", s.getOpenAPI)
+	if s.playground {
+		// Page itself is public, queries made by it are authenticated.
+		e.GET("/graphql/playground", s.graphQLPlayground)
+	}
 
 	s.echo = e
 
+	if sub, ok := s.storage.(Subscriber); ok {
+		hub := newNewsHub(s.maxStreamClients)
+
+		unsubscribe, err := sub.SubscribeNews(hub.publish)
+		if err != nil {
+			// Stream endpoints answer 501 Not Implemented without hub
+			// instead of streaming nothing.
+			s.log.WithError(err).Error(
+				"failed to subscribe to news, news stream is disabled")
+		} else {
+			s.hub = hub
+			s.unsubscribe = unsubscribe
+		}
+	}
+
+	if s.tlsFiles != nil {
+		e.TLSServer.Addr = s.bindAddr
+		e.TLSServer.TLSConfig = s.tlsFiles.serverConfig()
+	}
+
 	s.wg.Add(1)
 	go func() {
 		defer s.wg.Done()
 		for {
-			err := s.echo.Start(s.bindAddr)
+			var err error
+			if s.tlsFiles != nil {
+				err = s.echo.StartServer(s.echo.TLSServer)
+			} else {
+				err = s.echo.Start(s.bindAddr)
+			}
 			if err != nil {
 				if err == http.ErrServerClosed {
 					s.log.Info("server is closed")
@@ -92,21 +330,88 @@ func (s *Server) Start() {
 			}
 		}
 	}()
+
+	if s.tlsFiles != nil && s.tls.RedirectAddr != "" {
+		s.redirect = &http.Server{
+			Addr:    s.tls.RedirectAddr,
+			Handler: http.HandlerFunc(s.redirectToHTTPS),
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			for {
+				err := s.redirect.ListenAndServe()
+				if err != nil {
+					if err == http.ErrServerClosed {
+						s.log.Info("redirect server is closed")
+						return
+					}
+					s.log.WithError(err).Error(
+						"failed to start redirect server")
+					time.Sleep(5 * time.Second)
+				}
+			}
+		}()
+	}
+	return nil
+}
+
+// SetFeedCount changes default number of feed items.
+func (s *Server) SetFeedCount(n int) {
+	atomic.StoreInt64(&s.feedCount, int64(n))
+}
+
+// SetMaxStreamClients limits number of news stream clients served at
+// once. It must be called before Start.
+func (s *Server) SetMaxStreamClients(n int) {
+	s.maxStreamClients = n
+}
+
+// SetShutdownTimeout changes timeout of graceful shutdown made by Stop.
+func (s *Server) SetShutdownTimeout(d time.Duration) {
+	atomic.StoreInt64(&s.shutdownTimeout, int64(d))
 }
 
 func (s *Server) Stop() {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(),
+		time.Duration(atomic.LoadInt64(&s.shutdownTimeout)))
 	defer cancel()
 
+	// Streams never end by themselves, so they are ended before
+	// graceful shutdown which waits for requests.
+	if s.unsubscribe != nil {
+		err := s.unsubscribe()
+		if err != nil {
+			s.log.WithError(err).Error("failed to unsubscribe from news")
+		}
+	}
+	if s.hub != nil {
+		s.hub.close()
+	}
+
+	if s.redirect != nil {
+		err := s.redirect.Shutdown(ctx)
+		if err != nil {
+			s.log.WithError(err).Error(
+				"failed to graceful shutdown redirect server")
+		}
+	}
+
 	err := s.echo.Shutdown(ctx)
 	if err != nil {
 		s.log.WithError(err).Error("failed to graceful shutdown")
 	}
 
 	s.wg.Wait()
+
+	if s.limiterStore != nil {
+		s.limiterStore.Close()
+	}
 }
 
-func logrusLogger(next echo.HandlerFunc) echo.HandlerFunc {
+func (s *Server) logrusLogger(
+	next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		start := time.Now()
 
@@ -133,7 +438,7 @@ func logrusLogger(next echo.HandlerFunc) echo.HandlerFunc {
 
 		entry := logrus.WithFields(map[string]interface{}{
 			"subsystem":    "web_server",
-			"remote_ip":    c.RealIP(),
+			"remote_ip":    s.realIP(req),
 			"host":         req.Host,
 			"query_params": c.QueryParams(),
 			"uri":          req.RequestURI,
@@ -145,8 +450,13 @@ func logrusLogger(next echo.HandlerFunc) echo.HandlerFunc {
 			"latency":      stop.Sub(start).String(),
 			"bytes_in":     bytesIn,
 			"bytes_out":    strconv.FormatInt(res.Size, 10),
+			"request_id":   res.Header().Get(echo.HeaderXRequestID),
 		})
 
+		if p, ok := entity.PrincipalFromContext(req.Context()); ok {
+			entry = entry.WithField("principal", p.Name)
+		}
+
 		const msg = "request handled"
 
 		if res.Status >= 500 {
@@ -0,0 +1,260 @@
+package web
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/labstack/echo"
+)
+
+// DayCounter is implemented by storages which count news of every day.
+// Sitemap index responds with 501 Not Implemented for other storages.
+type DayCounter interface {
+	NewsDays(ctx context.Context) ([]entity.NewsDay, error)
+}
+
+const (
+	// maxSitemapURLs is maximum number of URLs in sitemap allowed by
+	// sitemaps protocol. Days with more news have many sitemaps.
+	maxSitemapURLs = 50000
+
+	// newsSitemapAge is age of the oldest news in Google News sitemap.
+	newsSitemapAge = 48 * time.Hour
+	// maxNewsSitemapURLs is maximum number of URLs in Google News
+	// sitemap.
+	maxNewsSitemapURLs = 1000
+	newsLanguage       = "en"
+
+	sitemapNamespace     = "http://www.sitemaps.org/schemas/sitemap/0.9"
+	newsSitemapNamespace = "http://www.google.com/schemas/sitemap-news/0.9"
+)
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	XMLNS    string       `xml:"xmlns,attr"`
+	Sitemaps []sitemapRef `xml:"sitemap"`
+}
+
+type sitemapRef struct {
+	Loc string `xml:"loc"`
+}
+
+// urlSet is sitemap. Google News extension elements are written with
+// news prefix declared by XMLNSNews, because some crawlers don't resolve
+// namespaces.
+type urlSet struct {
+	XMLName   xml.Name     `xml:"urlset"`
+	XMLNS     string       `xml:"xmlns,attr"`
+	XMLNSNews string       `xml:"xmlns:news,attr,omitempty"`
+	URLs      []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string       `xml:"loc"`
+	LastMod string       `xml:"lastmod,omitempty"`
+	News    *sitemapNews `xml:"news:news,omitempty"`
+}
+
+type sitemapNews struct {
+	Publication     newsPublication `xml:"news:publication"`
+	PublicationDate string          `xml:"news:publication_date"`
+	Title           string          `xml:"news:title"`
+}
+
+type newsPublication struct {
+	Name     string `xml:"news:name"`
+	Language string `xml:"news:language"`
+}
+
+// writeSitemap responds with sitemap or sitemap index v.
+func writeSitemap(c echo.Context, v interface{}) error {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		return errors.New("failed to marshal sitemap: " + err.Error())
+	}
+
+	return c.Blob(http.StatusOK, "application/xml; charset=UTF-8",
+		append([]byte(xml.Header), data...))
+}
+
+// sitemapName returns name of page of day sitemap. Pages are numbered
+// from 1, the first one has no number, e.g. 2019-01-02.xml and
+// 2019-01-02-2.xml.
+func sitemapName(day time.Time, page int) string {
+	name := day.UTC().Format("2006-01-02")
+	if page > 1 {
+		name += "-" + strconv.Itoa(page)
+	}
+	return name + ".xml"
+}
+
+// parseSitemapName parses name made by sitemapName.
+func parseSitemapName(name string) (time.Time, int, bool) {
+	const dayLen = len("2006-01-02")
+
+	name = strings.TrimSuffix(name, ".xml")
+	if len(name) < dayLen {
+		return time.Time{}, 0, false
+	}
+
+	day, err := time.ParseInLocation("2006-01-02", name[:dayLen], time.UTC)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+
+	page := 1
+	if rest := name[dayLen:]; rest != "" {
+		if rest[0] != '-' {
+			return time.Time{}, 0, false
+		}
+		// The first page has only one name.
+		page, err = strconv.Atoi(rest[1:])
+		if err != nil || page < 2 || rest[1] == '0' {
+			return time.Time{}, 0, false
+		}
+	}
+
+	return day, page, true
+}
+
+// getSitemapIndex responds with sitemap index of day sitemaps. Days
+// which have more news than one sitemap allows are split into pages.
+func (s *Server) getSitemapIndex(c echo.Context) error {
+	dc, ok := s.storage.(DayCounter)
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotImplemented,
+			"storage doesn't support counting news of days")
+	}
+
+	days, err := dc.NewsDays(c.Request().Context())
+	if err != nil {
+		return errors.New("failed to get news days: " + err.Error())
+	}
+
+	base := s.baseURL(c) + "/sitemaps/"
+
+	index := sitemapIndex{XMLNS: sitemapNamespace}
+
+	for _, d := range days {
+		pages := int((d.Count + maxSitemapURLs - 1) / maxSitemapURLs)
+		for p := 1; p <= pages; p++ {
+			index.Sitemaps = append(index.Sitemaps, sitemapRef{
+				Loc: base + sitemapName(d.Date, p),
+			})
+		}
+	}
+
+	return writeSitemap(c, index)
+}
+
+// errSitemapFull stops export of news when sitemap page is full.
+var errSitemapFull = errors.New("sitemap is full")
+
+// getDaySitemap responds with page of sitemap of news of day. News are
+// exported in date order, so pages of day are stable while its news
+// aren't added or deleted.
+func (s *Server) getDaySitemap(c echo.Context) error {
+	exp, ok := s.storage.(Exporter)
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotImplemented,
+			"storage doesn't support export")
+	}
+
+	day, page, ok := parseSitemapName(c.Param("name"))
+	if !ok {
+		return echo.ErrNotFound
+	}
+
+	f := entity.NewsFilter{
+		From: day,
+		To:   day.AddDate(0, 0, 1).Add(-time.Nanosecond),
+	}
+
+	var (
+		base = s.baseURL(c)
+		skip = (page - 1) * maxSitemapURLs
+		set  = urlSet{XMLNS: sitemapNamespace}
+	)
+
+	err := exp.ExportNews(c.Request().Context(), f,
+		func(n entity.News) error {
+			if skip > 0 {
+				skip--
+				return nil
+			}
+			set.URLs = append(set.URLs, sitemapURL{
+				Loc:     newsLink(base, n),
+				LastMod: n.Date.UTC().Format(time.RFC3339),
+			})
+			if len(set.URLs) == maxSitemapURLs {
+				return errSitemapFull
+			}
+			return nil
+		})
+	if err != nil && err != errSitemapFull {
+		return errors.New("failed to export news: " + err.Error())
+	}
+
+	// Pages after the last one don't exist, but the first one is empty
+	// if all news of day are deleted after index is got.
+	if page > 1 && len(set.URLs) == 0 {
+		return echo.ErrNotFound
+	}
+
+	return writeSitemap(c, set)
+}
+
+// getNewsSitemap responds with Google News sitemap of news published in
+// the last 48 hours. Google News allows up to 1000 of them, so the
+// newest ones are given.
+func (s *Server) getNewsSitemap(c echo.Context) error {
+	l, ok := s.storage.(Lister)
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotImplemented,
+			"storage doesn't support listing")
+	}
+
+	since := s.now().Add(-newsSitemapAge)
+
+	ns, err := l.ListNews(c.Request().Context(), entity.NewsFilter{
+		From:  since,
+		Limit: maxNewsSitemapURLs,
+	})
+	if err != nil {
+		return errors.New("failed to list news: " + err.Error())
+	}
+
+	base := s.baseURL(c)
+
+	set := urlSet{
+		XMLNS:     sitemapNamespace,
+		XMLNSNews: newsSitemapNamespace,
+	}
+
+	for _, n := range ns {
+		// Storages may filter by days only, so older news of the first
+		// day are skipped here.
+		if n.Date.Before(since) {
+			continue
+		}
+		set.URLs = append(set.URLs, sitemapURL{
+			Loc: newsLink(base, n),
+			News: &sitemapNews{
+				Publication: newsPublication{
+					Name:     feedTitle,
+					Language: newsLanguage,
+				},
+				PublicationDate: n.Date.UTC().Format(time.RFC3339),
+				Title:           n.Header,
+			},
+		})
+	}
+
+	return writeSitemap(c, set)
+}
@@ -0,0 +1,240 @@
+package web
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockSitemapStorage struct {
+	mockExportStorage
+}
+
+func (s *mockSitemapStorage) NewsDays(ctx context.Context) (
+	[]entity.NewsDay, error) {
+	args := s.Called()
+	return args.Get(0).([]entity.NewsDay), args.Error(1)
+}
+
+// Sitemaps are decoded by namespaces, so that prefixes written by server
+// are checked to be declared.
+type testURLSet struct {
+	XMLName xml.Name `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+		News    *struct {
+			Name            string `xml:"publication>name"`
+			Language        string `xml:"publication>language"`
+			PublicationDate string `xml:"publication_date"`
+			Title           string `xml:"title"`
+		} `xml:"http://www.google.com/schemas/sitemap-news/0.9 news"`
+	} `xml:"url"`
+}
+
+func TestServer_getSitemapIndex(t *testing.T) {
+	ms := &mockSitemapStorage{}
+	s := initServerWith(t, ms)
+	defer s.Stop()
+
+	ms.On("NewsDays").Return([]entity.NewsDay{{
+		Date:  time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+		Count: 3,
+	}, {
+		Date:  time.Date(2019, 1, 5, 0, 0, 0, 0, time.UTC),
+		Count: 2*maxSitemapURLs + 1,
+	}}, nil)
+
+	res := serve(s, http.MethodGet, "/sitemap.xml", nil)
+
+	ms.AssertExpectations(t)
+
+	if !assert.Equal(t, http.StatusOK, res.Code) {
+		return
+	}
+
+	assert.Equal(t, "application/xml; charset=UTF-8",
+		res.Header().Get(echo.HeaderContentType))
+
+	var index struct {
+		XMLName xml.Name `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 sitemapindex"`
+		Locs    []string `xml:"sitemap>loc"`
+	}
+	if assert.NoError(t, xml.Unmarshal(res.Body.Bytes(), &index)) {
+		assert.Equal(t, []string{
+			"http://example.com/sitemaps/2019-01-02.xml",
+			"http://example.com/sitemaps/2019-01-05.xml",
+			"http://example.com/sitemaps/2019-01-05-2.xml",
+			"http://example.com/sitemaps/2019-01-05-3.xml",
+		}, index.Locs)
+	}
+}
+
+func TestServer_getDaySitemap(t *testing.T) {
+	ms := &mockSitemapStorage{}
+	s := initServerWith(t, ms)
+	defer s.Stop()
+
+	ms.On("ExportNews", entity.NewsFilter{
+		From: time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2019, 1, 2, 23, 59, 59, 999999999, time.UTC),
+	}).Return(testExportNews[:1], nil)
+
+	res := serve(s, http.MethodGet, "/sitemaps/2019-01-02.xml", nil)
+
+	ms.AssertExpectations(t)
+
+	if !assert.Equal(t, http.StatusOK, res.Code) {
+		return
+	}
+
+	var set testURLSet
+	if !assert.NoError(t, xml.Unmarshal(res.Body.Bytes(), &set)) {
+		return
+	}
+
+	if assert.Len(t, set.URLs, 1) {
+		assert.Equal(t, "http://example.com/news/1", set.URLs[0].Loc)
+		assert.Equal(t, "2019-01-02T00:00:00Z", set.URLs[0].LastMod)
+		assert.Nil(t, set.URLs[0].News)
+	}
+}
+
+func TestServer_getDaySitemap_pages(t *testing.T) {
+	ms := &mockSitemapStorage{}
+	s := initServerWith(t, ms)
+	defer s.Stop()
+
+	day := time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	ns := make([]entity.News, maxSitemapURLs+2)
+	for i := range ns {
+		ns[i] = entity.News{ID: int64(i + 1), Header: "news", Date: day}
+	}
+
+	ms.On("ExportNews", entity.NewsFilter{
+		From: day,
+		To:   day.AddDate(0, 0, 1).Add(-time.Nanosecond),
+	}).Return(ns, nil)
+
+	for page, want := range map[int][]int64{
+		1: {1, maxSitemapURLs},
+		2: {maxSitemapURLs + 1, maxSitemapURLs + 2},
+	} {
+		res := serve(s, http.MethodGet,
+			"/sitemaps/"+sitemapName(day, page), nil)
+		if !assert.Equal(t, http.StatusOK, res.Code, page) {
+			continue
+		}
+
+		var set testURLSet
+		if !assert.NoError(t, xml.Unmarshal(res.Body.Bytes(), &set)) {
+			continue
+		}
+
+		n := len(set.URLs)
+		if assert.Equal(t, int(want[1]-want[0]+1), n, page) {
+			assert.Equal(t, "http://example.com/news/"+
+				strconv.FormatInt(want[0], 10), set.URLs[0].Loc, page)
+			assert.Equal(t, "http://example.com/news/"+
+				strconv.FormatInt(want[1], 10), set.URLs[n-1].Loc, page)
+		}
+	}
+
+	res := serve(s, http.MethodGet, "/sitemaps/2019-01-02-3.xml", nil)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+}
+
+func TestServer_getDaySitemap_notFound(t *testing.T) {
+	ms := &mockSitemapStorage{}
+	s := initServerWith(t, ms)
+	defer s.Stop()
+
+	for _, name := range []string{
+		"2019-13-01.xml",
+		"2019-01-02-1.xml",
+		"2019-01-02-02.xml",
+		"2019-01-02-.xml",
+		"2019-01-02x.xml",
+		"news.xml",
+	} {
+		res := serve(s, http.MethodGet, "/sitemaps/"+name, nil)
+		assert.Equal(t, http.StatusNotFound, res.Code, name)
+	}
+
+	// Storage isn't requested for unknown sitemaps.
+	ms.AssertExpectations(t)
+}
+
+func TestServer_getNewsSitemap(t *testing.T) {
+	ms := &mockListStorage{}
+	s := initServerWith(t, ms)
+	defer s.Stop()
+
+	s.now = func() time.Time {
+		return time.Date(2019, 1, 4, 0, 0, 0, 0, time.UTC)
+	}
+
+	ns := append(testFeedNews, entity.News{
+		ID:     3,
+		Header: "older than 48 hours",
+		Date:   time.Date(2019, 1, 1, 23, 0, 0, 0, time.UTC),
+	})
+
+	ms.On("ListNews", entity.NewsFilter{
+		From:  time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+		Limit: maxNewsSitemapURLs,
+	}).Return(ns, nil)
+
+	res := serve(s, http.MethodGet, "/news-sitemap.xml", nil)
+
+	ms.AssertExpectations(t)
+
+	if !assert.Equal(t, http.StatusOK, res.Code) {
+		return
+	}
+
+	assert.Contains(t, res.Body.String(),
+		`xmlns:news="http://www.google.com/schemas/sitemap-news/0.9"`)
+
+	var set testURLSet
+	if !assert.NoError(t, xml.Unmarshal(res.Body.Bytes(), &set)) {
+		return
+	}
+
+	if !assert.Len(t, set.URLs, 2) {
+		return
+	}
+
+	u := set.URLs[0]
+	assert.Equal(t, "http://example.com/news/2", u.Loc)
+	if assert.NotNil(t, u.News) {
+		assert.Equal(t, "News", u.News.Name)
+		assert.Equal(t, "en", u.News.Language)
+		assert.Equal(t, "2019-01-03T00:00:00Z", u.News.PublicationDate)
+		assert.Equal(t, "second & last", u.News.Title)
+	}
+
+	assert.Equal(t, "http://example.com/news/1", set.URLs[1].Loc)
+}
+
+func TestServer_sitemaps_notImplemented(t *testing.T) {
+	s := initServerWith(t, &mockStorage{})
+	defer s.Stop()
+
+	for _, target := range []string{
+		"/sitemap.xml",
+		"/sitemaps/2019-01-02.xml",
+		"/news-sitemap.xml",
+	} {
+		res := serve(s, http.MethodGet, target, nil)
+		assert.Equal(t, http.StatusNotImplemented, res.Code, target)
+	}
+}
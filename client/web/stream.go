@@ -0,0 +1,371 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/labstack/echo"
+	"golang.org/x/net/websocket"
+)
+
+// Subscriber is implemented by storages which notify about news changes.
+// News stream endpoints respond with 501 Not Implemented for other
+// storages.
+type Subscriber interface {
+	SubscribeNews(fn func(entity.NewsEvent)) (unsubscribe func() error,
+		err error)
+}
+
+const (
+	// DefaultMaxStreamClients is number of stream clients served at once
+	// if other maximum isn't set.
+	DefaultMaxStreamClients = 1000
+
+	// streamClientBuffer is number of events waiting to be sent to stream
+	// client. Client is evicted when its buffer is full.
+	streamClientBuffer = 64
+	// streamHistorySize is number of the last events kept to resume
+	// streams of reconnected clients.
+	streamHistorySize = 1000
+	// streamHeartbeatInterval is interval of SSE comments which keep
+	// idle connections open through proxies.
+	streamHeartbeatInterval = 15 * time.Second
+	// wsWriteTimeout is timeout of sending event to WebSocket client.
+	wsWriteTimeout = 10 * time.Second
+)
+
+var (
+	errStreamClosed         = errors.New("news stream is closed")
+	errTooManyStreamClients = errors.New("too many stream clients")
+)
+
+// streamEvent is news event numbered by hub. Its id is sequence number
+// prefixed by hub epoch, so that ids of other hub aren't resumed.
+type streamEvent struct {
+	entity.NewsEvent
+	seq uint64
+	id  string
+}
+
+// streamFilter selects events sent to stream client.
+type streamFilter struct {
+	// news filter without offset and limit.
+	news entity.NewsFilter
+	// types of events, nil means any.
+	types map[string]bool
+}
+
+func (f streamFilter) match(e entity.NewsEvent) bool {
+	if f.types != nil && !f.types[e.Type] {
+		return false
+	}
+	if !f.news.From.IsZero() && e.News.Date.Before(f.news.From) {
+		return false
+	}
+	if !f.news.To.IsZero() && e.News.Date.After(f.news.To) {
+		return false
+	}
+	// Query matches as case insensitive search of storages.
+	return f.news.Query == "" || strings.Contains(
+		strings.ToLower(e.News.Header), strings.ToLower(f.news.Query))
+}
+
+type streamClient struct {
+	filter streamFilter
+	events chan streamEvent
+	// done is closed when client is evicted or hub is closed.
+	done chan struct{}
+}
+
+// newsHub fans out news events to stream clients. Clients which don't
+// keep up are evicted, so that they don't hold up others or make hub
+// buffer events without limit.
+type newsHub struct {
+	mu         sync.Mutex
+	epoch      string
+	seq        uint64
+	history    []streamEvent
+	clients    map[*streamClient]struct{}
+	maxClients int
+	closed     bool
+}
+
+func newNewsHub(maxClients int) *newsHub {
+	return &newsHub{
+		epoch:      strconv.FormatInt(time.Now().UnixNano(), 36),
+		clients:    map[*streamClient]struct{}{},
+		maxClients: maxClients,
+	}
+}
+
+func (h *newsHub) publish(e entity.NewsEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+
+	h.seq++
+
+	se := streamEvent{
+		NewsEvent: e,
+		seq:       h.seq,
+		id:        h.epoch + "-" + strconv.FormatUint(h.seq, 10),
+	}
+
+	h.history = append(h.history, se)
+	if len(h.history) > streamHistorySize {
+		h.history = h.history[len(h.history)-streamHistorySize:]
+	}
+
+	for c := range h.clients {
+		if !c.filter.match(e) {
+			continue
+		}
+		select {
+		case c.events <- se:
+		default:
+			// Evicted client can reconnect and resume from the last
+			// event it got.
+			h.remove(c)
+		}
+	}
+}
+
+// lastSeq returns sequence number of event lastID if it is given by this
+// hub.
+func (h *newsHub) lastSeq(lastID string) (uint64, bool) {
+	i := strings.LastIndex(lastID, "-")
+	if i < 0 || lastID[:i] != h.epoch {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(lastID[i+1:], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return seq, true
+}
+
+// subscribe adds client which gets events matched by f. If lastID is
+// given, client gets kept events after it first. Events which are no
+// longer kept or are given by other hub are lost.
+func (h *newsHub) subscribe(f streamFilter, lastID string) (*streamClient,
+	error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, errStreamClosed
+	}
+
+	if len(h.clients) >= h.maxClients {
+		return nil, errTooManyStreamClients
+	}
+
+	var backlog []streamEvent
+
+	if seq, ok := h.lastSeq(lastID); ok {
+		for _, e := range h.history {
+			if e.seq > seq && f.match(e.NewsEvent) {
+				backlog = append(backlog, e)
+			}
+		}
+	}
+
+	c := &streamClient{
+		filter: f,
+		events: make(chan streamEvent, len(backlog)+streamClientBuffer),
+		done:   make(chan struct{}),
+	}
+
+	for _, e := range backlog {
+		c.events <- e
+	}
+
+	h.clients[c] = struct{}{}
+
+	return c, nil
+}
+
+func (h *newsHub) unsubscribe(c *streamClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.clients[c]; ok {
+		h.remove(c)
+	}
+}
+
+// remove removes client which is subscribed. Hub must be locked.
+func (h *newsHub) remove(c *streamClient) {
+	delete(h.clients, c)
+	close(c.done)
+}
+
+// close ends streams of all clients and refuses new ones.
+func (h *newsHub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+
+	for c := range h.clients {
+		h.remove(c)
+	}
+}
+
+// subscribeStream subscribes stream client with filter from from, to,
+// query and types parameters. Stream is resumed after Last-Event-ID
+// header or last_event_id parameter, the latter is for clients which
+// can't set headers.
+func (s *Server) subscribeStream(c echo.Context) (*streamClient, error) {
+	if s.hub == nil {
+		return nil, echo.NewHTTPError(http.StatusNotImplemented,
+			"storage doesn't support news stream")
+	}
+
+	var (
+		f   streamFilter
+		err error
+	)
+
+	f.news, err = filterParams(c)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if v := c.QueryParam("types"); v != "" {
+		f.types = map[string]bool{}
+		for _, t := range strings.Split(v, ",") {
+			if t != entity.NewsCreated && t != entity.NewsUpdated {
+				return nil, echo.NewHTTPError(http.StatusBadRequest,
+					"types must be created or updated")
+			}
+			f.types[t] = true
+		}
+	}
+
+	lastID := c.Request().Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.QueryParam("last_event_id")
+	}
+
+	sc, err := s.hub.subscribe(f, lastID)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusServiceUnavailable,
+			err.Error())
+	}
+
+	return sc, nil
+}
+
+// streamNews pushes news events as server-sent events. Event id is used
+// to resume stream, event type is news event type and data is news in
+// JSON.
+func (s *Server) streamNews(c echo.Context) error {
+	sc, err := s.subscribeStream(c)
+	if err != nil {
+		return err
+	}
+	defer s.hub.unsubscribe(sc)
+
+	res := c.Response()
+
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	// Disables response buffering of nginx.
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+
+		select {
+		case e := <-sc.events:
+			var data []byte
+			data, err = json.Marshal(s.newsJSON(c, e.News))
+			if err != nil {
+				s.log.WithError(err).Error("failed to marshal news")
+				return nil
+			}
+			_, err = io.WriteString(res, "id: "+e.id+"\nevent: "+e.Type+
+				"\ndata: "+string(data)+"\n\n")
+		case <-heartbeat.C:
+			_, err = io.WriteString(res, ": heartbeat\n\n")
+		case <-sc.done:
+			return nil
+		case <-c.Request().Context().Done():
+			return nil
+		}
+		if err != nil {
+			return nil
+		}
+
+		res.Flush()
+	}
+}
+
+// wsEvent is news event sent to WebSocket client.
+type wsEvent struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	// News is in shape of API version of stream.
+	News interface{} `json:"news"`
+}
+
+// streamNewsWS pushes news events to WebSocket client as JSON text
+// messages. Messages from client are ignored.
+func (s *Server) streamNewsWS(c echo.Context) error {
+	sc, err := s.subscribeStream(c)
+	if err != nil {
+		return err
+	}
+	defer s.hub.unsubscribe(sc)
+
+	// Server doesn't check origin as websocket.Handler does, because
+	// clients other than browsers don't send it.
+	websocket.Server{Handler: func(ws *websocket.Conn) {
+		defer ws.Close()
+
+		// Reading handles control frames and detects closed connection.
+		closed := make(chan struct{})
+		go func() {
+			io.Copy(ioutil.Discard, ws)
+			close(closed)
+		}()
+
+		for {
+			select {
+			case e := <-sc.events:
+				ws.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+				err := websocket.JSON.Send(ws, wsEvent{
+					ID:   e.id,
+					Type: e.Type,
+					News: s.newsJSON(c, e.News),
+				})
+				if err != nil {
+					return
+				}
+			case <-sc.done:
+				return
+			case <-closed:
+				return
+			}
+		}
+	}}.ServeHTTP(c.Response(), c.Request())
+
+	return nil
+}
@@ -0,0 +1,278 @@
+package web
+
+import (
+	"bufio"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+)
+
+// subscriberStorage publishes events to subscriber of server.
+type subscriberStorage struct {
+	mockStorage
+	publish func(entity.NewsEvent)
+}
+
+func (s *subscriberStorage) SubscribeNews(fn func(entity.NewsEvent)) (
+	func() error, error) {
+	s.publish = fn
+	return func() error { return nil }, nil
+}
+
+// failingSubscriberStorage fails to subscribe.
+type failingSubscriberStorage struct {
+	mockStorage
+}
+
+func (s *failingSubscriberStorage) SubscribeNews(
+	fn func(entity.NewsEvent)) (func() error, error) {
+	return nil, errors.New("not connected")
+}
+
+func initStreamServer(t *testing.T) (*subscriberStorage, *Server,
+	*httptest.Server) {
+	ss := &subscriberStorage{}
+	s := initServerWith(t, ss)
+	return ss, s, httptest.NewServer(s.echo)
+}
+
+func testNewsEvent(id int64, header string) entity.NewsEvent {
+	return entity.NewsEvent{
+		Type: entity.NewsCreated,
+		News: entity.News{
+			ID:     id,
+			Header: header,
+			Date:   time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+	}
+}
+
+func TestStreamFilter_match(t *testing.T) {
+	e := testNewsEvent(1, "Big News")
+
+	assert.True(t, streamFilter{}.match(e))
+	assert.True(t, streamFilter{
+		news: entity.NewsFilter{Query: "big"},
+	}.match(e))
+	assert.False(t, streamFilter{
+		news: entity.NewsFilter{Query: "small"},
+	}.match(e))
+	assert.False(t, streamFilter{news: entity.NewsFilter{
+		From: time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC),
+	}}.match(e))
+	assert.False(t, streamFilter{
+		types: map[string]bool{entity.NewsUpdated: true},
+	}.match(e))
+}
+
+func TestNewsHub_resume(t *testing.T) {
+	h := newNewsHub(10)
+
+	first, err := h.subscribe(streamFilter{}, "")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for i := int64(1); i <= 3; i++ {
+		h.publish(testNewsEvent(i, "header"))
+	}
+
+	lastID := (<-first.events).id
+
+	c, err := h.subscribe(streamFilter{}, lastID)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, int64(2), (<-c.events).News.ID)
+	assert.Equal(t, int64(3), (<-c.events).News.ID)
+
+	// Events of other hub aren't resumed.
+	c, err = h.subscribe(streamFilter{}, "other-1")
+	if assert.NoError(t, err) {
+		assert.Len(t, c.events, 0)
+	}
+}
+
+func TestNewsHub_evict(t *testing.T) {
+	h := newNewsHub(2)
+
+	slow, err := h.subscribe(streamFilter{}, "")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = h.subscribe(streamFilter{}, "")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = h.subscribe(streamFilter{}, "")
+	assert.Equal(t, errTooManyStreamClients, err)
+
+	for i := 0; i <= streamClientBuffer; i++ {
+		h.publish(testNewsEvent(int64(i), "header"))
+	}
+
+	select {
+	case <-slow.done:
+	default:
+		t.Fatal("slow client isn't evicted")
+	}
+
+	// Place of evicted client is free.
+	_, err = h.subscribe(streamFilter{}, "")
+	assert.NoError(t, err)
+
+	h.close()
+
+	_, err = h.subscribe(streamFilter{}, "")
+	assert.Equal(t, errStreamClosed, err)
+}
+
+// readSSE reads server-sent event fields until empty line.
+func readSSE(t *testing.T, r *bufio.Reader) map[string]string {
+	fields := map[string]string{}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal("failed to read event: " + err.Error())
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return fields
+		}
+		i := strings.Index(line, ": ")
+		fields[line[:i]] = line[i+2:]
+	}
+}
+
+// streamHTTPClient times out streams which aren't ended by server.
+var streamHTTPClient = &http.Client{Timeout: 5 * time.Second}
+
+func getStream(t *testing.T, ts *httptest.Server, target,
+	lastID string) *http.Response {
+	req, err := http.NewRequest(http.MethodGet, ts.URL+target, nil)
+	if err != nil {
+		t.Fatal("failed to create request: " + err.Error())
+	}
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+
+	res, err := streamHTTPClient.Do(req)
+	if err != nil {
+		t.Fatal("failed to get stream: " + err.Error())
+	}
+
+	return res
+}
+
+func TestServer_streamNews(t *testing.T) {
+	ss, s, ts := initStreamServer(t)
+	defer ts.Close()
+	defer s.Stop()
+
+	res := getStream(t, ts, "/news/stream?query=big", "")
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream",
+		res.Header.Get("Content-Type"))
+
+	ss.publish(testNewsEvent(1, "small news"))
+	ss.publish(testNewsEvent(2, "big news"))
+	ss.publish(testNewsEvent(3, "another big news"))
+
+	body := bufio.NewReader(res.Body)
+
+	e := readSSE(t, body)
+	assert.Equal(t, "created", e["event"])
+	assert.Equal(t,
+		`{"id":2,"header":"big news","date":"2019-01-02T00:00:00Z"}`,
+		e["data"])
+
+	resumed := getStream(t, ts, "/news/stream?query=big", e["id"])
+	defer resumed.Body.Close()
+
+	e = readSSE(t, bufio.NewReader(resumed.Body))
+	assert.Contains(t, e["data"], `"id":3`)
+
+	// Stop ends streams instead of waiting for them.
+	s.Stop()
+
+	_, err := ioutil.ReadAll(body)
+	assert.NoError(t, err)
+}
+
+func TestServer_streamNews_badRequest(t *testing.T) {
+	_, s, ts := initStreamServer(t)
+	defer ts.Close()
+	defer s.Stop()
+
+	res := getStream(t, ts, "/news/stream?types=deleted", "")
+	res.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
+
+func TestServer_streamNews_notImplemented(t *testing.T) {
+	s := initServerWith(t, &mockStorage{})
+	defer s.Stop()
+
+	res := serve(s, http.MethodGet, "/news/stream", nil)
+	assert.Equal(t, http.StatusNotImplemented, res.Code)
+}
+
+func TestServer_streamNews_subscribeFailed(t *testing.T) {
+	s := initServerWith(t, &failingSubscriberStorage{})
+	defer s.Stop()
+
+	res := serve(s, http.MethodGet, "/news/stream", nil)
+	assert.Equal(t, http.StatusNotImplemented, res.Code)
+
+	res = serve(s, http.MethodGet, "/news/stream/ws", nil)
+	assert.Equal(t, http.StatusNotImplemented, res.Code)
+}
+
+func TestServer_streamNewsWS(t *testing.T) {
+	ss, s, ts := initStreamServer(t)
+	defer ts.Close()
+	defer s.Stop()
+
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+
+		"/news/stream/ws?types=updated", "", ts.URL)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ws.Close()
+
+	updated := testNewsEvent(2, "header")
+	updated.Type = entity.NewsUpdated
+
+	// Subscription is made before handshake response.
+	ss.publish(testNewsEvent(1, "header"))
+	ss.publish(updated)
+
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	// Legacy stream sends news in v1 shape which entity.News has.
+	var e struct {
+		ID   string      `json:"id"`
+		Type string      `json:"type"`
+		News entity.News `json:"news"`
+	}
+	err = websocket.JSON.Receive(ws, &e)
+	if assert.NoError(t, err) {
+		assert.NotEmpty(t, e.ID)
+		assert.Equal(t, entity.NewsUpdated, e.Type)
+		assert.Equal(t, updated.News, e.News)
+	}
+}
@@ -0,0 +1,187 @@
+package web
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TLSConfig configures HTTPS of server. Server serves plain HTTP if
+// CertFile is empty.
+type TLSConfig struct {
+	// CertFile and KeyFile are PEM files of server certificate and its
+	// key. They are reloaded when changed, so that certificate is renewed
+	// without restart.
+	CertFile string
+	KeyFile  string
+	// ClientCAFile is PEM file of CA certificates which client
+	// certificates are verified by. Clients must present certificate
+	// if it's set, it's meant for internal consumers. It's reloaded when
+	// changed too.
+	ClientCAFile string
+	// RedirectAddr is address of plain HTTP server which redirects
+	// requests to HTTPS. It isn't started if it's empty.
+	RedirectAddr string
+}
+
+func (c TLSConfig) enabled() bool {
+	return c.CertFile != ""
+}
+
+// tlsReloader makes TLS config of files and remakes it when they
+// change. Modification times of files are checked on every handshake,
+// which costs much more than stat calls anyway.
+type tlsReloader struct {
+	conf TLSConfig
+
+	mu     sync.Mutex
+	mods   []time.Time
+	config *tls.Config
+
+	log *logrus.Entry
+}
+
+func newTLSReloader(c TLSConfig, log *logrus.Entry) *tlsReloader {
+	return &tlsReloader{
+		conf: c,
+		log:  log,
+	}
+}
+
+func (r *tlsReloader) files() []string {
+	fs := []string{r.conf.CertFile, r.conf.KeyFile}
+	if r.conf.ClientCAFile != "" {
+		fs = append(fs, r.conf.ClientCAFile)
+	}
+	return fs
+}
+
+// serverConfig returns TLS config of server which takes config of
+// every connection from r. HTTP/2 is offered first.
+func (r *tlsReloader) serverConfig() *tls.Config {
+	return &tls.Config{
+		NextProtos:         []string{"h2", "http/1.1"},
+		GetConfigForClient: r.getConfigForClient,
+	}
+}
+
+func (r *tlsReloader) getConfigForClient(*tls.ClientHelloInfo) (
+	*tls.Config, error) {
+	return r.current()
+}
+
+// current returns TLS config of files, it's reloaded if files have
+// changed since the last load. Previous config is kept if files fail to
+// load, since they may be in the middle of replacement.
+func (r *tlsReloader) current() (*tls.Config, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	mods, err := modTimes(r.files())
+	if err == nil && !equalTimes(mods, r.mods) {
+		var c *tls.Config
+		c, err = r.load()
+		if err == nil {
+			if r.config != nil {
+				r.log.Info("TLS files are reloaded")
+			}
+			r.config, r.mods = c, mods
+		}
+	}
+
+	if err != nil {
+		if r.config == nil {
+			return nil, err
+		}
+		r.log.WithError(err).Error(
+			"failed to reload TLS files, previous ones are used")
+	}
+
+	return r.config, nil
+}
+
+func (r *tlsReloader) load() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(r.conf.CertFile, r.conf.KeyFile)
+	if err != nil {
+		return nil, errors.New("failed to load certificate: " + err.Error())
+	}
+
+	c := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+
+	if r.conf.ClientCAFile != "" {
+		data, err := ioutil.ReadFile(r.conf.ClientCAFile)
+		if err != nil {
+			return nil, errors.New("failed to read client CA file: " +
+				err.Error())
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, errors.New("client CA file has no certificates")
+		}
+
+		c.ClientCAs = pool
+		c.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return c, nil
+}
+
+func modTimes(files []string) ([]time.Time, error) {
+	mods := make([]time.Time, len(files))
+	for i, f := range files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return nil, errors.New("failed to stat file: " + err.Error())
+		}
+		mods[i] = fi.ModTime()
+	}
+	return mods, nil
+}
+
+func equalTimes(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// redirectToHTTPS redirects request to the same URL on HTTPS port of
+// server. Redirect is permanent and keeps method, so that writing
+// requests aren't turned into reading ones.
+func (s *Server) redirectToHTTPS(w http.ResponseWriter, r *http.Request) {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	} else {
+		host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	}
+
+	_, port, err := net.SplitHostPort(s.bindAddr)
+	if err == nil && port != "443" {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+
+	http.Redirect(w, r, "https://"+host+r.URL.RequestURI(),
+		http.StatusPermanentRedirect)
+}
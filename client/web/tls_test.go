@@ -0,0 +1,302 @@
+package web
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testCA issues certificates of tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("failed to generate key: " + err.Error())
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl,
+		&key.PublicKey, key)
+	if err != nil {
+		t.Fatal("failed to create certificate: " + err.Error())
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal("failed to parse certificate: " + err.Error())
+	}
+
+	return &testCA{
+		cert: cert,
+		key:  key,
+		pem: pem.EncodeToMemory(&pem.Block{
+			Type:  "CERTIFICATE",
+			Bytes: der,
+		}),
+	}
+}
+
+// issue returns PEM certificate and key of server for 127.0.0.1 or of
+// client with common name name.
+func (ca *testCA) issue(t *testing.T, name string, client bool) (
+	certPEM, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("failed to generate key: " + err.Error())
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if client {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+		tmpl.IPAddresses = nil
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert,
+		&key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal("failed to create certificate: " + err.Error())
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal("failed to marshal key: " + err.Error())
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// rewriteFile replaces data of file path and moves its modification time
+// forward, so that change is noticed regardless of time precision of
+// file system.
+func rewriteFile(t *testing.T, path string, data []byte, mod time.Time) {
+	err := ioutil.WriteFile(path, data, 0600)
+	if err != nil {
+		t.Fatal("failed to write file: " + err.Error())
+	}
+	err = os.Chtimes(path, mod, mod)
+	if err != nil {
+		t.Fatal("failed to change file times: " + err.Error())
+	}
+}
+
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("failed to listen: " + err.Error())
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func waitListening(t *testing.T, addr string) {
+	for i := 0; i < 100; i++ {
+		c, err := net.Dial("tcp", addr)
+		if err == nil {
+			c.Close()
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("server doesn't listen " + addr)
+}
+
+func tlsClient(ca *testCA, certPEM, keyPEM []byte) *http.Client {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	c := &tls.Config{RootCAs: pool}
+	if certPEM != nil {
+		cert, _ := tls.X509KeyPair(certPEM, keyPEM)
+		c.Certificates = []tls.Certificate{cert}
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig:   c,
+			ForceAttemptHTTP2: true,
+		},
+		Timeout: 5 * time.Second,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func TestServer_TLS(t *testing.T) {
+	ca := newTestCA(t)
+
+	certPEM, keyPEM := ca.issue(t, "first", false)
+	certFile := writeTempFile(t, certPEM)
+	defer os.Remove(certFile)
+	keyFile := writeTempFile(t, keyPEM)
+	defer os.Remove(keyFile)
+
+	addr, redirectAddr := freeAddr(t), freeAddr(t)
+
+	s := NewServer(&mockStorage{}, addr, time.Second, TLSConfig{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		RedirectAddr: redirectAddr,
+	})
+	if !assert.NoError(t, s.Start()) {
+		return
+	}
+	defer s.Stop()
+
+	waitListening(t, addr)
+	waitListening(t, redirectAddr)
+
+	serverName := func() string {
+		res, err := tlsClient(ca, nil, nil).Get(
+			"https://" + addr + "/openapi.json")
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, 2, res.ProtoMajor)
+		return res.TLS.PeerCertificates[0].Subject.CommonName
+	}
+
+	assert.Equal(t, "first", serverName())
+
+	// Certificate which doesn't match key isn't loaded.
+	mod := time.Now().Add(time.Minute)
+	certPEM, keyPEM = ca.issue(t, "second", false)
+	rewriteFile(t, certFile, certPEM, mod)
+	assert.Equal(t, "first", serverName())
+
+	rewriteFile(t, keyFile, keyPEM, mod)
+	assert.Equal(t, "second", serverName())
+
+	res, err := tlsClient(ca, nil, nil).Post(
+		"http://"+redirectAddr+"/v2/news:import?x=1", "", nil)
+	if assert.NoError(t, err) {
+		res.Body.Close()
+		assert.Equal(t, http.StatusPermanentRedirect, res.StatusCode)
+		assert.Equal(t, "https://"+addr+"/v2/news:import?x=1",
+			res.Header.Get("Location"))
+	}
+}
+
+func TestServer_TLS_clientCA(t *testing.T) {
+	ca := newTestCA(t)
+
+	certPEM, keyPEM := ca.issue(t, "server", false)
+	certFile := writeTempFile(t, certPEM)
+	defer os.Remove(certFile)
+	keyFile := writeTempFile(t, keyPEM)
+	defer os.Remove(keyFile)
+	caFile := writeTempFile(t, ca.pem)
+	defer os.Remove(caFile)
+
+	addr := freeAddr(t)
+
+	s := NewServer(&mockStorage{}, addr, time.Second, TLSConfig{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientCAFile: caFile,
+	})
+	if !assert.NoError(t, s.Start()) {
+		return
+	}
+	defer s.Stop()
+
+	waitListening(t, addr)
+
+	get := func(c *http.Client) error {
+		res, err := c.Get("https://" + addr + "/openapi.json")
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		_, err = ioutil.ReadAll(res.Body)
+		return err
+	}
+
+	assert.Error(t, get(tlsClient(ca, nil, nil)))
+
+	otherCert, otherKey := newTestCA(t).issue(t, "other", true)
+	assert.Error(t, get(tlsClient(ca, otherCert, otherKey)))
+
+	clientCert, clientKey := ca.issue(t, "consumer", true)
+	assert.NoError(t, get(tlsClient(ca, clientCert, clientKey)))
+}
+
+func TestServer_redirectToHTTPS(t *testing.T) {
+	for _, c := range []struct {
+		bindAddr string
+		target   string
+		want     string
+	}{{
+		bindAddr: ":8443",
+		target:   "http://news.example.com:8080/news/1?x=1",
+		want:     "https://news.example.com:8443/news/1?x=1",
+	}, {
+		bindAddr: ":443",
+		target:   "http://news.example.com/news",
+		want:     "https://news.example.com/news",
+	}, {
+		bindAddr: ":443",
+		target:   "http://[::1]:8080/",
+		want:     "https://[::1]/",
+	}, {
+		bindAddr: "[::]:8443",
+		target:   "http://[::1]/feed.rss",
+		want:     "https://[::1]:8443/feed.rss",
+	}} {
+		s := NewServer(&mockStorage{}, c.bindAddr, time.Second, TLSConfig{})
+
+		res := httptest.NewRecorder()
+		s.redirectToHTTPS(res, httptest.NewRequest(http.MethodGet,
+			c.target, nil))
+
+		assert.Equal(t, http.StatusPermanentRedirect, res.Code, c.target)
+		assert.Equal(t, c.want, res.Header().Get("Location"), c.target)
+	}
+}
+
+func TestServer_Start_TLSFiles(t *testing.T) {
+	s := NewServer(&mockStorage{}, freeAddr(t), time.Second, TLSConfig{
+		CertFile: "missing-cert.pem",
+		KeyFile:  "missing-key.pem",
+	})
+	assert.Error(t, s.Start())
+}
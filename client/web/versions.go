@@ -0,0 +1,195 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/labstack/echo"
+)
+
+// apiVersion is version of news API. Versions differ by shape of news in
+// JSON responses, other representations and news export format are the
+// same in all of them.
+type apiVersion struct {
+	name string
+	// prefix is path prefix of version routes. Legacy routes without
+	// prefix are aliases of v1.
+	prefix string
+	// newsJSON maps news to JSON body of version, base is public URL of
+	// server.
+	newsJSON func(base string, n entity.News) interface{}
+	// deprecated versions are answered with Deprecation and Sunset
+	// headers and their usage is logged.
+	deprecated bool
+}
+
+// newsV1 is news in v1 API. It's the shape which unversioned API had,
+// so it doesn't follow changes of entity.News.
+type newsV1 struct {
+	ID         int64     `json:"id"`
+	Header     string    `json:"header"`
+	Date       time.Time `json:"date"`
+	ExternalID string    `json:"external_id,omitempty"`
+}
+
+func newsV1JSON(base string, n entity.News) interface{} {
+	return newsV1{
+		ID:         n.ID,
+		Header:     n.Header,
+		Date:       n.Date,
+		ExternalID: n.ExternalID,
+	}
+}
+
+// newsV2 is news in v2 API. Publication time is full timestamp in UTC
+// and news has its URL.
+type newsV2 struct {
+	ID          int64  `json:"id"`
+	Header      string `json:"header"`
+	PublishedAt string `json:"published_at"`
+	URL         string `json:"url"`
+	ExternalID  string `json:"external_id,omitempty"`
+}
+
+func newsV2JSON(base string, n entity.News) interface{} {
+	return newsV2{
+		ID:          n.ID,
+		Header:      n.Header,
+		PublishedAt: n.Date.UTC().Format(time.RFC3339Nano),
+		URL:         newsLink(base, n),
+		ExternalID:  n.ExternalID,
+	}
+}
+
+var (
+	apiV1 = &apiVersion{
+		name:       "v1",
+		prefix:     "/v1",
+		newsJSON:   newsV1JSON,
+		deprecated: true,
+	}
+	apiLegacy = &apiVersion{
+		name:       "v1",
+		newsJSON:   newsV1JSON,
+		deprecated: true,
+	}
+	apiV2 = &apiVersion{
+		name:     "v2",
+		prefix:   "/v2",
+		newsJSON: newsV2JSON,
+	}
+
+	// apiVersions are registered versions, the last one is current.
+	apiVersions = []*apiVersion{apiLegacy, apiV1, apiV2}
+)
+
+const apiVersionKey = "api_version"
+
+// versionOf returns API version of request. Requests of routes outside
+// of versions are treated as legacy ones.
+func versionOf(c echo.Context) *apiVersion {
+	if v, ok := c.Get(apiVersionKey).(*apiVersion); ok {
+		return v
+	}
+	return apiLegacy
+}
+
+// newsJSON maps news n to JSON body of API version of request.
+func (s *Server) newsJSON(c echo.Context, n entity.News) interface{} {
+	return versionOf(c).newsJSON(s.baseURL(c), n)
+}
+
+// useVersion puts API version v to request context. Requests of
+// deprecated version are answered with Deprecation and Sunset headers
+// if their dates are set and link to the same resource of current
+// version, and their usage is logged, so that clients which still use
+// it are known.
+func (s *Server) useVersion(v *apiVersion) echo.MiddlewareFunc {
+	current := apiVersions[len(apiVersions)-1]
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set(apiVersionKey, v)
+
+			if !v.deprecated {
+				return next(c)
+			}
+
+			req := c.Request()
+			h := c.Response().Header()
+
+			// Deprecation is RFC 9745 structured date and Sunset is RFC
+			// 8594 HTTP date.
+			if !s.v1Deprecation.IsZero() {
+				h.Set("Deprecation",
+					"@"+strconv.FormatInt(s.v1Deprecation.Unix(), 10))
+			}
+			if !s.v1Sunset.IsZero() {
+				h.Set("Sunset", s.v1Sunset.UTC().Format(http.TimeFormat))
+			}
+			h.Add("Link", "<"+current.prefix+
+				strings.TrimPrefix(req.URL.RequestURI(), v.prefix)+
+				`>; rel="successor-version"`)
+
+			log := s.log.WithFields(map[string]interface{}{
+				"api_version": v.name,
+				"legacy":      v.prefix == "",
+				"method":      req.Method,
+				"route":       c.Path(),
+				"remote_ip":   s.realIP(c.Request()),
+				"user_agent":  req.UserAgent(),
+				"request_id":  c.Response().Header().Get(echo.HeaderXRequestID),
+			})
+			if p, ok := entity.PrincipalFromContext(req.Context()); ok {
+				log = log.WithField("principal", p.Name)
+			}
+			log.Info("deprecated API version is used")
+
+			return next(c)
+		}
+	}
+}
+
+// unversionedPath returns route path p without API version prefix.
+func unversionedPath(p string) string {
+	for _, v := range apiVersions {
+		if v.prefix != "" && strings.HasPrefix(p, v.prefix+"/") {
+			return strings.TrimPrefix(p, v.prefix)
+		}
+	}
+	return p
+}
+
+// versionedPath tells whether request path p is under prefix of API
+// version. Legacy routes aren't versioned.
+func versionedPath(p string) bool {
+	for _, v := range apiVersions {
+		if v.prefix != "" && strings.HasPrefix(p, v.prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// newsRoutes registers news API routes of version v under its prefix.
+// Routes aren't grouped by echo.Group, because it routes every path
+// under prefix to group middlewares.
+func (s *Server) newsRoutes(e *echo.Echo, v *apiVersion) {
+	var (
+		version = s.useVersion(v)
+		reader  = s.requireRole(entity.RoleReader)
+		editor  = s.requireRole(entity.RoleEditor)
+	)
+
+	e.GET(v.prefix+"/news/:news_id", s.getNews, version, reader)
+	// Custom methods like /news:import are routed by op parameter,
+	// because echo treats colon as parameter start. Reading ones are GET
+	// and writing ones are POST.
+	e.GET(v.prefix+"/news:op", s.getNewsOp, version, reader)
+	e.POST(v.prefix+"/news:op", s.postNewsOp, version, editor)
+	e.GET(v.prefix+"/news/stream", s.streamNews, version, reader)
+	e.GET(v.prefix+"/news/stream/ws", s.streamNewsWS, version, reader)
+}
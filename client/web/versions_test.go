@@ -0,0 +1,166 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/labstack/echo"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_getNews_versions(t *testing.T) {
+	ms := &mockStorage{}
+	s := initServerWith(t, ms)
+	defer s.Stop()
+
+	ms.On("News", int64(123)).Return(entity.News{
+		ID:         123,
+		Header:     "header",
+		Date:       time.Date(2019, 1, 2, 12, 30, 0, 500, time.UTC),
+		ExternalID: "ext",
+	}, nil)
+
+	v1 := `{"id":123,"header":"header",` +
+		`"date":"2019-01-02T12:30:00.0000005Z","external_id":"ext"}`
+
+	for target, want := range map[string]string{
+		"/news/123":    v1,
+		"/v1/news/123": v1,
+		"/v2/news/123": `{"id":123,"header":"header",` +
+			`"published_at":"2019-01-02T12:30:00.0000005Z",` +
+			`"url":"http://example.com/news/123","external_id":"ext"}`,
+	} {
+		res := serve(s, http.MethodGet, target, nil)
+		if assert.Equal(t, http.StatusOK, res.Code, target) {
+			assert.JSONEq(t, want, res.Body.String(), target)
+		}
+	}
+}
+
+func TestServer_useVersion_deprecation(t *testing.T) {
+	ms := &mockStorage{}
+	s := NewServer(ms, "", 10*time.Second, TLSConfig{})
+	v1Deprecation := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	s.SetV1Deprecation(v1Deprecation)
+	require.NoError(t, s.Start())
+	defer s.Stop()
+
+	ms.On("News", int64(1)).Return(entity.News{ID: 1}, nil)
+
+	deprecation := "@" + strconv.FormatInt(v1Deprecation.Unix(), 10)
+
+	for target, successor := range map[string]string{
+		"/news/1?format=xml":    "/v2/news/1?format=xml",
+		"/v1/news/1?format=xml": "/v2/news/1?format=xml",
+		// Headers are set on errors too.
+		"/v1/news/x": "/v2/news/x",
+	} {
+		res := serve(s, http.MethodGet, target, nil)
+		h := res.Header()
+		assert.Equal(t, deprecation, h.Get("Deprecation"), target)
+		assert.Empty(t, h.Get("Sunset"), target)
+		assert.Equal(t, "<"+successor+`>; rel="successor-version"`,
+			h.Get("Link"), target)
+	}
+
+	res := serve(s, http.MethodGet, "/v2/news/1", nil)
+	assert.Empty(t, res.Header().Get("Deprecation"))
+	assert.Empty(t, res.Header().Get("Link"))
+}
+
+func TestServer_SetV1Sunset(t *testing.T) {
+	ms := &mockStorage{}
+	s := NewServer(ms, "", 10*time.Second, TLSConfig{})
+	s.SetV1Sunset(time.Date(2027, 1, 2, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, s.Start())
+	defer s.Stop()
+
+	ms.On("News", int64(1)).Return(entity.News{ID: 1}, nil)
+
+	res := serve(s, http.MethodGet, "/v1/news/1", nil)
+	assert.Equal(t, "Sat, 02 Jan 2027 00:00:00 GMT",
+		res.Header().Get("Sunset"))
+	// Deprecation date isn't announced unless it's set.
+	assert.Empty(t, res.Header().Get("Deprecation"))
+	assert.Equal(t, `</v2/news/1>; rel="successor-version"`,
+		res.Header().Get("Link"))
+
+	res = serve(s, http.MethodGet, "/v2/news/1", nil)
+	assert.Empty(t, res.Header().Get("Sunset"))
+}
+
+func TestServer_useVersion_usageLog(t *testing.T) {
+	hook := test.NewGlobal()
+	defer logrus.StandardLogger().ReplaceHooks(logrus.LevelHooks{})
+
+	_, s := initAuthServer(t, APIKeys{
+		"key": {Name: "alice", Role: entity.RoleReader},
+	})
+	defer s.Stop()
+
+	serve(s, http.MethodGet, "/v2/news/1",
+		map[string]string{apiKeyHeader: "key"})
+	serve(s, http.MethodGet, "/news/1", map[string]string{
+		apiKeyHeader: "key",
+		"User-Agent": "old-client/1.0",
+	})
+
+	var usages []*logrus.Entry
+	for _, e := range hook.AllEntries() {
+		if e.Message == "deprecated API version is used" {
+			usages = append(usages, e)
+		}
+	}
+
+	if !assert.Len(t, usages, 1) {
+		return
+	}
+
+	assert.Equal(t, "v1", usages[0].Data["api_version"])
+	assert.Equal(t, true, usages[0].Data["legacy"])
+	assert.Equal(t, "/news/:news_id", usages[0].Data["route"])
+	assert.Equal(t, "alice", usages[0].Data["principal"])
+	assert.Equal(t, "old-client/1.0", usages[0].Data["user_agent"])
+}
+
+func TestServer_rateLimit_versions(t *testing.T) {
+	s, _ := initRateLimitServer(t, nil,
+		[]string{"GET /news/:news_id=1/s:1"}, NewMemoryLimiterStore())
+	defer s.Stop()
+
+	// Versions share limit and tokens of route.
+	res := serve(s, http.MethodGet, "/v1/news/1", nil)
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	res = serve(s, http.MethodGet, "/v2/news/1", nil)
+	assert.Equal(t, http.StatusTooManyRequests, res.Code)
+}
+
+func TestServer_errors_versions(t *testing.T) {
+	s := initServerWith(t, &mockStorage{})
+	defer s.Stop()
+
+	for _, target := range []string{"/v1/news/x", "/v2/news/x"} {
+		res := serve(s, http.MethodGet, target, nil)
+		assert.Equal(t, http.StatusBadRequest, res.Code, target)
+		assert.Equal(t, "application/problem+json",
+			res.Header().Get(echo.HeaderContentType), target)
+		assert.Contains(t, res.Body.String(), `"status":400`, target)
+	}
+
+	// Legacy routes keep plain text errors.
+	for _, target := range []string{"/news/x", "/sitemaps/x"} {
+		res := serve(s, http.MethodGet, target, nil)
+		assert.True(t, strings.HasPrefix(
+			res.Header().Get(echo.HeaderContentType), echo.MIMETextPlain),
+			target)
+		assert.NotContains(t, res.Body.String(), `"status"`, target)
+	}
+}
@@ -0,0 +1,1055 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// Section is a set of config sections used by a binary. Flags and
+// environment variables are registered and validated only for the
+// sections it uses.
+type Section int
+
+const (
+	SectionStorage Section = 1 << iota
+	SectionNATS
+	SectionEmbeddedNATS
+	SectionWeb
+	// SectionGRPC is gRPC client of storage, which is used instead of NATS
+	// one when transport is "grpc".
+	SectionGRPC
+	// SectionGRPCServer is gRPC server of storage.
+	SectionGRPCServer
+)
+
+// Transports of storage clients.
+const (
+	TransportNATS = "nats"
+	TransportGRPC = "grpc"
+)
+
+// SQLiteScheme is scheme of storage.uri which selects SQLite storage,
+// the rest of URI is path to database file.
+const SQLiteScheme = "sqlite://"
+
+// maxFeedCount is maximum number of feed items web server allows.
+const maxFeedCount = 1000
+
+// logLevelKey is key of log level, which every binary applies on reload.
+const logLevelKey = "log_level"
+
+type Config struct {
+	LogLevel string `yaml:"log_level" toml:"log_level"`
+	// Transport is the way client talks to storage: nats or grpc.
+	Transport string `yaml:"transport,omitempty" toml:"transport,omitempty"`
+
+	Storage *Storage `yaml:"storage,omitempty" toml:"storage,omitempty"`
+	NATS    *NATS    `yaml:"nats,omitempty" toml:"nats,omitempty"`
+	Web     *Web     `yaml:"web,omitempty" toml:"web,omitempty"`
+	GRPC    *GRPC    `yaml:"grpc,omitempty" toml:"grpc,omitempty"`
+
+	// File is the path of loaded config file, if any.
+	File string `yaml:"-" toml:"-"`
+
+	// PrintConfig is set when binary is asked to print its config and exit.
+	PrintConfig bool `yaml:"-" toml:"-"`
+
+	// Args are command line arguments remaining after flags.
+	Args []string `yaml:"-" toml:"-"`
+
+	name     string
+	args     []string
+	sections Section
+}
+
+type Storage struct {
+	URI           string   `yaml:"uri" toml:"uri"`
+	ReplicaURIs   []string `yaml:"replica_uris" toml:"replica_uris"`
+	MaxReplicaLag Duration `yaml:"max_replica_lag" toml:"max_replica_lag"`
+	PingTimeout   Duration `yaml:"ping_timeout" toml:"ping_timeout"`
+	QueryTimeout  Duration `yaml:"query_timeout" toml:"query_timeout"`
+	// AutoMigrate makes storage migrate its schema on start. Otherwise
+	// start fails when schema is behind.
+	AutoMigrate bool `yaml:"auto_migrate" toml:"auto_migrate"`
+}
+
+type NATS struct {
+	URL          string   `yaml:"url" toml:"url"`
+	Subject      string   `yaml:"subject" toml:"subject"`
+	DrainTimeout Duration `yaml:"drain_timeout" toml:"drain_timeout"`
+	EmbeddedAddr string   `yaml:"embedded_addr,omitempty" toml:"embedded_addr,omitempty"`
+	// TrustPrincipalHeaders makes storage trust principal of request
+	// headers, changes are attributed to anonymous otherwise. It must be
+	// set only if NATS allows only clients which authenticate principal
+	// to publish to storage subjects.
+	TrustPrincipalHeaders bool `yaml:"trust_principal_headers,omitempty" toml:"trust_principal_headers,omitempty"`
+}
+
+type GRPC struct {
+	// Addr is address of storage gRPC server for clients.
+	Addr           string   `yaml:"addr,omitempty" toml:"addr,omitempty"`
+	RequestTimeout Duration `yaml:"request_timeout,omitempty" toml:"request_timeout,omitempty"`
+	// BindAddr is address storage gRPC server listens on, empty disables
+	// it.
+	BindAddr        string   `yaml:"bind_addr,omitempty" toml:"bind_addr,omitempty"`
+	ShutdownTimeout Duration `yaml:"shutdown_timeout,omitempty" toml:"shutdown_timeout,omitempty"`
+	// TLSCertFile and TLSKeyFile are PEM files of server certificate and
+	// key, or of client certificate for mutual TLS. TLSCAFile is PEM file
+	// of CAs which verify peer: server requires client certificates if
+	// it's set. TLS is disabled if none of them is set.
+	TLSCertFile string `yaml:"tls_cert_file,omitempty" toml:"tls_cert_file,omitempty"`
+	TLSKeyFile  string `yaml:"tls_key_file,omitempty" toml:"tls_key_file,omitempty"`
+	TLSCAFile   string `yaml:"tls_ca_file,omitempty" toml:"tls_ca_file,omitempty"`
+}
+
+type Web struct {
+	BindAddr        string   `yaml:"bind_addr" toml:"bind_addr"`
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	// TLSCertFile and TLSKeyFile are PEM files of certificate and key
+	// which enable HTTPS and HTTP/2. They are reloaded when changed.
+	TLSCertFile string `yaml:"tls_cert_file,omitempty" toml:"tls_cert_file,omitempty"`
+	TLSKeyFile  string `yaml:"tls_key_file,omitempty" toml:"tls_key_file,omitempty"`
+	// TLSClientCAFile is PEM file of CAs which client certificates are
+	// verified by. Client certificates aren't required if it's empty.
+	TLSClientCAFile string `yaml:"tls_client_ca_file,omitempty" toml:"tls_client_ca_file,omitempty"`
+	// RedirectAddr is address of plain HTTP server which redirects to
+	// HTTPS. It isn't started if it's empty.
+	RedirectAddr string `yaml:"redirect_addr,omitempty" toml:"redirect_addr,omitempty"`
+	// Authentication is disabled if neither API keys file nor JWT
+	// algorithm is set.
+	APIKeysFile string `yaml:"api_keys_file,omitempty" toml:"api_keys_file,omitempty"`
+	// JWTAlgorithm is HS256 or RS256.
+	JWTAlgorithm string `yaml:"jwt_algorithm,omitempty" toml:"jwt_algorithm,omitempty"`
+	JWTKeyFile   string `yaml:"jwt_key_file,omitempty" toml:"jwt_key_file,omitempty"`
+	// RateLimits are "ROUTE=N/UNIT[:BURST]" limits, e.g.
+	// "GET /news/:news_id=10/s:20". Rate limiting is disabled if there
+	// are none.
+	RateLimits []string `yaml:"rate_limits,omitempty" toml:"rate_limits,omitempty"`
+	// RateLimitNATSURL is URL of NATS with JetStream to share rate limits
+	// between web servers. Rate limits are kept in memory if it's empty.
+	RateLimitNATSURL string `yaml:"rate_limit_nats_url,omitempty" toml:"rate_limit_nats_url,omitempty"`
+	// AuthFailureLimit is "N/UNIT[:BURST]" limit of failed
+	// authentications from one IP address.
+	AuthFailureLimit string `yaml:"auth_failure_limit" toml:"auth_failure_limit"`
+	// TrustedProxies are IP addresses and CIDR networks of proxies which
+	// client IP address is taken from X-Forwarded-For and X-Real-IP
+	// headers of. Remote address of connection is used if there are none.
+	TrustedProxies []string `yaml:"trusted_proxies,omitempty" toml:"trusted_proxies,omitempty"`
+	// FeedCount is number of RSS and Atom feed items if feed request
+	// doesn't set it.
+	FeedCount int `yaml:"feed_count" toml:"feed_count"`
+	// StreamMaxClients is number of news stream clients served at once.
+	StreamMaxClients int `yaml:"stream_max_clients" toml:"stream_max_clients"`
+	// GraphQLPlayground enables GraphQL Playground page.
+	GraphQLPlayground bool `yaml:"graphql_playground" toml:"graphql_playground"`
+	// PublicURL is URL which web server is reachable by. It's base of
+	// canonical URLs of pages, request host is used if it's empty.
+	PublicURL string `yaml:"public_url,omitempty" toml:"public_url,omitempty"`
+	// TemplateDir is directory which HTML templates are reloaded from on
+	// every render in development. Embedded templates are used if it's
+	// empty.
+	TemplateDir string `yaml:"template_dir,omitempty" toml:"template_dir,omitempty"`
+	// V1Deprecation is date in 2006-01-02 format since which v1 API is
+	// deprecated. It's announced to v1 clients if it's set.
+	V1Deprecation string `yaml:"v1_deprecation,omitempty" toml:"v1_deprecation,omitempty"`
+	// V1Sunset is date in 2006-01-02 format when deprecated v1 API is
+	// removed. It's announced to v1 clients if it's set.
+	V1Sunset string `yaml:"v1_sunset,omitempty" toml:"v1_sunset,omitempty"`
+}
+
+// v1DateLayout is layout of web.v1_deprecation and web.v1_sunset dates.
+const v1DateLayout = "2006-01-02"
+
+// V1DeprecationDate returns V1Deprecation as time, zero if it isn't set.
+func (w *Web) V1DeprecationDate() time.Time {
+	t, _ := time.Parse(v1DateLayout, w.V1Deprecation)
+	return t
+}
+
+// V1SunsetDate returns V1Sunset as time, zero if it isn't set.
+func (w *Web) V1SunsetDate() time.Time {
+	t, _ := time.Parse(v1DateLayout, w.V1Sunset)
+	return t
+}
+
+// Default returns config with default values for the given sections.
+func Default(sections Section) *Config {
+	c := &Config{
+		LogLevel: "info",
+	}
+
+	if sections&SectionStorage != 0 {
+		c.Storage = &Storage{
+			MaxReplicaLag: Duration(10 * time.Second),
+			PingTimeout:   Duration(10 * time.Second),
+			QueryTimeout:  Duration(3 * time.Second),
+			AutoMigrate:   true,
+		}
+	}
+
+	if sections&(SectionNATS|SectionEmbeddedNATS) != 0 {
+		c.NATS = &NATS{
+			URL:          "nats://127.0.0.1:4222",
+			Subject:      "news",
+			DrainTimeout: Duration(5 * time.Second),
+		}
+	}
+
+	if sections&SectionWeb != 0 {
+		c.Web = &Web{
+			BindAddr:         ":8080",
+			ShutdownTimeout:  Duration(10 * time.Second),
+			AuthFailureLimit: "10/m",
+			FeedCount:        20,
+			StreamMaxClients: 1000,
+		}
+	}
+
+	if sections&SectionGRPC != 0 {
+		c.Transport = TransportNATS
+		c.GRPC = &GRPC{
+			Addr:           "127.0.0.1:9090",
+			RequestTimeout: Duration(5 * time.Second),
+		}
+	}
+
+	if sections&SectionGRPCServer != 0 {
+		if c.GRPC == nil {
+			c.GRPC = &GRPC{}
+		}
+		c.GRPC.ShutdownTimeout = Duration(10 * time.Second)
+	}
+
+	return c
+}
+
+type option struct {
+	section Section
+	key     string
+	flag    string
+	// envs are environment variables in ascending priority order.
+	envs  []string
+	usage string
+	value flag.Value
+}
+
+func (c *Config) options() (opts []option) {
+	opts = append(opts, option{
+		key:   logLevelKey,
+		flag:  "log-level",
+		envs:  []string{"LOG_LEVEL"},
+		usage: "log level: debug, info, warning or error",
+		value: (*stringValue)(&c.LogLevel),
+	})
+
+	if s := c.Storage; s != nil {
+		opts = append(opts, []option{{
+			section: SectionStorage,
+			key:     "storage.uri",
+			flag:    "storage-uri",
+			envs:    []string{"POSTGRES_URI", "STORAGE_URI"},
+			usage:   "storage URI: postgres://..., DSN or sqlite://path",
+			value:   (*stringValue)(&s.URI),
+		}, {
+			section: SectionStorage,
+			key:     "storage.replica_uris",
+			flag:    "postgres-replica-uris",
+			envs:    []string{"POSTGRES_REPLICA_URIS"},
+			usage:   "comma separated postgres read replica URIs",
+			value:   (*listValue)(&s.ReplicaURIs),
+		}, {
+			section: SectionStorage,
+			key:     "storage.max_replica_lag",
+			flag:    "postgres-max-replica-lag",
+			envs:    []string{"POSTGRES_MAX_REPLICA_LAG"},
+			usage:   "max lag of replica to read from it",
+			value:   &s.MaxReplicaLag,
+		}, {
+			section: SectionStorage,
+			key:     "storage.ping_timeout",
+			flag:    "storage-ping-timeout",
+			envs:    []string{"STORAGE_PING_TIMEOUT"},
+			usage:   "timeout of storage ping on start",
+			value:   &s.PingTimeout,
+		}, {
+			section: SectionStorage,
+			key:     "storage.query_timeout",
+			flag:    "storage-query-timeout",
+			envs:    []string{"STORAGE_QUERY_TIMEOUT"},
+			usage:   "timeout of storage queries made to handle a request",
+			value:   &s.QueryTimeout,
+		}, {
+			section: SectionStorage,
+			key:     "storage.auto_migrate",
+			flag:    "storage-auto-migrate",
+			envs:    []string{"STORAGE_AUTO_MIGRATE"},
+			usage: "migrate storage schema on start instead of failing " +
+				"when it is behind",
+			value: (*boolValue)(&s.AutoMigrate),
+		}}...)
+	}
+
+	if n := c.NATS; n != nil {
+		opts = append(opts, []option{{
+			section: SectionNATS | SectionEmbeddedNATS,
+			key:     "nats.url",
+			flag:    "nats-url",
+			envs:    []string{"NATS_URL"},
+			usage:   "NATS server URL",
+			value:   (*stringValue)(&n.URL),
+		}, {
+			section: SectionNATS | SectionEmbeddedNATS,
+			key:     "nats.subject",
+			flag:    "nats-subject",
+			envs:    []string{"SUBSCRIBE_SUBJECT"},
+			usage:   "NATS subject of storage service",
+			value:   (*stringValue)(&n.Subject),
+		}, {
+			section: SectionNATS | SectionEmbeddedNATS,
+			key:     "nats.drain_timeout",
+			flag:    "nats-drain-timeout",
+			envs:    []string{"NATS_DRAIN_TIMEOUT"},
+			usage:   "timeout of NATS connection drain",
+			value:   &n.DrainTimeout,
+		}, {
+			section: SectionEmbeddedNATS,
+			key:     "nats.embedded_addr",
+			flag:    "nats-embedded-addr",
+			envs:    []string{"NATS_EMBEDDED_ADDR"},
+			usage:   "host:port to start embedded NATS server on and connect to",
+			value:   (*stringValue)(&n.EmbeddedAddr),
+		}, {
+			section: SectionEmbeddedNATS,
+			key:     "nats.trust_principal_headers",
+			flag:    "nats-trust-principal-headers",
+			envs:    []string{"NATS_TRUST_PRINCIPAL_HEADERS"},
+			usage:   "trust principal of request headers",
+			value:   (*boolValue)(&n.TrustPrincipalHeaders),
+		}}...)
+	}
+
+	if w := c.Web; w != nil {
+		opts = append(opts, []option{{
+			section: SectionWeb,
+			key:     "web.bind_addr",
+			flag:    "bind-addr",
+			envs:    []string{"BIND_ADDR"},
+			usage:   "web server bind address",
+			value:   (*stringValue)(&w.BindAddr),
+		}, {
+			section: SectionWeb,
+			key:     "web.shutdown_timeout",
+			flag:    "web-shutdown-timeout",
+			envs:    []string{"WEB_SHUTDOWN_TIMEOUT"},
+			usage:   "timeout of web server graceful shutdown",
+			value:   &w.ShutdownTimeout,
+		}, {
+			section: SectionWeb,
+			key:     "web.tls_cert_file",
+			flag:    "web-tls-cert-file",
+			envs:    []string{"WEB_TLS_CERT_FILE"},
+			usage:   "PEM file of web server TLS certificate",
+			value:   (*stringValue)(&w.TLSCertFile),
+		}, {
+			section: SectionWeb,
+			key:     "web.tls_key_file",
+			flag:    "web-tls-key-file",
+			envs:    []string{"WEB_TLS_KEY_FILE"},
+			usage:   "PEM file of web server TLS key",
+			value:   (*stringValue)(&w.TLSKeyFile),
+		}, {
+			section: SectionWeb,
+			key:     "web.tls_client_ca_file",
+			flag:    "web-tls-client-ca-file",
+			envs:    []string{"WEB_TLS_CLIENT_CA_FILE"},
+			usage:   "PEM file of CAs to verify required client certificates by",
+			value:   (*stringValue)(&w.TLSClientCAFile),
+		}, {
+			section: SectionWeb,
+			key:     "web.redirect_addr",
+			flag:    "web-redirect-addr",
+			envs:    []string{"WEB_REDIRECT_ADDR"},
+			usage:   "host:port of HTTP server redirecting to HTTPS",
+			value:   (*stringValue)(&w.RedirectAddr),
+		}, {
+			section: SectionWeb,
+			key:     "web.api_keys_file",
+			flag:    "web-api-keys-file",
+			envs:    []string{"WEB_API_KEYS_FILE"},
+			usage:   "file with \"key name role\" lines of API keys",
+			value:   (*stringValue)(&w.APIKeysFile),
+		}, {
+			section: SectionWeb,
+			key:     "web.jwt_algorithm",
+			flag:    "web-jwt-algorithm",
+			envs:    []string{"WEB_JWT_ALGORITHM"},
+			usage:   "algorithm of JWT bearer tokens: HS256 or RS256",
+			value:   (*stringValue)(&w.JWTAlgorithm),
+		}, {
+			section: SectionWeb,
+			key:     "web.jwt_key_file",
+			flag:    "web-jwt-key-file",
+			envs:    []string{"WEB_JWT_KEY_FILE"},
+			usage:   "file with HS256 secret or RS256 PEM public key",
+			value:   (*stringValue)(&w.JWTKeyFile),
+		}, {
+			section: SectionWeb,
+			key:     "web.rate_limits",
+			flag:    "web-rate-limits",
+			envs:    []string{"WEB_RATE_LIMITS"},
+			usage:   "comma separated ROUTE=N/UNIT[:BURST] rate limits",
+			value:   (*listValue)(&w.RateLimits),
+		}, {
+			section: SectionWeb,
+			key:     "web.rate_limit_nats_url",
+			flag:    "web-rate-limit-nats-url",
+			envs:    []string{"WEB_RATE_LIMIT_NATS_URL"},
+			usage:   "NATS URL to share rate limits, empty keeps them in memory",
+			value:   (*stringValue)(&w.RateLimitNATSURL),
+		}, {
+			section: SectionWeb,
+			key:     "web.auth_failure_limit",
+			flag:    "web-auth-failure-limit",
+			envs:    []string{"WEB_AUTH_FAILURE_LIMIT"},
+			usage:   "N/UNIT[:BURST] limit of failed authentications by IP",
+			value:   (*stringValue)(&w.AuthFailureLimit),
+		}, {
+			section: SectionWeb,
+			key:     "web.trusted_proxies",
+			flag:    "web-trusted-proxies",
+			envs:    []string{"WEB_TRUSTED_PROXIES"},
+			usage:   "comma separated IPs and CIDRs of trusted proxies",
+			value:   (*listValue)(&w.TrustedProxies),
+		}, {
+			section: SectionWeb,
+			key:     "web.feed_count",
+			flag:    "web-feed-count",
+			envs:    []string{"WEB_FEED_COUNT"},
+			usage:   "default number of RSS and Atom feed items",
+			value:   (*intValue)(&w.FeedCount),
+		}, {
+			section: SectionWeb,
+			key:     "web.stream_max_clients",
+			flag:    "web-stream-max-clients",
+			envs:    []string{"WEB_STREAM_MAX_CLIENTS"},
+			usage:   "number of news stream clients served at once",
+			value:   (*intValue)(&w.StreamMaxClients),
+		}, {
+			section: SectionWeb,
+			key:     "web.graphql_playground",
+			flag:    "web-graphql-playground",
+			envs:    []string{"WEB_GRAPHQL_PLAYGROUND"},
+			usage:   "serve GraphQL Playground page at /graphql/playground",
+			value:   (*boolValue)(&w.GraphQLPlayground),
+		}, {
+			section: SectionWeb,
+			key:     "web.public_url",
+			flag:    "web-public-url",
+			envs:    []string{"WEB_PUBLIC_URL"},
+			usage:   "public URL of web server, e.g. https://news.example.com",
+			value:   (*stringValue)(&w.PublicURL),
+		}, {
+			section: SectionWeb,
+			key:     "web.template_dir",
+			flag:    "web-template-dir",
+			envs:    []string{"WEB_TEMPLATE_DIR"},
+			usage:   "directory to reload HTML templates from in development",
+			value:   (*stringValue)(&w.TemplateDir),
+		}, {
+			section: SectionWeb,
+			key:     "web.v1_deprecation",
+			flag:    "web-v1-deprecation",
+			envs:    []string{"WEB_V1_DEPRECATION"},
+			usage:   "date in 2006-01-02 format since which v1 API is deprecated",
+			value:   (*stringValue)(&w.V1Deprecation),
+		}, {
+			section: SectionWeb,
+			key:     "web.v1_sunset",
+			flag:    "web-v1-sunset",
+			envs:    []string{"WEB_V1_SUNSET"},
+			usage:   "date in 2006-01-02 format when v1 API is removed",
+			value:   (*stringValue)(&w.V1Sunset),
+		}}...)
+	}
+
+	if g := c.GRPC; g != nil {
+		opts = append(opts, []option{{
+			section: SectionGRPC,
+			key:     "transport",
+			flag:    "transport",
+			envs:    []string{"STORAGE_TRANSPORT"},
+			usage:   "transport of storage client: nats or grpc",
+			value:   (*stringValue)(&c.Transport),
+		}, {
+			section: SectionGRPC,
+			key:     "grpc.addr",
+			flag:    "grpc-addr",
+			envs:    []string{"GRPC_ADDR"},
+			usage:   "storage gRPC server address",
+			value:   (*stringValue)(&g.Addr),
+		}, {
+			section: SectionGRPC,
+			key:     "grpc.request_timeout",
+			flag:    "grpc-request-timeout",
+			envs:    []string{"GRPC_REQUEST_TIMEOUT"},
+			usage:   "timeout of gRPC requests to storage",
+			value:   &g.RequestTimeout,
+		}, {
+			section: SectionGRPCServer,
+			key:     "grpc.bind_addr",
+			flag:    "grpc-bind-addr",
+			envs:    []string{"GRPC_BIND_ADDR"},
+			usage:   "host:port to serve gRPC on, empty disables gRPC",
+			value:   (*stringValue)(&g.BindAddr),
+		}, {
+			section: SectionGRPCServer,
+			key:     "grpc.shutdown_timeout",
+			flag:    "grpc-shutdown-timeout",
+			envs:    []string{"GRPC_SHUTDOWN_TIMEOUT"},
+			usage:   "timeout of gRPC server graceful shutdown",
+			value:   &g.ShutdownTimeout,
+		}, {
+			section: SectionGRPC | SectionGRPCServer,
+			key:     "grpc.tls_cert_file",
+			flag:    "grpc-tls-cert-file",
+			envs:    []string{"GRPC_TLS_CERT_FILE"},
+			usage:   "PEM certificate file of gRPC server or client",
+			value:   (*stringValue)(&g.TLSCertFile),
+		}, {
+			section: SectionGRPC | SectionGRPCServer,
+			key:     "grpc.tls_key_file",
+			flag:    "grpc-tls-key-file",
+			envs:    []string{"GRPC_TLS_KEY_FILE"},
+			usage:   "PEM key file of gRPC certificate",
+			value:   (*stringValue)(&g.TLSKeyFile),
+		}, {
+			section: SectionGRPC | SectionGRPCServer,
+			key:     "grpc.tls_ca_file",
+			flag:    "grpc-tls-ca-file",
+			envs:    []string{"GRPC_TLS_CA_FILE"},
+			usage:   "PEM file of CAs which verify gRPC peer certificates",
+			value:   (*stringValue)(&g.TLSCAFile),
+		}}...)
+	}
+
+	return
+}
+
+func (o option) enabled(sections Section) bool {
+	return o.section == 0 || o.section&sections != 0
+}
+
+// Load loads config for the given sections. Values are taken from
+// defaults, config file, environment variables and command line flags,
+// each next one overriding the previous. Config file is set by -config
+// flag or CONFIG_FILE environment variable and can be YAML or TOML
+// depending on its extension.
+func Load(name string, args []string, sections Section) (*Config, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+
+	file := fs.String("config", os.Getenv("CONFIG_FILE"),
+		"path to YAML or TOML config file")
+	printConfig := fs.Bool("print-config", false,
+		"print config with redacted secrets and exit")
+
+	var flagSettings []setting
+
+	for _, o := range Default(sections).options() {
+		if !o.enabled(sections) {
+			continue
+		}
+		usage := o.usage
+		if d := o.value.String(); d != "" {
+			usage += " (default " + d + ")"
+		}
+		if len(o.envs) > 0 {
+			usage += " [$" + o.envs[len(o.envs)-1] + "]"
+		}
+		fs.Var(&deferredValue{name: o.flag, set: &flagSettings}, o.flag,
+			usage)
+	}
+
+	err := fs.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+
+	c := Default(sections)
+	c.File = *file
+	c.PrintConfig = *printConfig
+	c.Args = fs.Args()
+	c.name = name
+	c.args = args
+	c.sections = sections
+
+	if c.File != "" {
+		err = c.loadFile(c.File)
+		if err != nil {
+			return nil, errors.New("failed to load config file: " +
+				err.Error())
+		}
+	}
+
+	opts := map[string]option{}
+
+	for _, o := range c.options() {
+		if !o.enabled(sections) {
+			continue
+		}
+
+		opts[o.flag] = o
+
+		for _, env := range o.envs {
+			v, ok := os.LookupEnv(env)
+			if !ok || v == "" {
+				continue
+			}
+			err = o.value.Set(v)
+			if err != nil {
+				return nil, errors.New("failed to set " + env + ": " +
+					err.Error())
+			}
+		}
+	}
+
+	for _, s := range flagSettings {
+		err = opts[s.name].value.Set(s.value)
+		if err != nil {
+			return nil, errors.New("failed to set -" + s.name + ": " +
+				err.Error())
+		}
+	}
+
+	return c, nil
+}
+
+// Reload loads and validates config again from the same sources. Log
+// level and settings of reloadable keys are taken from new config,
+// others keep their current values and keys of changed ones are returned
+// as ignored. Reloadable keys are settings which binary applies without
+// restart.
+func (c *Config) Reload(reloadable ...string) (n *Config, ignored []string,
+	err error) {
+	n, err = Load(c.name, c.args, c.sections)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = n.Validate()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cur := map[string]option{}
+	for _, o := range c.options() {
+		cur[o.key] = o
+	}
+
+	for _, o := range n.options() {
+		if o.key == logLevelKey || contains(reloadable, o.key) ||
+			!o.enabled(c.sections) {
+			continue
+		}
+		co, ok := cur[o.key]
+		if !ok {
+			continue
+		}
+		v := co.value.String()
+		if o.value.String() == v {
+			continue
+		}
+		err = o.value.Set(v)
+		if err != nil {
+			return nil, nil, errors.New("failed to keep " + o.key + ": " +
+				err.Error())
+		}
+		ignored = append(ignored, o.key)
+	}
+
+	return n, ignored, nil
+}
+
+// ReloadAndApply reloads config, sets its log level and applies settings
+// of reloadable keys by apply. Ignored settings are logged, so that it's
+// known which ones need restart. Current config is returned on failure.
+func (c *Config) ReloadAndApply(reloadable []string,
+	apply func(n *Config)) *Config {
+	log := logrus.WithField("subsystem", "config")
+
+	n, ignored, err := c.Reload(reloadable...)
+	if err != nil {
+		log.WithError(err).Error("failed to reload config, keeping current one")
+		return c
+	}
+
+	for _, key := range ignored {
+		log.WithField("setting", key).Warn(
+			"setting can't be changed without restart, ignoring it")
+	}
+
+	logrus.SetLevel(n.LogrusLevel())
+	apply(n)
+
+	log.Info("config reloaded")
+
+	return n
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	// Sections which binary doesn't use must stay nil after unmarshal.
+	storage, nats, web, grpc := c.Storage, c.NATS, c.Web, c.GRPC
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(data, c)
+	case ".toml":
+		var md toml.MetaData
+		md, err = toml.Decode(string(data), c)
+		if err == nil && len(md.Undecoded()) > 0 {
+			keys := make([]string, 0, len(md.Undecoded()))
+			for _, k := range md.Undecoded() {
+				keys = append(keys, k.String())
+			}
+			err = errors.New("unknown keys: " + strings.Join(keys, ", "))
+		}
+	default:
+		return errors.New("unknown config file extension, " +
+			"expected .yaml, .yml or .toml")
+	}
+	if err != nil {
+		return err
+	}
+
+	if storage == nil {
+		c.Storage = nil
+	}
+	if nats == nil {
+		c.NATS = nil
+	}
+	if web == nil {
+		c.Web = nil
+	}
+	if grpc == nil {
+		c.GRPC = nil
+	}
+	if c.sections&SectionGRPC == 0 {
+		c.Transport = ""
+	}
+
+	return nil
+}
+
+// LogrusLevel returns parsed log level. Config must be validated.
+func (c *Config) LogrusLevel() logrus.Level {
+	l, _ := logrus.ParseLevel(c.LogLevel)
+	return l
+}
+
+// sqliteInMemory returns true if SQLite path is in-memory database.
+func sqliteInMemory(path string) bool {
+	return path == ":memory:" || strings.HasPrefix(path, "file::memory:") ||
+		strings.Contains(path, "mode=memory")
+}
+
+// Validate checks that config values are usable.
+func (c *Config) Validate() error {
+	_, err := logrus.ParseLevel(c.LogLevel)
+	if err != nil {
+		return errors.New("invalid log_level: " + err.Error())
+	}
+
+	if s := c.Storage; s != nil {
+		if s.URI == "" {
+			return errors.New("storage.uri is required")
+		}
+		// Values without scheme are lib/pq key=value DSNs like
+		// "host=db password=secret".
+		if strings.Contains(s.URI, "://") &&
+			!strings.HasPrefix(s.URI, SQLiteScheme) &&
+			!strings.HasPrefix(s.URI, "postgres://") &&
+			!strings.HasPrefix(s.URI, "postgresql://") {
+			return errors.New("storage.uri must start with " +
+				"postgres://, postgresql:// or sqlite:// or be postgres DSN")
+		}
+		if strings.HasPrefix(s.URI, SQLiteScheme) && len(s.ReplicaURIs) > 0 {
+			return errors.New("storage.replica_uris are supported " +
+				"only by postgres")
+		}
+		// Migrator of SQLite storage opens its own connection, and every
+		// connection to in-memory database opens a new empty one, so
+		// schema would be applied to another database.
+		if strings.HasPrefix(s.URI, SQLiteScheme) &&
+			sqliteInMemory(strings.TrimPrefix(s.URI, SQLiteScheme)) {
+			return errors.New("in-memory sqlite storage isn't supported")
+		}
+		err = positive(map[string]Duration{
+			"storage.max_replica_lag": s.MaxReplicaLag,
+			"storage.ping_timeout":    s.PingTimeout,
+			"storage.query_timeout":   s.QueryTimeout,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if n := c.NATS; n != nil {
+		if n.URL == "" && n.EmbeddedAddr == "" {
+			return errors.New("nats.url is required")
+		}
+		if n.Subject == "" {
+			return errors.New("nats.subject is required")
+		}
+		if n.EmbeddedAddr != "" {
+			_, _, err = net.SplitHostPort(n.EmbeddedAddr)
+			if err != nil {
+				return errors.New("invalid nats.embedded_addr: " +
+					err.Error())
+			}
+		}
+		err = positive(map[string]Duration{
+			"nats.drain_timeout": n.DrainTimeout,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if g := c.GRPC; g != nil {
+		err = c.validateGRPC(g)
+		if err != nil {
+			return err
+		}
+	}
+
+	if w := c.Web; w != nil {
+		_, _, err = net.SplitHostPort(w.BindAddr)
+		if err != nil {
+			return errors.New("invalid web.bind_addr: " + err.Error())
+		}
+		err = positive(map[string]Duration{
+			"web.shutdown_timeout": w.ShutdownTimeout,
+		})
+		if err != nil {
+			return err
+		}
+		if (w.TLSCertFile == "") != (w.TLSKeyFile == "") {
+			return errors.New(
+				"web.tls_cert_file and web.tls_key_file must be set together")
+		}
+		if w.TLSCertFile == "" {
+			if w.TLSClientCAFile != "" {
+				return errors.New("web.tls_client_ca_file requires TLS")
+			}
+			if w.RedirectAddr != "" {
+				return errors.New("web.redirect_addr requires TLS")
+			}
+		}
+		if w.RedirectAddr != "" {
+			_, _, err = net.SplitHostPort(w.RedirectAddr)
+			if err != nil {
+				return errors.New("invalid web.redirect_addr: " +
+					err.Error())
+			}
+		}
+		if w.FeedCount < 1 || w.FeedCount > maxFeedCount {
+			return errors.New("web.feed_count must be from 1 to " +
+				strconv.Itoa(maxFeedCount))
+		}
+		if w.StreamMaxClients < 1 {
+			return errors.New("web.stream_max_clients must be positive")
+		}
+		if w.PublicURL != "" {
+			u, err := url.Parse(w.PublicURL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") ||
+				u.Host == "" {
+				return errors.New("web.public_url must be http or https URL")
+			}
+		}
+		for key, v := range map[string]string{
+			"web.v1_deprecation": w.V1Deprecation,
+			"web.v1_sunset":      w.V1Sunset,
+		} {
+			if v == "" {
+				continue
+			}
+			_, err := time.Parse(v1DateLayout, v)
+			if err != nil {
+				return errors.New(key + " must be date in " +
+					v1DateLayout + " format")
+			}
+		}
+		if w.V1Deprecation != "" && w.V1Sunset != "" &&
+			!w.V1DeprecationDate().Before(w.V1SunsetDate()) {
+			return errors.New("web.v1_deprecation must be before " +
+				"web.v1_sunset")
+		}
+		switch w.JWTAlgorithm {
+		case "":
+		case "HS256", "RS256":
+			if w.JWTKeyFile == "" {
+				return errors.New("web.jwt_key_file is required for JWT")
+			}
+		default:
+			return errors.New("web.jwt_algorithm must be HS256 or RS256")
+		}
+	}
+
+	return nil
+}
+
+// validateGRPC validates client settings if transport is set, i.e. config
+// is of client, and server settings if server is enabled.
+func (c *Config) validateGRPC(g *GRPC) error {
+	if c.Transport != "" {
+		switch c.Transport {
+		case TransportNATS:
+		case TransportGRPC:
+			if g.Addr == "" {
+				return errors.New("grpc.addr is required for grpc transport")
+			}
+		default:
+			return errors.New("transport must be nats or grpc")
+		}
+		err := positive(map[string]Duration{
+			"grpc.request_timeout": g.RequestTimeout,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if (g.TLSCertFile == "") != (g.TLSKeyFile == "") {
+		return errors.New(
+			"grpc.tls_cert_file and grpc.tls_key_file must be set together")
+	}
+
+	if g.BindAddr != "" {
+		if g.TLSCAFile != "" && g.TLSCertFile == "" {
+			return errors.New("grpc.tls_ca_file requires grpc.tls_cert_file")
+		}
+		_, _, err := net.SplitHostPort(g.BindAddr)
+		if err != nil {
+			return errors.New("invalid grpc.bind_addr: " + err.Error())
+		}
+		err = positive(map[string]Duration{
+			"grpc.shutdown_timeout": g.ShutdownTimeout,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func positive(ds map[string]Duration) error {
+	for name, d := range ds {
+		if d <= 0 {
+			return errors.New(name + " must be positive")
+		}
+	}
+	return nil
+}
+
+const redacted = "xxxxx"
+
+// dsnPassword matches password of key=value DSN, which is quoted if it
+// has spaces.
+var dsnPassword = regexp.MustCompile(`password=('(?:[^'\\]|\\.)*'|\S+)`)
+
+// redactURI hides password in URI or key=value DSN. User of URI without
+// password is hidden too, since it's token, like in NATS URLs.
+func redactURI(uri string) string {
+	if !strings.Contains(uri, "://") {
+		return dsnPassword.ReplaceAllString(uri, "password="+redacted)
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		return redacted
+	}
+
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+		} else {
+			u.User = url.User(redacted)
+		}
+	}
+
+	q := u.Query()
+	if q.Get("password") != "" {
+		q.Set("password", redacted)
+		u.RawQuery = q.Encode()
+	}
+
+	return u.String()
+}
+
+// Redacted returns copy of config with secrets hidden.
+func (c *Config) Redacted() *Config {
+	r := *c
+
+	if c.Storage != nil {
+		s := *c.Storage
+		s.URI = redactURI(s.URI)
+		s.ReplicaURIs = nil
+		for _, uri := range c.Storage.ReplicaURIs {
+			s.ReplicaURIs = append(s.ReplicaURIs, redactURI(uri))
+		}
+		r.Storage = &s
+	}
+
+	if c.NATS != nil {
+		n := *c.NATS
+		n.URL = redactURI(n.URL)
+		r.NATS = &n
+	}
+
+	if c.Web != nil {
+		w := *c.Web
+		w.RateLimitNATSURL = redactURI(w.RateLimitNATSURL)
+		r.Web = &w
+	}
+
+	return &r
+}
+
+// Print writes config with redacted secrets to w in YAML.
+func (c *Config) Print(w io.Writer) error {
+	data, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return errors.New("failed to marshal config: " + err.Error())
+	}
+
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration is time.Duration which is read from and written to config
+// files, environment and flags in time.ParseDuration format, e.g. "3s".
+type Duration time.Duration
+
+func (d Duration) Duration() time.Duration {
+	return time.Duration(d)
+}
+
+func (d *Duration) Set(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return errors.New("failed to parse duration: " + err.Error())
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	return d.Set(string(text))
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+type stringValue string
+
+func (v *stringValue) Set(s string) error {
+	*v = stringValue(s)
+	return nil
+}
+
+func (v *stringValue) String() string {
+	return string(*v)
+}
+
+type boolValue bool
+
+func (v *boolValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return errors.New("failed to parse bool: " + err.Error())
+	}
+	*v = boolValue(b)
+	return nil
+}
+
+func (v *boolValue) String() string {
+	return strconv.FormatBool(bool(*v))
+}
+
+type intValue int
+
+func (v *intValue) Set(s string) error {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return errors.New("failed to parse int: " + err.Error())
+	}
+	*v = intValue(i)
+	return nil
+}
+
+func (v *intValue) String() string {
+	return strconv.Itoa(int(*v))
+}
+
+// listValue is comma separated list of strings.
+type listValue []string
+
+func (v *listValue) Set(s string) error {
+	*v = nil
+	for _, e := range strings.Split(s, ",") {
+		e = strings.TrimSpace(e)
+		if e != "" {
+			*v = append(*v, e)
+		}
+	}
+	return nil
+}
+
+func (v *listValue) String() string {
+	return strings.Join(*v, ",")
+}
+
+// deferredValue records flag values so they can be applied after the
+// config file and environment, because flags have the highest precedence.
+type deferredValue struct {
+	name string
+	set  *[]setting
+}
+
+type setting struct {
+	name  string
+	value string
+}
+
+func (v *deferredValue) Set(s string) error {
+	*v.set = append(*v.set, setting{name: v.name, value: s})
+	return nil
+}
+
+func (v *deferredValue) String() string {
+	return ""
+}
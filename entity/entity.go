@@ -9,6 +9,58 @@ type News struct {
 	ID     int64     `db:"id" json:"id"`
 	Header string    `db:"header" json:"header"`
 	Date   time.Time `db:"date" json:"date"`
+	// ExternalID is an optional unique ID of news in external system
+	// which news are imported from.
+	ExternalID string `db:"external_id" json:"external_id,omitempty"`
+}
+
+// Validate checks news fields which are set by clients.
+func (n News) Validate() error {
+	if n.Header == "" {
+		return ValidationError("header is required")
+	}
+	if n.Date.IsZero() {
+		return ValidationError("date is required")
+	}
+	return nil
+}
+
+// NewsFilter selects news to list. Zero values mean no restriction.
+type NewsFilter struct {
+	// From and To are inclusive bounds of news date.
+	From time.Time
+	To   time.Time
+	// Query is a text to search in news header.
+	Query  string
+	Offset int64
+	Limit  int64
+}
+
+// NewsDay is a day which has news and number of news of it. Date is
+// midnight of the day in UTC.
+type NewsDay struct {
+	Date  time.Time
+	Count int64
+}
+
+// Types of news events.
+const (
+	NewsCreated = "created"
+	NewsUpdated = "updated"
+)
+
+// NewsEvent is change of news which is pushed to subscribers.
+type NewsEvent struct {
+	// Type is NewsCreated or NewsUpdated.
+	Type string `json:"type"`
+	News News   `json:"news"`
 }
 
 var ErrNewsNotFound = errors.New("news not found")
+
+// ValidationError is returned when news or request are invalid.
+type ValidationError string
+
+func (e ValidationError) Error() string {
+	return string(e)
+}
@@ -0,0 +1,63 @@
+package entity
+
+import "strconv"
+
+// MaxImportErrors is maximum number of line errors kept in import
+// result, the rest of them are only counted.
+const MaxImportErrors = 1000
+
+// ImportOptions control how news are imported.
+type ImportOptions struct {
+	// DryRun makes import validate and count news, but not save them.
+	DryRun bool
+	// Atomic makes import save nothing if any line has error. Otherwise
+	// valid lines are saved and invalid ones are reported.
+	Atomic bool
+	// Upsert makes import update news with the same external ID instead
+	// of reporting them as errors. It makes repeated imports idempotent.
+	Upsert bool
+}
+
+// ImportRecord is a news read from line of imported data.
+type ImportRecord struct {
+	Line int
+	News News
+}
+
+// ImportSource is a stream of imported news.
+type ImportSource interface {
+	// Next returns next record or io.EOF after the last one. Invalid
+	// lines are returned as *ImportError, reading can be continued
+	// after them. Other errors stop import.
+	Next() (ImportRecord, error)
+}
+
+// ImportError is an error of imported line.
+type ImportError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+func (e *ImportError) Error() string {
+	return "line " + strconv.Itoa(e.Line) + ": " + e.Message
+}
+
+type ImportResult struct {
+	// Total is number of read records including failed ones.
+	Total   int `json:"total"`
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Failed  int `json:"failed"`
+	// Committed tells whether news are saved.
+	Committed bool          `json:"committed"`
+	Errors    []ImportError `json:"errors,omitempty"`
+}
+
+// AddError counts failed line and keeps its error if there are less
+// than MaxImportErrors of them.
+func (r *ImportResult) AddError(e ImportError) {
+	r.Failed++
+	if len(r.Errors) < MaxImportErrors {
+		r.Errors = append(r.Errors, e)
+	}
+}
@@ -0,0 +1,18 @@
+package entity
+
+import "context"
+
+type primaryReadKey struct{}
+
+// ContextWithPrimaryRead returns ctx which makes storages read from
+// primary database instead of replicas, so that reads see writes made
+// just before them.
+func ContextWithPrimaryRead(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadKey{}, true)
+}
+
+// PrimaryReadFromContext reports whether ctx requires read from primary.
+func PrimaryReadFromContext(ctx context.Context) bool {
+	p, _ := ctx.Value(primaryReadKey{}).(bool)
+	return p
+}
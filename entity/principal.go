@@ -0,0 +1,61 @@
+package entity
+
+import (
+	"context"
+	"errors"
+)
+
+// Role is access level of principal. Every role has access of lower
+// ones.
+type Role int
+
+const (
+	RoleReader Role = iota + 1
+	RoleEditor
+	RoleAdmin
+)
+
+var roleNames = map[Role]string{
+	RoleReader: "reader",
+	RoleEditor: "editor",
+	RoleAdmin:  "admin",
+}
+
+func (r Role) String() string {
+	return roleNames[r]
+}
+
+// ParseRole parses role name.
+func ParseRole(s string) (Role, error) {
+	for r, name := range roleNames {
+		if name == s {
+			return r, nil
+		}
+	}
+	return 0, errors.New("unknown role " + s +
+		", expected reader, editor or admin")
+}
+
+// Principal is authenticated client on whose behalf request is made.
+type Principal struct {
+	Name string
+	Role Role
+}
+
+// Anonymous is principal of requests whose principal is unknown or
+// isn't trusted. It has no role.
+var Anonymous = Principal{Name: "anonymous"}
+
+type principalKey struct{}
+
+// ContextWithPrincipal returns ctx carrying p to storages, so that they
+// can attribute changes.
+func ContextWithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns principal of ctx if any.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
@@ -0,0 +1,18 @@
+package entity
+
+import "context"
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns ctx carrying id of request which it's
+// made for to storages, so that their logs are correlated with logs of
+// clients.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns request id of ctx if any.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok && id != ""
+}
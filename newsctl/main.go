@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dimuls/news-storage/config"
+	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/storage/nats"
+	"github.com/dimuls/news-storage/storage/service"
+)
+
+// Exit codes.
+const (
+	exitOK = iota
+	exitError
+	exitUsage
+	exitNotFound
+	exitTransport
+	exitServer
+)
+
+const usage = `usage: newsctl [config flags] COMMAND [flags] [ARGS]
+
+commands:
+  get ID                           get news
+  list [-from -to -offset -limit]  list news ordered by date descending
+  search [flags] QUERY             search news by header, takes list flags
+  create -header -date             create news
+  update ID [-header] [-date]      update news
+  delete ID                        delete news
+
+dates are in 2006-01-02 format, every command takes -output and -timeout
+flags, config flags are printed by newsctl -h.
+
+exit codes: 0 success, 1 error, 2 usage error, 3 news not found,
+4 transport error, 5 server error`
+
+// usageError is returned when command is used incorrectly.
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+// exitCode returns exit code meaning err.
+func exitCode(err error) int {
+	switch err := err.(type) {
+	case nil:
+		return exitOK
+	case usageError:
+		return exitUsage
+	case *service.RequestError:
+		return exitTransport
+	case *service.ResponseError, entity.ValidationError:
+		return exitServer
+	default:
+		if err == entity.ErrNewsNotFound {
+			return exitNotFound
+		}
+		if err == flag.ErrHelp {
+			return exitUsage
+		}
+		return exitError
+	}
+}
+
+// command is parsed command with its common flags.
+type command struct {
+	flags   *flag.FlagSet
+	output  *string
+	timeout *time.Duration
+}
+
+func newCommand(name string) command {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	return command{
+		flags: fs,
+		output: fs.String("output", outputTable,
+			"output format: table, json or proto"),
+		timeout: fs.Duration("timeout", 5*time.Second, "request timeout"),
+	}
+}
+
+// parse parses command flags and nArgs positional arguments which
+// are returned. Flags are allowed after positional arguments.
+func (c command) parse(args []string, nArgs int) ([]string, error) {
+	var pos []string
+
+	for {
+		err := c.flags.Parse(args)
+		if err != nil {
+			return nil, err
+		}
+		args = c.flags.Args()
+		if len(args) == 0 {
+			break
+		}
+		pos, args = append(pos, args[0]), args[1:]
+	}
+
+	if len(pos) != nArgs {
+		return nil, usageError(usage)
+	}
+
+	return pos, checkOutput(*c.output)
+}
+
+// dateFlag is a flag with date value.
+type dateFlag struct {
+	time.Time
+}
+
+func (f *dateFlag) Set(s string) error {
+	t, err := time.ParseInLocation("2006-01-02", s, time.UTC)
+	if err != nil {
+		return errors.New("invalid date, must be in 2006-01-02 format")
+	}
+	f.Time = t
+	return nil
+}
+
+func (f *dateFlag) String() string {
+	if f.IsZero() {
+		return ""
+	}
+	return f.Format("2006-01-02")
+}
+
+func parseID(s string) (int64, error) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, usageError("invalid ID " + s)
+	}
+	return id, nil
+}
+
+func run(c *config.Config) error {
+	if len(c.Args) == 0 {
+		return usageError(usage)
+	}
+
+	name, args := c.Args[0], c.Args[1:]
+
+	cmd := newCommand(name)
+
+	var do func(ctx context.Context, nc *nats.Client) (interface{}, error)
+
+	switch name {
+	case "get":
+		pos, err := cmd.parse(args, 1)
+		if err != nil {
+			return err
+		}
+		id, err := parseID(pos[0])
+		if err != nil {
+			return err
+		}
+		do = func(ctx context.Context, nc *nats.Client) (interface{}, error) {
+			return nc.News(ctx, id)
+		}
+
+	case "list", "search":
+		var from, to dateFlag
+		cmd.flags.Var(&from, "from", "list news from date")
+		cmd.flags.Var(&to, "to", "list news to date inclusive")
+		offset := cmd.flags.Int64("offset", 0, "number of news to skip")
+		limit := cmd.flags.Int64("limit", 0,
+			"maximum number of news, server default if 0")
+
+		nArgs := 0
+		if name == "search" {
+			nArgs = 1
+		}
+
+		pos, err := cmd.parse(args, nArgs)
+		if err != nil {
+			return err
+		}
+
+		var query string
+		if len(pos) > 0 {
+			query = strings.TrimSpace(pos[0])
+		}
+
+		f := entity.NewsFilter{
+			From:   from.Time,
+			To:     to.Time,
+			Query:  query,
+			Offset: *offset,
+			Limit:  *limit,
+		}
+
+		if name == "search" && f.Query == "" {
+			return usageError("search query is empty")
+		}
+
+		do = func(ctx context.Context, nc *nats.Client) (interface{}, error) {
+			return nc.ListNews(ctx, f)
+		}
+
+	case "create":
+		var date dateFlag
+		cmd.flags.Var(&date, "date", "news date")
+		header := cmd.flags.String("header", "", "news header")
+
+		_, err := cmd.parse(args, 0)
+		if err != nil {
+			return err
+		}
+
+		n := entity.News{
+			Header: *header,
+			Date:   date.Time,
+		}
+
+		err = n.Validate()
+		if err != nil {
+			return usageError(err.Error())
+		}
+
+		do = func(ctx context.Context, nc *nats.Client) (interface{}, error) {
+			return nc.CreateNews(ctx, n)
+		}
+
+	case "update":
+		var date dateFlag
+		cmd.flags.Var(&date, "date", "new news date")
+		header := cmd.flags.String("header", "", "new news header")
+
+		pos, err := cmd.parse(args, 1)
+		if err != nil {
+			return err
+		}
+		id, err := parseID(pos[0])
+		if err != nil {
+			return err
+		}
+		if *header == "" && date.IsZero() {
+			return usageError("nothing to update, set -header or -date")
+		}
+
+		do = func(ctx context.Context, nc *nats.Client) (interface{}, error) {
+			n, err := nc.News(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			if *header != "" {
+				n.Header = *header
+			}
+			if !date.IsZero() {
+				n.Date = date.Time
+			}
+			return nc.UpdateNews(ctx, n)
+		}
+
+	case "delete":
+		pos, err := cmd.parse(args, 1)
+		if err != nil {
+			return err
+		}
+		id, err := parseID(pos[0])
+		if err != nil {
+			return err
+		}
+		do = func(ctx context.Context, nc *nats.Client) (interface{}, error) {
+			return nil, nc.DeleteNews(ctx, id)
+		}
+
+	default:
+		return usageError("unknown command " + name + "\n\n" + usage)
+	}
+
+	nc, err := nats.NewClient(c.NATS.URL, c.NATS.Subject,
+		c.NATS.DrainTimeout.Duration())
+	if err != nil {
+		return &service.RequestError{Err: err}
+	}
+	defer nc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *cmd.timeout)
+	defer cancel()
+
+	res, err := do(ctx, nc)
+	if err != nil {
+		return err
+	}
+
+	if res == nil {
+		return nil
+	}
+
+	return write(os.Stdout, *cmd.output, res)
+}
+
+func main() {
+	c, err := config.Load(os.Args[0], os.Args[1:], config.SectionNATS)
+	if err != nil {
+		if err == flag.ErrHelp {
+			fmt.Fprintln(os.Stderr, usage)
+			os.Exit(exitUsage)
+		}
+		fmt.Fprintln(os.Stderr, "failed to load config: "+err.Error())
+		os.Exit(exitUsage)
+	}
+
+	if c.PrintConfig {
+		err = c.Print(os.Stdout)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "failed to print config: "+err.Error())
+			os.Exit(exitError)
+		}
+		return
+	}
+
+	err = c.Validate()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "invalid config: "+err.Error())
+		os.Exit(exitUsage)
+	}
+
+	err = run(c)
+	if err != nil && err != flag.ErrHelp {
+		fmt.Fprintln(os.Stderr, err.Error())
+	}
+
+	os.Exit(exitCode(err))
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dimuls/news-storage/config"
+	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/storage/nats"
+	"github.com/dimuls/news-storage/storage/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	for _, c := range []struct {
+		err  error
+		want int
+	}{
+		{nil, exitOK},
+		{errors.New("failed"), exitError},
+		{usageError("usage"), exitUsage},
+		{flag.ErrHelp, exitUsage},
+		{entity.ErrNewsNotFound, exitNotFound},
+		{&service.RequestError{Err: errors.New("no servers")}, exitTransport},
+		{&service.ResponseError{Message: "db is down"}, exitServer},
+		{entity.ValidationError("header is required"), exitServer},
+	} {
+		assert.Equal(t, c.want, exitCode(c.err), c.err)
+	}
+}
+
+// testStorage keeps news with ID 1 and fails to delete any.
+type testStorage struct {
+	service.Storage
+}
+
+func (s testStorage) News(ctx context.Context, id int64) (entity.News,
+	error) {
+	if id != 1 {
+		return entity.News{}, entity.ErrNewsNotFound
+	}
+	return entity.News{
+		ID:     1,
+		Header: "header",
+		Date:   time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+	}, nil
+}
+
+func (s testStorage) DeleteNews(ctx context.Context, id int64) error {
+	return errors.New("db is down")
+}
+
+func TestRun(t *testing.T) {
+	es := nats.NewEmbeddedServer("127.0.0.1:0")
+	if err := es.Start(); err != nil {
+		t.Fatal("failed to start embedded nats server: " + err.Error())
+	}
+	defer es.Stop()
+
+	const subject = "test.news"
+
+	ns := nats.NewServer(testStorage{}, es.URL(), subject, 3*time.Second,
+		5*time.Second)
+	if err := ns.Start(); err != nil {
+		t.Fatal("failed to start nats server: " + err.Error())
+	}
+	defer ns.Stop()
+
+	// Results are written to stdout.
+	stdout := os.Stdout
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal("failed to open " + os.DevNull + ": " + err.Error())
+	}
+	defer devNull.Close()
+	os.Stdout = devNull
+	defer func() { os.Stdout = stdout }()
+
+	for _, c := range []struct {
+		natsURL string
+		args    []string
+		want    int
+	}{
+		{es.URL(), []string{"get", "1"}, exitOK},
+		{es.URL(), []string{"get", "1", "-output", "json"}, exitOK},
+		{es.URL(), []string{"get", "2"}, exitNotFound},
+		{es.URL(), []string{"delete", "1"}, exitServer},
+		{es.URL(), []string{"get"}, exitUsage},
+		{es.URL(), []string{"get", "x"}, exitUsage},
+		{es.URL(), []string{"create", "-date", "2019-01-02"}, exitUsage},
+		{es.URL(), []string{"unknown"}, exitUsage},
+		{es.URL(), nil, exitUsage},
+		{"nats://127.0.0.1:1", []string{"get", "1"}, exitTransport},
+	} {
+		args := append([]string{"-nats-url", c.natsURL,
+			"-nats-subject", subject}, c.args...)
+
+		cfg, err := config.Load("newsctl", args, config.SectionNATS)
+		if err != nil {
+			t.Fatal("failed to load config: " + err.Error())
+		}
+
+		assert.Equal(t, c.want, exitCode(run(cfg)), c.args)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/storage/pb"
+	"github.com/golang/protobuf/proto"
+)
+
+// Output formats.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputProto = "proto"
+)
+
+func checkOutput(output string) error {
+	switch output {
+	case outputTable, outputJSON, outputProto:
+		return nil
+	}
+	return usageError("unknown output format " + output)
+}
+
+// write writes news or list of news res to w in output format.
+func write(w io.Writer, output string, res interface{}) error {
+	var ns []entity.News
+
+	switch res := res.(type) {
+	case entity.News:
+		ns = []entity.News{res}
+	case []entity.News:
+		ns = res
+	default:
+		return errors.New("unexpected result type")
+	}
+
+	switch output {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+
+	case outputProto:
+		for _, n := range ns {
+			err := proto.MarshalText(w, &pb.News{
+				Id:     n.ID,
+				Header: n.Header,
+				Date:   n.Date.UTC().Format("2006-01-02"),
+			})
+			if err != nil {
+				return err
+			}
+			// Messages are separated by empty line.
+			fmt.Fprintln(w)
+		}
+		return nil
+
+	default:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tDATE\tHEADER")
+		for _, n := range ns {
+			fmt.Fprintf(tw, "%d\t%s\t%s\n", n.ID,
+				n.Date.UTC().Format("2006-01-02"), n.Header)
+		}
+		return tw.Flush()
+	}
+}
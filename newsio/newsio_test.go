@@ -0,0 +1,137 @@
+package newsio
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/storage/pb"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+var testNews = []entity.News{{
+	ID:         1,
+	Header:     "first",
+	Date:       time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+	ExternalID: "a",
+}, {
+	ID:     2,
+	Header: "second, \"quoted\"",
+	Date:   time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC),
+}}
+
+func readAll(t *testing.T, src entity.ImportSource) (
+	recs []entity.ImportRecord, errs []entity.ImportError) {
+	for {
+		rec, err := src.Next()
+		if err == io.EOF {
+			return
+		}
+		if ie, ok := err.(*entity.ImportError); ok {
+			errs = append(errs, *ie)
+			continue
+		}
+		if err != nil {
+			t.Fatal("failed to read: " + err.Error())
+		}
+		recs = append(recs, rec)
+	}
+}
+
+// TestRoundTrip checks that exported news can be imported back.
+func TestRoundTrip(t *testing.T) {
+	// CSV has header row before news.
+	for format, firstLine := range map[string]int{
+		FormatJSONL: 1,
+		FormatCSV:   2,
+	} {
+		var buf bytes.Buffer
+
+		w, err := NewWriter(&buf, format)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		for _, n := range testNews {
+			assert.NoError(t, w.Write(n))
+		}
+		assert.NoError(t, w.Flush())
+
+		src, err := NewReader(&buf, format)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		recs, errs := readAll(t, src)
+		assert.Empty(t, errs, format)
+
+		if assert.Len(t, recs, len(testNews), format) {
+			for i, rec := range recs {
+				want := testNews[i]
+				want.ID = 0
+				assert.Equal(t, firstLine+i, rec.Line, format)
+				assert.Equal(t, want, rec.News, format)
+			}
+		}
+	}
+}
+
+func TestReader_errors(t *testing.T) {
+	src, err := NewReader(strings.NewReader("header,date\n"+
+		"first,2019-01-02\n"+
+		"second\n"+
+		",2019-01-02\n"+
+		"fourth,yesterday\n"+
+		"\"bad\"quote,2019-01-02\n"+
+		"\"multi\nline\",2019-01-02\n"), FormatCSV)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	recs, errs := readAll(t, src)
+
+	if assert.Len(t, recs, 2) {
+		assert.Equal(t, 2, recs[0].Line)
+		assert.Equal(t, 7, recs[1].Line)
+		assert.Equal(t, "multi\nline", recs[1].News.Header)
+	}
+
+	var lines []int
+	for _, e := range errs {
+		lines = append(lines, e.Line)
+	}
+	assert.Equal(t, []int{3, 4, 5, 6}, lines)
+
+	_, err = NewReader(strings.NewReader("id,header\n"), FormatCSV)
+	assert.Error(t, err)
+}
+
+func TestWriter_proto(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, FormatProto)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, n := range testNews {
+		assert.NoError(t, w.Write(n))
+	}
+	assert.NoError(t, w.Flush())
+
+	b := proto.NewBuffer(buf.Bytes())
+
+	for _, n := range testNews {
+		var got pb.News
+		if assert.NoError(t, b.DecodeMessage(&got)) {
+			assert.Equal(t, n.ID, got.Id)
+			assert.Equal(t, n.Header, got.Header)
+			assert.Equal(t, n.Date.Format("2006-01-02"), got.Date)
+			assert.Equal(t, n.ExternalID, got.ExternalId)
+		}
+	}
+}
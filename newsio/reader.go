@@ -0,0 +1,201 @@
+// Package newsio reads and writes news in formats used to move them in
+// bulk between news storage and other systems.
+package newsio
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+)
+
+// Formats of news streams.
+const (
+	// FormatJSONL is one JSON object per line.
+	FormatJSONL = "jsonl"
+	// FormatCSV is CSV with header row naming columns.
+	FormatCSV = "csv"
+)
+
+// maxLineSize is maximum size of JSONL line.
+const maxLineSize = 1 << 20
+
+// record is news as it is read from import sources. Unknown fields, e.g.
+// id, are ignored.
+type record struct {
+	ExternalID string `json:"external_id"`
+	Header     string `json:"header"`
+	Date       string `json:"date"`
+}
+
+// parseDate parses date in 2006-01-02 or RFC 3339 format.
+func parseDate(s string) (time.Time, error) {
+	t, err := time.ParseInLocation("2006-01-02", s, time.UTC)
+	if err == nil {
+		return t, nil
+	}
+	t, err = time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, errors.New(
+			"invalid date, must be in 2006-01-02 or RFC 3339 format")
+	}
+	return t, nil
+}
+
+func (r record) news() (entity.News, error) {
+	n := entity.News{
+		ExternalID: strings.TrimSpace(r.ExternalID),
+		Header:     strings.TrimSpace(r.Header),
+	}
+
+	if r.Date != "" {
+		var err error
+		n.Date, err = parseDate(strings.TrimSpace(r.Date))
+		if err != nil {
+			return entity.News{}, err
+		}
+	}
+
+	return n, n.Validate()
+}
+
+// NewReader returns source of news read from r in format.
+func NewReader(r io.Reader, format string) (entity.ImportSource, error) {
+	switch format {
+	case FormatJSONL:
+		s := bufio.NewScanner(r)
+		s.Buffer(make([]byte, 64*1024), maxLineSize)
+		return &jsonlReader{scanner: s}, nil
+	case FormatCSV:
+		return newCSVReader(r)
+	}
+	return nil, errors.New("unknown format " + format)
+}
+
+type jsonlReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (r *jsonlReader) Next() (entity.ImportRecord, error) {
+	for r.scanner.Scan() {
+		r.line++
+
+		data := r.scanner.Bytes()
+		if len(strings.TrimSpace(string(data))) == 0 {
+			continue
+		}
+
+		var rec record
+
+		err := json.Unmarshal(data, &rec)
+		if err != nil {
+			return entity.ImportRecord{}, &entity.ImportError{
+				Line:    r.line,
+				Message: "invalid JSON: " + err.Error(),
+			}
+		}
+
+		n, err := rec.news()
+		if err != nil {
+			return entity.ImportRecord{}, &entity.ImportError{
+				Line:    r.line,
+				Message: err.Error(),
+			}
+		}
+
+		return entity.ImportRecord{Line: r.line, News: n}, nil
+	}
+
+	err := r.scanner.Err()
+	if err != nil {
+		return entity.ImportRecord{}, errors.New("failed to read line " +
+			strconv.Itoa(r.line+1) + ": " + err.Error())
+	}
+
+	return entity.ImportRecord{}, io.EOF
+}
+
+type csvReader struct {
+	reader *csv.Reader
+	// columns are indexes of record fields in CSV record.
+	columns map[string]int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+
+	names, err := cr.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errors.New("CSV header row is missing")
+		}
+		return nil, errors.New("failed to read CSV header row: " +
+			err.Error())
+	}
+
+	columns := map[string]int{}
+	for i, name := range names {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, name := range []string{"header", "date"} {
+		if _, ok := columns[name]; !ok {
+			return nil, errors.New("CSV header row has no " + name +
+				" column")
+		}
+	}
+
+	return &csvReader{
+		reader:  cr,
+		columns: columns,
+	}, nil
+}
+
+func (r *csvReader) field(fields []string, name string) string {
+	i, ok := r.columns[name]
+	if !ok {
+		return ""
+	}
+	return fields[i]
+}
+
+func (r *csvReader) Next() (entity.ImportRecord, error) {
+	fields, err := r.reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return entity.ImportRecord{}, io.EOF
+		}
+		if pe, ok := err.(*csv.ParseError); ok {
+			return entity.ImportRecord{}, &entity.ImportError{
+				Line:    pe.StartLine,
+				Message: "invalid CSV: " + pe.Err.Error(),
+			}
+		}
+		return entity.ImportRecord{}, errors.New("failed to read CSV: " +
+			err.Error())
+	}
+
+	line, _ := r.reader.FieldPos(0)
+
+	n, err := record{
+		ExternalID: r.field(fields, "external_id"),
+		Header:     r.field(fields, "header"),
+		Date:       r.field(fields, "date"),
+	}.news()
+	if err != nil {
+		return entity.ImportRecord{}, &entity.ImportError{
+			Line:    line,
+			Message: err.Error(),
+		}
+	}
+
+	return entity.ImportRecord{Line: line, News: n}, nil
+}
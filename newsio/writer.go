@@ -0,0 +1,131 @@
+package newsio
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/storage/pb"
+	"github.com/golang/protobuf/proto"
+)
+
+// FormatProto is sequence of pb.News messages each prefixed by its
+// varint encoded size. Dates are in 2006-01-02 format as in NATS
+// protocol.
+const FormatProto = "protobuf"
+
+// Writer writes news to stream in some format.
+type Writer interface {
+	Write(n entity.News) error
+	// Flush writes buffered news to underlying writer.
+	Flush() error
+}
+
+// NewWriter returns writer of news in format to w.
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case FormatJSONL:
+		bw := bufio.NewWriter(w)
+		return &jsonlWriter{buf: bw, encoder: json.NewEncoder(bw)}, nil
+	case FormatCSV:
+		return &csvWriter{writer: csv.NewWriter(w)}, nil
+	case FormatProto:
+		return &protoWriter{buf: bufio.NewWriter(w)}, nil
+	}
+	return nil, errors.New("unknown format " + format)
+}
+
+// ContentType returns MIME type of format.
+func ContentType(format string) string {
+	switch format {
+	case FormatJSONL:
+		return "application/x-ndjson"
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatProto:
+		return "application/x-protobuf"
+	}
+	return "application/octet-stream"
+}
+
+type jsonlWriter struct {
+	buf     *bufio.Writer
+	encoder *json.Encoder
+}
+
+func (w *jsonlWriter) Write(n entity.News) error {
+	return w.encoder.Encode(n)
+}
+
+func (w *jsonlWriter) Flush() error {
+	return w.buf.Flush()
+}
+
+var csvHeader = []string{"id", "external_id", "header", "date"}
+
+type csvWriter struct {
+	writer        *csv.Writer
+	headerWritten bool
+}
+
+func (w *csvWriter) writeHeader() error {
+	if w.headerWritten {
+		return nil
+	}
+	w.headerWritten = true
+	return w.writer.Write(csvHeader)
+}
+
+func (w *csvWriter) Write(n entity.News) error {
+	err := w.writeHeader()
+	if err != nil {
+		return err
+	}
+	return w.writer.Write([]string{
+		strconv.FormatInt(n.ID, 10),
+		n.ExternalID,
+		n.Header,
+		n.Date.Format(time.RFC3339),
+	})
+}
+
+// Flush writes header row even if there is no news.
+func (w *csvWriter) Flush() error {
+	err := w.writeHeader()
+	if err != nil {
+		return err
+	}
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+type protoWriter struct {
+	buf   *bufio.Writer
+	proto proto.Buffer
+}
+
+func (w *protoWriter) Write(n entity.News) error {
+	w.proto.Reset()
+
+	err := w.proto.EncodeMessage(&pb.News{
+		Id:         n.ID,
+		Header:     n.Header,
+		Date:       n.Date.UTC().Format("2006-01-02"),
+		ExternalId: n.ExternalID,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = w.buf.Write(w.proto.Bytes())
+	return err
+}
+
+func (w *protoWriter) Flush() error {
+	return w.buf.Flush()
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dimuls/news-storage/client/web"
+	"github.com/dimuls/news-storage/config"
+	"github.com/dimuls/news-storage/storage/postgres"
+	"github.com/sirupsen/logrus"
+)
+
+// Postgres storage is used by web server directly, without NATS between
+// them.
+var (
+	_ web.Storage  = (*postgres.Storage)(nil)
+	_ web.Importer = (*postgres.Storage)(nil)
+	_ web.Exporter = (*postgres.Storage)(nil)
+)
+
+// reloadConfig reloads config and applies storage and web settings which
+// can be changed without restart.
+func reloadConfig(c *config.Config, ps *postgres.Storage,
+	ws *web.Server) *config.Config {
+	reloadable := append([]string{
+		"storage.query_timeout",
+		"storage.max_replica_lag",
+	}, web.ReloadableSettings...)
+
+	return c.ReloadAndApply(reloadable, func(n *config.Config) {
+		ps.SetQueryTimeout(n.Storage.QueryTimeout.Duration())
+		ps.SetMaxReplicaLag(n.Storage.MaxReplicaLag.Duration())
+		ws.ApplyConfig(n.Web)
+	})
+}
+
+// run serves web API of postgres storage until it is stopped by signal.
+// Storage is closed before run returns, including on errors.
+func run(c *config.Config, log *logrus.Entry) error {
+	ps, err := postgres.NewStorage(c.Storage.URI, c.Storage.ReplicaURIs,
+		c.Storage.MaxReplicaLag.Duration(), c.Storage.PingTimeout.Duration())
+	if err != nil {
+		return errors.New("failed to create postgres storage: " + err.Error())
+	}
+
+	defer func() {
+		err := ps.Close()
+		if err != nil {
+			log.WithError(err).Error("failed to close storage")
+		}
+	}()
+
+	// Web server queries storage directly, so storage limits queries
+	// instead of transport servers.
+	ps.SetQueryTimeout(c.Storage.QueryTimeout.Duration())
+
+	err = ps.Migrator().UpOrCheck(c.Storage.AutoMigrate)
+	if err != nil {
+		return errors.New("failed to prepare storage schema: " + err.Error())
+	}
+
+	ws, err := web.NewServerFromConfig(c.Web, ps)
+	if err != nil {
+		return errors.New("failed to create web server: " + err.Error())
+	}
+
+	err = ws.Start()
+	if err != nil {
+		return errors.New("failed to start web server: " + err.Error())
+	}
+
+	log.Info("web server started")
+
+	ss := make(chan os.Signal, 1)
+
+	signal.Notify(ss, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
+
+	var s os.Signal
+
+	for s = range ss {
+		if s != syscall.SIGHUP {
+			break
+		}
+		log.Info("captured SIGHUP signal, reloading config")
+		c = reloadConfig(c, ps, ws)
+	}
+
+	log.Infof("captured %v signal, stopping", s)
+
+	st := time.Now()
+	ws.Stop()
+	et := time.Now()
+
+	log.Infof("stopped in %g seconds, exiting",
+		et.Sub(st).Seconds())
+
+	return nil
+}
+
+func main() {
+	log := logrus.WithField("subsystem", "main")
+
+	c, err := config.Load(os.Args[0], os.Args[1:],
+		config.SectionStorage|config.SectionWeb)
+	if err != nil {
+		if err == flag.ErrHelp {
+			return
+		}
+		log.WithError(err).Fatal("failed to load config")
+	}
+
+	if c.PrintConfig {
+		err = c.Print(os.Stdout)
+		if err != nil {
+			log.WithError(err).Fatal("failed to print config")
+		}
+		return
+	}
+
+	err = c.Validate()
+	if err == nil && strings.HasPrefix(c.Storage.URI, config.SQLiteScheme) {
+		err = errors.New("standalone binary supports only postgres storage")
+	}
+	if err != nil {
+		log.WithError(err).Fatal("invalid config")
+	}
+
+	logrus.SetLevel(c.LogrusLevel())
+
+	err = run(c, log)
+	if err != nil {
+		log.WithError(err).Fatal("failed to run")
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"strings"
+
+	"github.com/dimuls/news-storage/config"
+)
+
+// boolFlag is implemented by flag values which need no argument.
+type boolFlag interface {
+	IsBoolFlag() bool
+}
+
+// splitFlags splits args into flags defined in fs with their values and
+// the rest, keeping order of both.
+func splitFlags(fs *flag.FlagSet, args []string) (own, rest []string) {
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+
+		if a == "--" {
+			return own, append(rest, args[i:]...)
+		}
+
+		if len(a) < 2 || a[0] != '-' {
+			rest = append(rest, a)
+			continue
+		}
+
+		name := strings.TrimPrefix(a[1:], "-")
+		hasValue := false
+		if j := strings.IndexByte(name, '='); j >= 0 {
+			name, hasValue = name[:j], true
+		}
+
+		f := fs.Lookup(name)
+		if f == nil {
+			rest = append(rest, a)
+			continue
+		}
+
+		own = append(own, a)
+
+		if b, ok := f.Value.(boolFlag); ok && b.IsBoolFlag() {
+			continue
+		}
+
+		if !hasValue && i+1 < len(args) {
+			i++
+			own = append(own, args[i])
+		}
+	}
+
+	return own, rest
+}
+
+// loadSubcommandConfig parses subcommand flags defined in fs first, so
+// they can be placed anywhere, and loads storage config from the rest of
+// args.
+func loadSubcommandConfig(name string, args []string,
+	fs *flag.FlagSet) (*config.Config, error) {
+	own, rest := splitFlags(fs, args)
+
+	err := fs.Parse(own)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := config.Load(name, rest, config.SectionStorage)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.Validate()
+	if err != nil {
+		return nil, errors.New("invalid config: " + err.Error())
+	}
+
+	return c, nil
+}
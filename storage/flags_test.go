@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("format", "", "")
+	fs.Bool("dry-run", false, "")
+
+	own, rest := splitFlags(fs, []string{"-dry-run", "-log-level", "debug",
+		"--format", "csv", "news.csv", "-format=jsonl", "--", "-dry-run"})
+
+	assert.Equal(t, []string{"-dry-run", "--format", "csv",
+		"-format=jsonl"}, own)
+	assert.Equal(t, []string{"-log-level", "debug", "news.csv", "--",
+		"-dry-run"}, rest)
+}
+
+func TestLoadSubcommandConfig(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "")
+
+	c, err := loadSubcommandConfig("test", []string{"-dry-run",
+		"-storage-uri", "sqlite://news.db", "-"}, fs)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, *dryRun)
+	assert.Equal(t, "sqlite://news.db", c.Storage.URI)
+	assert.Equal(t, []string{"-"}, c.Args)
+}
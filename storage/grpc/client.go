@@ -0,0 +1,270 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/storage/pb"
+	"github.com/dimuls/news-storage/storage/service"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Client is storage client which talks to Server. It returns the same
+// errors as NATS client.
+type Client struct {
+	requestTimeout time.Duration
+	connection     *grpc.ClientConn
+	client         pb.NewsStorageClient
+
+	log *logrus.Entry
+}
+
+// NewClient creates client of server on addr. It connects over TLS if t
+// is enabled and presents client certificate if t has one. Requests
+// without deadline are given requestTimeout, except exports which can
+// take long. Connection is established lazily.
+func NewClient(addr string, requestTimeout time.Duration, t TLSConfig) (
+	*Client, error) {
+	opt := grpc.WithInsecure()
+	if t.enabled() {
+		creds, err := t.clientCredentials()
+		if err != nil {
+			return nil, err
+		}
+		opt = grpc.WithTransportCredentials(creds)
+	}
+
+	conn, err := grpc.Dial(addr, opt)
+	if err != nil {
+		return nil, errors.New("failed to dial grpc server: " +
+			err.Error())
+	}
+
+	return &Client{
+		requestTimeout: requestTimeout,
+		connection:     conn,
+		client:         pb.NewNewsStorageClient(conn),
+		log:            logrus.WithField("subsystem", "grpc_client"),
+	}, nil
+}
+
+func (c *Client) Close() {
+	c.connection.Close()
+}
+
+func (c *Client) withTimeout(ctx context.Context) (context.Context,
+	context.CancelFunc) {
+	ctx = outgoingMetadata(ctx)
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.requestTimeout)
+}
+
+// Metadata keys which carry principal and request id as NATS headers do.
+const (
+	principalMetadata     = "x-principal"
+	principalRoleMetadata = "x-principal-role"
+	requestIDMetadata     = "x-request-id"
+)
+
+// outgoingMetadata adds principal and request id of ctx if any to
+// request metadata.
+func outgoingMetadata(ctx context.Context) context.Context {
+	if p, ok := entity.PrincipalFromContext(ctx); ok {
+		ctx = metadata.AppendToOutgoingContext(ctx,
+			principalMetadata, p.Name,
+			principalRoleMetadata, p.Role.String())
+	}
+	if id, ok := entity.RequestIDFromContext(ctx); ok {
+		ctx = metadata.AppendToOutgoingContext(ctx, requestIDMetadata, id)
+	}
+	return ctx
+}
+
+// statusError converts gRPC status to the same errors as NATS client
+// returns.
+func statusError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	switch st.Code() {
+	case codes.NotFound:
+		return entity.ErrNewsNotFound
+	case codes.InvalidArgument:
+		return entity.ValidationError(st.Message())
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Canceled:
+		return &service.RequestError{Err: err}
+	}
+
+	return &service.ResponseError{
+		Code:    http.StatusInternalServerError,
+		Message: st.Message(),
+	}
+}
+
+func (c *Client) News(ctx context.Context, id int64) (entity.News,
+	error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	n, err := c.client.GetNews(ctx, &pb.GetNewsRequest{Id: id})
+	if err != nil {
+		return entity.News{}, statusError(err)
+	}
+
+	return pb.ToNews(n)
+}
+
+// NewsByIDs gets news with ids by one request. News are returned in no
+// particular order, missing ones are omitted.
+func (c *Client) NewsByIDs(ctx context.Context, ids []int64) (
+	[]entity.News, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	res, err := c.client.BatchGetNews(ctx, &pb.BatchGetNewsRequest{Ids: ids})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return pb.ToNewsList(res.News)
+}
+
+// NewsDays gets days which have news in UTC with number of news of
+// every day. Days are ordered by date.
+func (c *Client) NewsDays(ctx context.Context) ([]entity.NewsDay,
+	error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	res, err := c.client.NewsDays(ctx, &pb.NewsDaysRequest{})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return pb.ToNewsDays(res.Days)
+}
+
+// ListNews lists news selected by filter f. News are searched by header
+// if f.Query is not empty.
+func (c *Client) ListNews(ctx context.Context, f entity.NewsFilter) (
+	[]entity.News, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	stream, err := c.client.ListNews(ctx, pb.FromFilter(f))
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	ns := []entity.News{}
+
+	err = receiveNews(stream, func(n entity.News) error {
+		ns = append(ns, n)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ns, nil
+}
+
+// CreateNews creates news n and returns it with assigned ID.
+func (c *Client) CreateNews(ctx context.Context, n entity.News) (
+	entity.News, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	res, err := c.client.CreateNews(ctx,
+		&pb.CreateNewsRequest{News: pb.FromNews(n)})
+	if err != nil {
+		return entity.News{}, statusError(err)
+	}
+
+	return pb.ToNews(res)
+}
+
+func (c *Client) UpdateNews(ctx context.Context, n entity.News) (
+	entity.News, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	res, err := c.client.UpdateNews(ctx,
+		&pb.UpdateNewsRequest{News: pb.FromNews(n)})
+	if err != nil {
+		return entity.News{}, statusError(err)
+	}
+
+	return pb.ToNews(res)
+}
+
+func (c *Client) DeleteNews(ctx context.Context, id int64) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	_, err := c.client.DeleteNews(ctx, &pb.DeleteNewsRequest{Id: id})
+	if err != nil {
+		return statusError(err)
+	}
+
+	return nil
+}
+
+// ExportNews calls fn for every news matched by dates and query of f in
+// date order. Stream is canceled if fn fails.
+func (c *Client) ExportNews(ctx context.Context, f entity.NewsFilter,
+	fn func(entity.News) error) error {
+	ctx, cancel := context.WithCancel(outgoingMetadata(ctx))
+	defer cancel()
+
+	stream, err := c.client.ExportNews(ctx, &pb.ExportNewsRequest{
+		From:  pb.FormatDate(f.From),
+		To:    pb.FormatDate(f.To),
+		Query: f.Query,
+	})
+	if err != nil {
+		return statusError(err)
+	}
+
+	return receiveNews(stream, fn)
+}
+
+// newsStream is stream of news returned by server.
+type newsStream interface {
+	Recv() (*pb.News, error)
+}
+
+// receiveNews calls fn for every news of stream until its end.
+func receiveNews(stream newsStream, fn func(entity.News) error) error {
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return statusError(err)
+		}
+
+		n, err := pb.ToNews(res)
+		if err != nil {
+			return err
+		}
+
+		err = fn(n)
+		if err != nil {
+			return err
+		}
+	}
+}
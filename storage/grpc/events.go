@@ -0,0 +1,163 @@
+package grpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/storage/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Subscriber notifies about news changes, e.g. NATS server which gets
+// events of all servers of its subject.
+type Subscriber interface {
+	SubscribeNews(fn func(entity.NewsEvent)) (unsubscribe func() error,
+		err error)
+}
+
+const (
+	// subscriberBuffer is number of events waiting to be sent to
+	// subscriber. Subscriber which doesn't keep up is disconnected, so
+	// that it doesn't hold events of others.
+	subscriberBuffer = 64
+	// resubscribeDelay is delay of client before subscribing again after
+	// subscription is broken.
+	resubscribeDelay = time.Second
+)
+
+// SetSubscriber makes server stream news events of sub to subscribers.
+// SubscribeNews is unimplemented if it isn't set. It must be called
+// before Start.
+func (s *Server) SetSubscriber(sub Subscriber) {
+	s.subscriber = sub
+}
+
+func (s *Server) SubscribeNews(req *pb.SubscribeNewsRequest,
+	stream pb.NewsStorage_SubscribeNewsServer) error {
+	ctx := incomingMetadata(stream.Context())
+
+	if s.subscriber == nil {
+		return status.Error(codes.Unimplemented,
+			"news events aren't served")
+	}
+
+	var (
+		events   = make(chan entity.NewsEvent, subscriberBuffer)
+		overflow = make(chan struct{})
+		once     sync.Once
+	)
+
+	unsubscribe, err := s.subscriber.SubscribeNews(
+		func(e entity.NewsEvent) {
+			select {
+			case events <- e:
+			default:
+				once.Do(func() { close(overflow) })
+			}
+		})
+	if err != nil {
+		return s.statusError(ctx, "SubscribeNews", err)
+	}
+	defer unsubscribe()
+
+	for {
+		select {
+		case e := <-events:
+			err = stream.Send(&pb.NewsEvent{
+				Type: e.Type,
+				News: pb.FromNews(e.News),
+			})
+			if err != nil {
+				return s.statusError(ctx, "SubscribeNews", err)
+			}
+		case <-overflow:
+			return status.Error(codes.ResourceExhausted,
+				"subscriber doesn't keep up with events")
+		case <-ctx.Done():
+			return s.statusError(ctx, "SubscribeNews", ctx.Err())
+		case <-s.done:
+			return status.Error(codes.Unavailable, "server is stopping")
+		}
+	}
+}
+
+// SubscribeNews calls fn with every news event of server until
+// unsubscribe is called. Broken subscription is renewed, events
+// published meanwhile are lost as they are for NATS subscribers which
+// reconnect. Malformed events are skipped.
+func (c *Client) SubscribeNews(fn func(entity.NewsEvent)) (
+	unsubscribe func() error, err error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	stream, err := c.client.SubscribeNews(ctx, &pb.SubscribeNewsRequest{})
+	if err != nil {
+		cancel()
+		return nil, statusError(err)
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for {
+			err := receiveEvents(stream, fn)
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			if status.Code(err) == codes.Unimplemented {
+				c.log.WithError(err).Error(
+					"server doesn't serve news events")
+				return
+			}
+
+			c.log.WithError(err).Warn(
+				"news subscription is broken, subscribing again")
+
+			select {
+			case <-time.After(resubscribeDelay):
+			case <-ctx.Done():
+				return
+			}
+
+			stream, err = c.client.SubscribeNews(ctx,
+				&pb.SubscribeNewsRequest{})
+			if err != nil {
+				c.log.WithError(err).Error("failed to subscribe")
+			}
+		}
+	}()
+
+	return func() error {
+		cancel()
+		<-done
+		return nil
+	}, nil
+}
+
+// receiveEvents calls fn for every event of stream until it fails.
+func receiveEvents(stream pb.NewsStorage_SubscribeNewsClient,
+	fn func(entity.NewsEvent)) error {
+	if stream == nil {
+		return nil
+	}
+
+	for {
+		e, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		n, err := pb.ToNews(e.News)
+		if err != nil {
+			continue
+		}
+
+		fn(entity.NewsEvent{Type: e.Type, News: n})
+	}
+}
@@ -0,0 +1,142 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/storage/pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// subscriberMock hands functions of subscribers to test.
+type subscriberMock struct {
+	subscribed chan func(entity.NewsEvent)
+}
+
+func (s *subscriberMock) SubscribeNews(fn func(entity.NewsEvent)) (
+	unsubscribe func() error, err error) {
+	s.subscribed <- fn
+	return func() error { return nil }, nil
+}
+
+func TestGRPC_SubscribeNews(t *testing.T) {
+	sm := &subscriberMock{subscribed: make(chan func(entity.NewsEvent), 1)}
+
+	s := NewServer(&storageMock{}, "127.0.0.1:0", 3*time.Second,
+		5*time.Second, TLSConfig{})
+	s.SetSubscriber(sm)
+	if !assert.NoError(t, s.Start()) {
+		return
+	}
+
+	c, err := NewClient(s.Addr(), time.Second, TLSConfig{})
+	if !assert.NoError(t, err) {
+		s.Stop()
+		return
+	}
+	defer cleanGRPC(s, c)
+
+	events := make(chan entity.NewsEvent, 1)
+
+	unsubscribe, err := c.SubscribeNews(func(e entity.NewsEvent) {
+		events <- e
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer unsubscribe()
+
+	var publish func(entity.NewsEvent)
+	select {
+	case publish = <-sm.subscribed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("server hasn't subscribed")
+	}
+
+	want := entity.NewsEvent{
+		Type: entity.NewsUpdated,
+		News: entity.News{
+			ID:     1,
+			Header: "header",
+			Date:   time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	publish(want)
+
+	select {
+	case e := <-events:
+		assert.Equal(t, want, e)
+	case <-time.After(2 * time.Second):
+		t.Fatal("event isn't received")
+	}
+}
+
+func TestGRPC_SubscribeNews_unimplemented(t *testing.T) {
+	_, s, c := initGRPC(t)
+	defer cleanGRPC(s, c)
+
+	stream, err := c.client.SubscribeNews(context.TODO(),
+		&pb.SubscribeNewsRequest{})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
+
+func TestServer_SubscribeNews_overflow(t *testing.T) {
+	sm := &subscriberMock{subscribed: make(chan func(entity.NewsEvent), 1)}
+
+	s := NewServer(&storageMock{}, "127.0.0.1:0", 3*time.Second,
+		5*time.Second, TLSConfig{})
+	s.SetSubscriber(sm)
+	if !assert.NoError(t, s.Start()) {
+		return
+	}
+
+	c, err := NewClient(s.Addr(), time.Second, TLSConfig{})
+	if !assert.NoError(t, err) {
+		s.Stop()
+		return
+	}
+	defer cleanGRPC(s, c)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	stream, err := c.client.SubscribeNews(ctx, &pb.SubscribeNewsRequest{})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var publish func(entity.NewsEvent)
+	select {
+	case publish = <-sm.subscribed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("server hasn't subscribed")
+	}
+
+	// Subscriber doesn't receive while events are published, so that
+	// buffer overflows sooner or later.
+	e := entity.NewsEvent{
+		Type: entity.NewsCreated,
+		News: entity.News{ID: 1, Header: "header"},
+	}
+	for i := 0; i < 100000; i++ {
+		publish(e)
+	}
+
+	for {
+		_, err = stream.Recv()
+		if err != nil {
+			break
+		}
+	}
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
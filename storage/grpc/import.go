@@ -0,0 +1,112 @@
+package grpc
+
+import (
+	"context"
+	"io"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/storage/pb"
+	"github.com/dimuls/news-storage/storage/service"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxChunkSize is maximum size of chunk of imported news, which is well
+// below default limit of gRPC message size.
+const maxChunkSize = 1 << 20
+
+// ImportNews imports news of chunks streamed by client. The first chunk
+// carries import request. Like export, import is not limited by query
+// timeout.
+func (s *Server) ImportNews(stream pb.NewsStorage_ImportNewsServer) error {
+	ctx := incomingMetadata(stream.Context())
+
+	imp, ok := s.storage.(service.Importer)
+	if !ok {
+		return status.Error(codes.Unimplemented,
+			"storage doesn't support import")
+	}
+
+	first, err := stream.Recv()
+	if err == io.EOF || err == nil && first.Request == nil {
+		return status.Error(codes.InvalidArgument,
+			"the first chunk has no import request")
+	}
+	if err != nil {
+		return s.statusError(ctx, "ImportNews", err)
+	}
+
+	o := pb.ToImportOptions(first.Request)
+
+	src := service.NewChunkSource(func() (*pb.ImportChunk, error) {
+		if first != nil {
+			chunk := first
+			first = nil
+			return chunk, nil
+		}
+
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return nil, entity.ValidationError(
+				"stream is ended before the last chunk")
+		}
+		return chunk, err
+	})
+
+	res, err := imp.ImportNews(ctx, src, o)
+	if src.Err() != nil {
+		err = src.Err()
+	}
+	if err != nil {
+		return s.statusError(ctx, "ImportNews", err)
+	}
+
+	if res.Committed {
+		s.writes.Add(ctx)
+		p, _ := entity.PrincipalFromContext(ctx)
+		requestLog(s.log, ctx).WithFields(logrus.Fields{
+			"method":    "ImportNews",
+			"principal": p.Name,
+			"created":   res.Created,
+			"updated":   res.Updated,
+		}).Info("news imported")
+	}
+
+	return stream.SendAndClose(pb.FromImportResult(res))
+}
+
+// ImportNews imports news read from src by storage of server with
+// options o. News are streamed in chunks, invalid lines of src are
+// passed to server to be counted in result. Import is canceled if src
+// fails.
+func (c *Client) ImportNews(ctx context.Context, src entity.ImportSource,
+	o entity.ImportOptions) (entity.ImportResult, error) {
+	ctx, cancel := context.WithCancel(outgoingMetadata(ctx))
+	defer cancel()
+
+	stream, err := c.client.ImportNews(ctx)
+	if err != nil {
+		return entity.ImportResult{}, statusError(err)
+	}
+
+	req := pb.FromImportOptions(o)
+
+	err = service.SendChunks(src, maxChunkSize,
+		func(chunk *pb.ImportChunk) error {
+			chunk.Request, req = req, nil
+			return stream.Send(chunk)
+		})
+	// Send fails with io.EOF if server ended stream, its status is
+	// returned by CloseAndRecv then.
+	if err != nil && err != io.EOF {
+		return entity.ImportResult{}, err
+	}
+
+	res, err := stream.CloseAndRecv()
+	if err != nil {
+		return entity.ImportResult{}, statusError(err)
+	}
+
+	return pb.ToImportResult(res), nil
+}
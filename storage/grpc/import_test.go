@@ -0,0 +1,183 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// sliceSource is import source of records, err is returned after them
+// once ready is closed.
+type sliceSource struct {
+	records []entity.ImportRecord
+	err     error
+	ready   chan struct{}
+	i       int
+}
+
+func (s *sliceSource) Next() (entity.ImportRecord, error) {
+	if s.i == len(s.records) {
+		if s.err != nil {
+			<-s.ready
+			return entity.ImportRecord{}, s.err
+		}
+		return entity.ImportRecord{}, io.EOF
+	}
+	s.i++
+	return s.records[s.i-1], nil
+}
+
+// importStorage imports news to records and reports result of import to
+// results. Reading is closed when the first record is read.
+type importStorage struct {
+	storageMock
+	options entity.ImportOptions
+	records []entity.ImportRecord
+	results chan error
+	reading chan struct{}
+}
+
+func (s *importStorage) ImportNews(ctx context.Context,
+	src entity.ImportSource, o entity.ImportOptions) (
+	r entity.ImportResult, err error) {
+	defer func() { s.results <- err }()
+
+	s.options = o
+
+	for {
+		rec, err := src.Next()
+		if err == io.EOF {
+			break
+		}
+		if ie, ok := err.(*entity.ImportError); ok {
+			r.Total++
+			r.AddError(*ie)
+			continue
+		}
+		if err != nil {
+			return r, errors.New("failed to read news: " + err.Error())
+		}
+		if len(s.records) == 0 {
+			close(s.reading)
+		}
+		r.Total++
+		r.Created++
+		s.records = append(s.records, rec)
+	}
+
+	r.Committed = !o.DryRun
+
+	return r, nil
+}
+
+func importRecords(n int) []entity.ImportRecord {
+	recs := make([]entity.ImportRecord, n)
+	for i := range recs {
+		recs[i] = entity.ImportRecord{
+			Line: i + 1,
+			News: entity.News{
+				Header: "header " + strconv.Itoa(i),
+				Date:   time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC),
+			},
+		}
+	}
+	return recs
+}
+
+func initImportGRPC(t *testing.T) (*importStorage, *Server, *Client) {
+	is := &importStorage{
+		results: make(chan error, 1),
+		reading: make(chan struct{}),
+	}
+
+	s := NewServer(is, "127.0.0.1:0", 3*time.Second, 5*time.Second,
+		TLSConfig{})
+
+	err := s.Start()
+	if err != nil {
+		t.Fatal("failed to start grpc server: " + err.Error())
+	}
+
+	c, err := NewClient(s.Addr(), time.Second, TLSConfig{})
+	if err != nil {
+		s.Stop()
+		t.Fatal("failed to create grpc client: " + err.Error())
+	}
+
+	return is, s, c
+}
+
+func TestGRPC_ImportNews(t *testing.T) {
+	is, s, c := initImportGRPC(t)
+	defer cleanGRPC(s, c)
+
+	recs := importRecords(2500)
+	recs[20].News.Header = ""
+
+	o := entity.ImportOptions{DryRun: true}
+
+	res, err := c.ImportNews(context.TODO(), &sliceSource{records: recs}, o)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, <-is.results)
+
+	assert.Equal(t, entity.ImportResult{
+		Total:   2500,
+		Created: 2499,
+		Failed:  1,
+		Errors: []entity.ImportError{
+			{Line: 21, Message: "header is required"},
+		},
+	}, res)
+	assert.Equal(t, o, is.options)
+	assert.Equal(t, append(append([]entity.ImportRecord{}, recs[:20]...),
+		recs[21:]...), is.records)
+}
+
+func TestGRPC_ImportNews_sourceError(t *testing.T) {
+	is, s, c := initImportGRPC(t)
+	defer cleanGRPC(s, c)
+
+	// Source fails once server reads the first chunk, so that it's
+	// canceled while server is importing.
+	src := &sliceSource{
+		records: importRecords(1500),
+		err:     errors.New("connection reset"),
+		ready:   is.reading,
+	}
+
+	_, err := c.ImportNews(context.TODO(), src, entity.ImportOptions{})
+	assert.Error(t, err)
+
+	select {
+	case err := <-is.results:
+		assert.Error(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("import isn't canceled")
+	}
+}
+
+func TestGRPC_ImportNews_unsupported(t *testing.T) {
+	_, s, c := initGRPC(t)
+	defer cleanGRPC(s, c)
+
+	_, err := c.ImportNews(context.TODO(), &sliceSource{},
+		entity.ImportOptions{})
+	assert.Error(t, err)
+
+	stream, err := c.client.ImportNews(context.TODO())
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = stream.CloseAndRecv()
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
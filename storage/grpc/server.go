@@ -0,0 +1,374 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/storage/pb"
+	"github.com/dimuls/news-storage/storage/service"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// Server serves the same storage operations as NATS server for
+// consumers which can't use NATS. It implements pb.NewsStorageServer,
+// errors are returned as gRPC statuses.
+type Server struct {
+	// queryTimeout is time.Duration accessed atomically, because it can
+	// be changed while server is handling requests.
+	queryTimeout int64
+
+	storage         service.Storage
+	bindAddr        string
+	shutdownTimeout time.Duration
+	tls             TLSConfig
+	events          service.EventPublisher
+	subscriber      Subscriber
+	writes          *service.Writes
+
+	server   *grpc.Server
+	listener net.Listener
+	wg       sync.WaitGroup
+	// done is closed on stop to end subscriptions, which never end
+	// otherwise.
+	done chan struct{}
+
+	log *logrus.Entry
+}
+
+// NewServer creates server which listens on bindAddr. It serves TLS if
+// t is enabled and requires client certificates if t has CA file. Every
+// request is given queryTimeout to get its result from storage, or less
+// if client deadline is earlier. Export streams are not limited by it.
+func NewServer(s service.Storage, bindAddr string, queryTimeout,
+	shutdownTimeout time.Duration, t TLSConfig) *Server {
+	return &Server{
+		queryTimeout:    int64(queryTimeout),
+		storage:         s,
+		bindAddr:        bindAddr,
+		shutdownTimeout: shutdownTimeout,
+		tls:             t,
+		done:            make(chan struct{}),
+		writes:          service.NewWrites(0),
+		log:             logrus.WithField("subsystem", "grpc_server"),
+	}
+}
+
+// SetQueryTimeout changes query timeout of requests handled from now on.
+func (s *Server) SetQueryTimeout(d time.Duration) {
+	atomic.StoreInt64(&s.queryTimeout, int64(d))
+}
+
+// SetPrimaryReadWindow makes reads of principal within d after its write
+// be made from primary, so that principal sees the write while replicas
+// catch up. It is disabled by zero d.
+func (s *Server) SetPrimaryReadWindow(d time.Duration) {
+	s.writes.SetWindow(d)
+}
+
+// SetEventPublisher makes server publish news events by p, so that
+// subscribers see changes made over gRPC too. It must be called before
+// Start.
+func (s *Server) SetEventPublisher(p service.EventPublisher) {
+	s.events = p
+}
+
+// publishEvent publishes news event if publisher is set.
+func (s *Server) publishEvent(typ string, n entity.News) {
+	if s.events != nil {
+		s.events.PublishNewsEvent(entity.NewsEvent{Type: typ, News: n})
+	}
+}
+
+func (s *Server) Start() error {
+	var opts []grpc.ServerOption
+
+	if s.tls.enabled() {
+		creds, err := s.tls.serverCredentials()
+		if err != nil {
+			return err
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	l, err := net.Listen("tcp", s.bindAddr)
+	if err != nil {
+		return errors.New("failed to listen: " + err.Error())
+	}
+
+	s.listener = l
+	s.server = grpc.NewServer(opts...)
+
+	pb.RegisterNewsStorageServer(s.server, s)
+	reflection.Register(s.server)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		err := s.server.Serve(l)
+		if err != nil {
+			s.log.WithError(err).Error("failed to serve")
+		}
+	}()
+
+	return nil
+}
+
+// Addr returns address server listens on. Server must be started.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Stop waits for running requests up to shutdown timeout and then
+// closes their connections.
+func (s *Server) Stop() {
+	close(s.done)
+
+	stopped := make(chan struct{})
+
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(s.shutdownTimeout):
+		s.log.Warn("graceful stop timed out, closing connections")
+		s.server.Stop()
+	}
+
+	s.wg.Wait()
+}
+
+func (s *Server) withQueryTimeout(ctx context.Context) (
+	context.Context, context.CancelFunc) {
+	return context.WithTimeout(s.writes.ReadContext(incomingMetadata(ctx)),
+		time.Duration(atomic.LoadInt64(&s.queryTimeout)))
+}
+
+// peerAuthenticated reports whether peer of request presented
+// certificate verified by server.
+func peerAuthenticated(ctx context.Context) bool {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return false
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	return ok && len(info.State.VerifiedChains) > 0
+}
+
+// incomingMetadata returns ctx with principal and request id from
+// request metadata if any. Principal is trusted only from peers
+// authenticated by client certificate, since anyone who reaches server
+// could claim any principal otherwise, it's anonymous then.
+func incomingMetadata(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if ids := md.Get(requestIDMetadata); len(ids) > 0 && ids[0] != "" {
+		ctx = entity.ContextWithRequestID(ctx, ids[0])
+	}
+
+	names := md.Get(principalMetadata)
+	if len(names) == 0 || names[0] == "" || !peerAuthenticated(ctx) {
+		return entity.ContextWithPrincipal(ctx, entity.Anonymous)
+	}
+
+	var role entity.Role
+	if roles := md.Get(principalRoleMetadata); len(roles) > 0 {
+		role, _ = entity.ParseRole(roles[0])
+	}
+
+	return entity.ContextWithPrincipal(ctx, entity.Principal{
+		Name: names[0],
+		Role: role,
+	})
+}
+
+// requestLog returns log with id of request of ctx if any.
+func requestLog(log *logrus.Entry, ctx context.Context) *logrus.Entry {
+	if id, ok := entity.RequestIDFromContext(ctx); ok {
+		return log.WithField("request_id", id)
+	}
+	return log
+}
+
+// logChange logs change of news with principal who made it and
+// remembers the write for reads which follow it.
+func (s *Server) logChange(ctx context.Context, method string) {
+	s.writes.Add(ctx)
+	p, _ := entity.PrincipalFromContext(ctx)
+	requestLog(s.log, ctx).WithFields(logrus.Fields{
+		"method":    method,
+		"principal": p.Name,
+	}).Info("news changed")
+}
+
+// statusError converts handling error to gRPC status. Internal errors
+// are logged and hidden from clients.
+func (s *Server) statusError(ctx context.Context, method string,
+	err error) error {
+	if err == entity.ErrNewsNotFound {
+		return status.Error(codes.NotFound, err.Error())
+	}
+
+	if _, ok := err.(entity.ValidationError); ok {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// Errors of sending to stream are statuses already.
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case context.Canceled:
+		return status.Error(codes.Canceled, err.Error())
+	}
+
+	requestLog(s.log, ctx).WithError(err).WithField("method", method).Error(
+		"failed to handle request")
+
+	return status.Error(codes.Internal, "internal server error")
+}
+
+func (s *Server) GetNews(ctx context.Context, req *pb.GetNewsRequest) (
+	*pb.News, error) {
+	ctx, cancel := s.withQueryTimeout(ctx)
+	defer cancel()
+
+	n, err := s.storage.News(ctx, req.Id)
+	if err != nil {
+		return nil, s.statusError(ctx, "GetNews", err)
+	}
+
+	return pb.FromNews(n), nil
+}
+
+func (s *Server) BatchGetNews(ctx context.Context,
+	req *pb.BatchGetNewsRequest) (*pb.BatchGetNewsResponse, error) {
+	ctx, cancel := s.withQueryTimeout(ctx)
+	defer cancel()
+
+	res, err := service.BatchGetNews(ctx, s.storage, req)
+	if err != nil {
+		return nil, s.statusError(ctx, "BatchGetNews", err)
+	}
+
+	return res, nil
+}
+
+func (s *Server) NewsDays(ctx context.Context,
+	req *pb.NewsDaysRequest) (*pb.NewsDaysResponse, error) {
+	ctx, cancel := s.withQueryTimeout(ctx)
+	defer cancel()
+
+	res, err := service.NewsDays(ctx, s.storage)
+	if err != nil {
+		return nil, s.statusError(ctx, "NewsDays", err)
+	}
+
+	return res, nil
+}
+
+func (s *Server) ListNews(req *pb.ListNewsRequest,
+	stream pb.NewsStorage_ListNewsServer) error {
+	ctx := s.writes.ReadContext(incomingMetadata(stream.Context()))
+
+	err := service.ListNews(ctx, s.storage,
+		time.Duration(atomic.LoadInt64(&s.queryTimeout)), req,
+		func(n entity.News) error {
+			return stream.Send(pb.FromNews(n))
+		})
+	if err != nil {
+		return s.statusError(ctx, "ListNews", err)
+	}
+
+	return nil
+}
+
+func (s *Server) CreateNews(ctx context.Context,
+	req *pb.CreateNewsRequest) (*pb.News, error) {
+	n, err := service.RequestNews(req.News)
+	if err != nil {
+		return nil, s.statusError(ctx, "CreateNews", err)
+	}
+
+	n.ID = 0
+
+	ctx, cancel := s.withQueryTimeout(ctx)
+	defer cancel()
+
+	n, err = s.storage.CreateNews(ctx, n)
+	if err != nil {
+		return nil, s.statusError(ctx, "CreateNews", err)
+	}
+
+	s.logChange(ctx, "CreateNews")
+	s.publishEvent(entity.NewsCreated, n)
+
+	return pb.FromNews(n), nil
+}
+
+func (s *Server) UpdateNews(ctx context.Context,
+	req *pb.UpdateNewsRequest) (*pb.News, error) {
+	n, err := service.RequestNews(req.News)
+	if err != nil {
+		return nil, s.statusError(ctx, "UpdateNews", err)
+	}
+
+	ctx, cancel := s.withQueryTimeout(ctx)
+	defer cancel()
+
+	n, err = s.storage.UpdateNews(ctx, n)
+	if err != nil {
+		return nil, s.statusError(ctx, "UpdateNews", err)
+	}
+
+	s.logChange(ctx, "UpdateNews")
+	s.publishEvent(entity.NewsUpdated, n)
+
+	return pb.FromNews(n), nil
+}
+
+func (s *Server) DeleteNews(ctx context.Context,
+	req *pb.DeleteNewsRequest) (*pb.DeleteNewsResponse, error) {
+	ctx, cancel := s.withQueryTimeout(ctx)
+	defer cancel()
+
+	err := s.storage.DeleteNews(ctx, req.Id)
+	if err != nil {
+		return nil, s.statusError(ctx, "DeleteNews", err)
+	}
+
+	s.logChange(ctx, "DeleteNews")
+
+	return &pb.DeleteNewsResponse{}, nil
+}
+
+func (s *Server) ExportNews(req *pb.ExportNewsRequest,
+	stream pb.NewsStorage_ExportNewsServer) error {
+	ctx := s.writes.ReadContext(incomingMetadata(stream.Context()))
+
+	err := service.ExportNews(ctx, s.storage, req, func(n entity.News) error {
+		return stream.Send(pb.FromNews(n))
+	})
+	if err != nil {
+		return s.statusError(ctx, "ExportNews", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,441 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/storage/service"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+)
+
+type storageMock struct {
+	mock.Mock
+}
+
+func (s *storageMock) News(ctx context.Context, id int64) (entity.News, error) {
+	args := s.Called(id)
+	return args.Get(0).(entity.News), args.Error(1)
+}
+
+func (s *storageMock) NewsByIDs(ctx context.Context, ids []int64) (
+	[]entity.News, error) {
+	args := s.Called(ids)
+	return args.Get(0).([]entity.News), args.Error(1)
+}
+
+func (s *storageMock) NewsDays(ctx context.Context) ([]entity.NewsDay,
+	error) {
+	args := s.Called()
+	return args.Get(0).([]entity.NewsDay), args.Error(1)
+}
+
+func (s *storageMock) ListNews(ctx context.Context, f entity.NewsFilter) (
+	[]entity.News, error) {
+	args := s.Called(f)
+	return args.Get(0).([]entity.News), args.Error(1)
+}
+
+func (s *storageMock) CreateNews(ctx context.Context, n entity.News) (
+	entity.News, error) {
+	args := s.Called(n)
+	return args.Get(0).(entity.News), args.Error(1)
+}
+
+func (s *storageMock) UpdateNews(ctx context.Context, n entity.News) (
+	entity.News, error) {
+	args := s.Called(n)
+	return args.Get(0).(entity.News), args.Error(1)
+}
+
+func (s *storageMock) DeleteNews(ctx context.Context, id int64) error {
+	args := s.Called(id)
+	return args.Error(0)
+}
+
+func (s *storageMock) ExportNews(ctx context.Context, f entity.NewsFilter,
+	fn func(entity.News) error) error {
+	args := s.Called(f)
+	for _, n := range args.Get(0).([]entity.News) {
+		err := fn(n)
+		if err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func manyNews(n int) []entity.News {
+	ns := make([]entity.News, 0, n)
+	for i := 1; i <= n; i++ {
+		ns = append(ns, entity.News{
+			ID:     int64(i),
+			Header: "header",
+			Date:   time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+		})
+	}
+	return ns
+}
+
+func initGRPC(t *testing.T) (*storageMock, *Server, *Client) {
+	sm := &storageMock{}
+
+	s := NewServer(sm, "127.0.0.1:0", 3*time.Second, 5*time.Second,
+		TLSConfig{})
+
+	err := s.Start()
+	if err != nil {
+		t.Fatal("failed to start grpc server: " + err.Error())
+	}
+
+	c, err := NewClient(s.Addr(), time.Second, TLSConfig{})
+	if err != nil {
+		s.Stop()
+		t.Fatal("failed to create grpc client: " + err.Error())
+	}
+
+	return sm, s, c
+}
+
+func cleanGRPC(s *Server, c *Client) {
+	c.Close()
+	s.Stop()
+}
+
+func TestGRPC_News(t *testing.T) {
+	sm, s, c := initGRPC(t)
+	defer cleanGRPC(s, c)
+
+	// Publication time is passed with full precision.
+	wantN := entity.News{
+		ID:     1,
+		Header: "header",
+		Date:   time.Date(2019, 1, 2, 12, 30, 0, 500, time.UTC),
+	}
+
+	sm.On("News", int64(1)).Return(wantN, nil)
+	sm.On("News", int64(2)).Return(entity.News{}, entity.ErrNewsNotFound)
+	sm.On("News", int64(3)).Return(entity.News{}, errors.New("error"))
+
+	n, err := c.News(context.TODO(), 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, wantN, n)
+	}
+
+	_, err = c.News(context.TODO(), 2)
+	assert.Equal(t, entity.ErrNewsNotFound, err)
+
+	_, err = c.News(context.TODO(), 3)
+	assert.Equal(t, &service.ResponseError{
+		Code:    500,
+		Message: "internal server error",
+	}, err)
+
+	sm.AssertExpectations(t)
+}
+
+func TestGRPC_News_deadline(t *testing.T) {
+	sm, s, c := initGRPC(t)
+	defer cleanGRPC(s, c)
+
+	sm.On("News", int64(1)).After(500*time.Millisecond).Return(
+		entity.News{}, nil)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := c.News(ctx, 1)
+	assert.IsType(t, &service.RequestError{}, err)
+}
+
+func TestGRPC_CreateNews_invalid(t *testing.T) {
+	_, s, c := initGRPC(t)
+	defer cleanGRPC(s, c)
+
+	_, err := c.CreateNews(context.TODO(), entity.News{
+		Date: time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+	})
+	assert.Equal(t, entity.ValidationError("header is required"), err)
+}
+
+func TestGRPC_UpdateDeleteNews(t *testing.T) {
+	sm, s, c := initGRPC(t)
+	defer cleanGRPC(s, c)
+
+	n := entity.News{
+		ID:     1,
+		Header: "header",
+		Date:   time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	sm.On("UpdateNews", n).Return(n, nil)
+	sm.On("DeleteNews", int64(1)).Return(nil)
+
+	updated, err := c.UpdateNews(context.TODO(), n)
+	if assert.NoError(t, err) {
+		assert.Equal(t, n, updated)
+	}
+
+	assert.NoError(t, c.DeleteNews(context.TODO(), 1))
+
+	sm.AssertExpectations(t)
+}
+
+func TestGRPC_ListNews(t *testing.T) {
+	sm, s, c := initGRPC(t)
+	defer cleanGRPC(s, c)
+
+	wantNs := manyNews(3)
+
+	sm.On("ListNews", entity.NewsFilter{
+		Query: "query",
+		Limit: 20,
+	}).Return(wantNs, nil)
+
+	ns, err := c.ListNews(context.TODO(), entity.NewsFilter{Query: "query"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, wantNs, ns)
+	}
+
+	_, err = c.ListNews(context.TODO(), entity.NewsFilter{Limit: -1})
+	assert.Equal(t, entity.ValidationError("limit must be non-negative"),
+		err)
+
+	sm.AssertExpectations(t)
+}
+
+func TestGRPC_ExportNews(t *testing.T) {
+	sm, s, c := initGRPC(t)
+	defer cleanGRPC(s, c)
+
+	wantNs := manyNews(3000)
+
+	sm.On("ExportNews", entity.NewsFilter{}).Return(wantNs, nil)
+
+	var ns []entity.News
+
+	err := c.ExportNews(context.TODO(), entity.NewsFilter{},
+		func(n entity.News) error {
+			ns = append(ns, n)
+			return nil
+		})
+	if assert.NoError(t, err) {
+		assert.Equal(t, wantNs, ns)
+	}
+
+	fnErr := errors.New("error")
+
+	err = c.ExportNews(context.TODO(), entity.NewsFilter{},
+		func(n entity.News) error {
+			return fnErr
+		})
+	assert.Equal(t, fnErr, err)
+}
+
+func TestGRPC_NewsByIDs(t *testing.T) {
+	sm, s, c := initGRPC(t)
+	defer cleanGRPC(s, c)
+
+	wantN := entity.News{
+		ID:     1,
+		Header: "header",
+		Date:   time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	sm.On("NewsByIDs", []int64{1, 2}).Return([]entity.News{wantN}, nil)
+
+	ns, err := c.NewsByIDs(context.TODO(), []int64{1, 2})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []entity.News{wantN}, ns)
+	}
+
+	sm.AssertExpectations(t)
+}
+
+func TestGRPC_NewsDays(t *testing.T) {
+	sm, s, c := initGRPC(t)
+	defer cleanGRPC(s, c)
+
+	wantDays := []entity.NewsDay{{
+		Date:  time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+		Count: 3,
+	}}
+
+	sm.On("NewsDays").Return(wantDays, nil)
+
+	days, err := c.NewsDays(context.TODO())
+	if assert.NoError(t, err) {
+		assert.Equal(t, wantDays, days)
+	}
+
+	sm.AssertExpectations(t)
+}
+
+func TestServer_reflection(t *testing.T) {
+	_, s, c := initGRPC(t)
+	defer cleanGRPC(s, c)
+
+	conn, err := grpc.Dial(s.Addr(), grpc.WithInsecure())
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second)
+	defer cancel()
+
+	stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(
+		ctx)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	err = stream.Send(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_ListServices{},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	res, err := stream.Recv()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var services []string
+	for _, s := range res.GetListServicesResponse().GetService() {
+		services = append(services, s.Name)
+	}
+
+	assert.Contains(t, services, "NewsStorage")
+}
+
+// principalStorage remembers principal of the last deleting request.
+type principalStorage struct {
+	storageMock
+	principal chan entity.Principal
+}
+
+func (s *principalStorage) DeleteNews(ctx context.Context, id int64) error {
+	p, _ := entity.PrincipalFromContext(ctx)
+	s.principal <- p
+	return nil
+}
+
+func TestGRPC_principal(t *testing.T) {
+	ps := &principalStorage{principal: make(chan entity.Principal, 1)}
+
+	s := NewServer(ps, "127.0.0.1:0", 3*time.Second, 5*time.Second,
+		TLSConfig{})
+	if !assert.NoError(t, s.Start()) {
+		return
+	}
+
+	c, err := NewClient(s.Addr(), time.Second, TLSConfig{})
+	if !assert.NoError(t, err) {
+		s.Stop()
+		return
+	}
+	defer cleanGRPC(s, c)
+
+	// Principal of unauthenticated peer isn't trusted.
+	p := entity.Principal{Name: "alice", Role: entity.RoleAdmin}
+
+	err = c.DeleteNews(entity.ContextWithPrincipal(context.TODO(), p), 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, entity.Anonymous, <-ps.principal)
+	}
+}
+
+// requestIDStorage remembers request id of the last deleting request and
+// fails it.
+type requestIDStorage struct {
+	storageMock
+	requestID chan string
+}
+
+func (s *requestIDStorage) DeleteNews(ctx context.Context, id int64) error {
+	rid, _ := entity.RequestIDFromContext(ctx)
+	s.requestID <- rid
+	return errors.New("db is down")
+}
+
+func TestGRPC_requestID(t *testing.T) {
+	hook := test.NewGlobal()
+	defer logrus.StandardLogger().ReplaceHooks(logrus.LevelHooks{})
+
+	rs := &requestIDStorage{requestID: make(chan string, 1)}
+
+	s := NewServer(rs, "127.0.0.1:0", 3*time.Second, 5*time.Second,
+		TLSConfig{})
+	if !assert.NoError(t, s.Start()) {
+		return
+	}
+
+	c, err := NewClient(s.Addr(), time.Second, TLSConfig{})
+	if !assert.NoError(t, err) {
+		s.Stop()
+		return
+	}
+	defer cleanGRPC(s, c)
+
+	ctx := entity.ContextWithRequestID(context.TODO(), "req-1")
+
+	err = c.DeleteNews(ctx, 1)
+	assert.Error(t, err)
+	assert.Equal(t, "req-1", <-rs.requestID)
+
+	e := hook.LastEntry()
+	if assert.NotNil(t, e) {
+		assert.Equal(t, "req-1", e.Data["request_id"])
+		assert.Equal(t, "DeleteNews", e.Data["method"])
+	}
+}
+
+type eventRecorder struct {
+	events chan entity.NewsEvent
+}
+
+func (r *eventRecorder) PublishNewsEvent(e entity.NewsEvent) {
+	r.events <- e
+}
+
+func TestServer_SetEventPublisher(t *testing.T) {
+	sm := &storageMock{}
+	er := &eventRecorder{events: make(chan entity.NewsEvent, 1)}
+
+	s := NewServer(sm, "127.0.0.1:0", 3*time.Second, 5*time.Second,
+		TLSConfig{})
+	s.SetEventPublisher(er)
+	if !assert.NoError(t, s.Start()) {
+		return
+	}
+
+	c, err := NewClient(s.Addr(), time.Second, TLSConfig{})
+	if !assert.NoError(t, err) {
+		s.Stop()
+		return
+	}
+	defer cleanGRPC(s, c)
+
+	n := entity.News{
+		ID:     1,
+		Header: "header",
+		Date:   time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	sm.On("UpdateNews", n).Return(n, nil)
+
+	_, err = c.UpdateNews(context.TODO(), n)
+	if assert.NoError(t, err) {
+		assert.Equal(t, entity.NewsEvent{Type: entity.NewsUpdated, News: n},
+			<-er.events)
+	}
+}
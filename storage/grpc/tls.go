@@ -0,0 +1,103 @@
+package grpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// TLSConfig configures TLS of server or client. TLS is disabled if all
+// files are empty.
+type TLSConfig struct {
+	// CertFile and KeyFile are PEM files of server certificate and its
+	// key, or of client certificate for mutual TLS.
+	CertFile string
+	KeyFile  string
+	// CAFile is PEM file of CA certificates which peer certificates are
+	// verified by. Server requires client certificates if it's set,
+	// client verifies server by system CAs if it isn't.
+	CAFile string
+}
+
+func (c TLSConfig) enabled() bool {
+	return c.CertFile != "" || c.CAFile != ""
+}
+
+func (c TLSConfig) certificates() ([]tls.Certificate, error) {
+	if c.CertFile == "" {
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, errors.New("failed to load certificate: " + err.Error())
+	}
+
+	return []tls.Certificate{cert}, nil
+}
+
+func (c TLSConfig) certPool() (*x509.CertPool, error) {
+	data, err := ioutil.ReadFile(c.CAFile)
+	if err != nil {
+		return nil, errors.New("failed to read CA file: " + err.Error())
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.New("CA file has no certificates")
+	}
+
+	return pool, nil
+}
+
+func (c TLSConfig) serverCredentials() (credentials.TransportCredentials,
+	error) {
+	if c.CertFile == "" {
+		return nil, errors.New("server TLS requires certificate")
+	}
+
+	certs, err := c.certificates()
+	if err != nil {
+		return nil, err
+	}
+
+	conf := &tls.Config{
+		Certificates: certs,
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if c.CAFile != "" {
+		conf.ClientCAs, err = c.certPool()
+		if err != nil {
+			return nil, err
+		}
+		conf.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return credentials.NewTLS(conf), nil
+}
+
+func (c TLSConfig) clientCredentials() (credentials.TransportCredentials,
+	error) {
+	certs, err := c.certificates()
+	if err != nil {
+		return nil, err
+	}
+
+	conf := &tls.Config{
+		Certificates: certs,
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if c.CAFile != "" {
+		conf.RootCAs, err = c.certPool()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return credentials.NewTLS(conf), nil
+}
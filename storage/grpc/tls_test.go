@@ -0,0 +1,195 @@
+package grpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/storage/service"
+	"github.com/stretchr/testify/assert"
+)
+
+// testCA issues certificates of tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("failed to generate key: " + err.Error())
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl,
+		&key.PublicKey, key)
+	if err != nil {
+		t.Fatal("failed to create certificate: " + err.Error())
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal("failed to parse certificate: " + err.Error())
+	}
+
+	return &testCA{
+		cert: cert,
+		key:  key,
+		pem: pem.EncodeToMemory(&pem.Block{
+			Type:  "CERTIFICATE",
+			Bytes: der,
+		}),
+	}
+}
+
+// issue writes certificate and key of server for 127.0.0.1 or of client
+// to files and returns their paths.
+func (ca *testCA) issue(t *testing.T, client bool) (certFile,
+	keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("failed to generate key: " + err.Error())
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if client {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+		tmpl.IPAddresses = nil
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert,
+		&key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal("failed to create certificate: " + err.Error())
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal("failed to marshal key: " + err.Error())
+	}
+
+	return writeTempFile(t, pem.EncodeToMemory(&pem.Block{
+			Type:  "CERTIFICATE",
+			Bytes: der,
+		})), writeTempFile(t, pem.EncodeToMemory(&pem.Block{
+			Type:  "EC PRIVATE KEY",
+			Bytes: keyDER,
+		}))
+}
+
+func writeTempFile(t *testing.T, data []byte) string {
+	f, err := ioutil.TempFile("", "grpc_test")
+	if err != nil {
+		t.Fatal("failed to create temp file: " + err.Error())
+	}
+	defer f.Close()
+
+	_, err = f.Write(data)
+	if err != nil {
+		t.Fatal("failed to write temp file: " + err.Error())
+	}
+
+	return f.Name()
+}
+
+func TestGRPC_mutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	caFile := writeTempFile(t, ca.pem)
+	defer os.Remove(caFile)
+
+	serverCert, serverKey := ca.issue(t, false)
+	defer os.Remove(serverCert)
+	defer os.Remove(serverKey)
+
+	clientCert, clientKey := ca.issue(t, true)
+	defer os.Remove(clientCert)
+	defer os.Remove(clientKey)
+
+	ps := &principalStorage{principal: make(chan entity.Principal, 1)}
+
+	s := NewServer(ps, "127.0.0.1:0", 3*time.Second, 5*time.Second,
+		TLSConfig{
+			CertFile: serverCert,
+			KeyFile:  serverKey,
+			CAFile:   caFile,
+		})
+	if !assert.NoError(t, s.Start()) {
+		return
+	}
+	defer s.Stop()
+
+	want := entity.Principal{Name: "alice", Role: entity.RoleAdmin}
+	ctx := entity.ContextWithPrincipal(context.TODO(), want)
+
+	c, err := NewClient(s.Addr(), time.Second, TLSConfig{
+		CertFile: clientCert,
+		KeyFile:  clientKey,
+		CAFile:   caFile,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer c.Close()
+
+	// Principal of peer authenticated by certificate is trusted.
+	err = c.DeleteNews(ctx, 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, want, <-ps.principal)
+	}
+
+	for name, tc := range map[string]TLSConfig{
+		"without certificate": {CAFile: caFile},
+		"plain":               {},
+	} {
+		c, err := NewClient(s.Addr(), time.Second, tc)
+		if !assert.NoError(t, err, name) {
+			continue
+		}
+		err = c.DeleteNews(ctx, 1)
+		assert.IsType(t, &service.RequestError{}, err, name)
+		c.Close()
+	}
+}
+
+func TestServer_Start_TLSFiles(t *testing.T) {
+	s := NewServer(&storageMock{}, "127.0.0.1:0", 3*time.Second,
+		5*time.Second, TLSConfig{
+			CertFile: "missing-cert.pem",
+			KeyFile:  "missing-key.pem",
+		})
+	assert.Error(t, s.Start())
+
+	s = NewServer(&storageMock{}, "127.0.0.1:0", 3*time.Second,
+		5*time.Second, TLSConfig{CAFile: "ca.pem"})
+	assert.Error(t, s.Start())
+}
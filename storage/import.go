@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/newsio"
+)
+
+const importUsage = "usage: import [-format jsonl|csv] [-dry-run] [-atomic] " +
+	"[-upsert] [config flags] FILE, FILE - is stdin"
+
+// runImport handles import subcommand which imports news in bulk from
+// JSONL or CSV file.
+func runImport(name string, args []string) error {
+	var o entity.ImportOptions
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	format := fs.String("format", "",
+		"jsonl or csv, detected by file extension if empty")
+	fs.BoolVar(&o.DryRun, "dry-run", false,
+		"validate and count news without saving them")
+	fs.BoolVar(&o.Atomic, "atomic", false,
+		"save nothing if any line has error")
+	fs.BoolVar(&o.Upsert, "upsert", false,
+		"update news with existing external id")
+
+	c, err := loadSubcommandConfig(name, args, fs)
+	if err != nil {
+		return err
+	}
+
+	if len(c.Args) != 1 {
+		return errors.New(importUsage)
+	}
+
+	path := c.Args[0]
+
+	if *format == "" {
+		*format = newsio.FormatJSONL
+		if filepath.Ext(path) == ".csv" {
+			*format = newsio.FormatCSV
+		}
+	}
+
+	var r io.Reader = os.Stdin
+
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return errors.New("failed to open file: " + err.Error())
+		}
+		defer f.Close()
+		r = f
+	}
+
+	src, err := newsio.NewReader(r, *format)
+	if err != nil {
+		return err
+	}
+
+	s, err := newStorage(c.Storage)
+	if err != nil {
+		return errors.New("failed to create storage: " + err.Error())
+	}
+	defer s.Close()
+
+	err = s.Migrator().UpOrCheck(c.Storage.AutoMigrate)
+	if err != nil {
+		return errors.New("failed to prepare storage schema: " + err.Error())
+	}
+
+	res, err := s.ImportNews(context.Background(), src, o)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range res.Errors {
+		fmt.Fprintln(os.Stderr, e.Error())
+	}
+	if res.Failed > len(res.Errors) {
+		fmt.Fprintf(os.Stderr, "%d more errors\n", res.Failed-len(res.Errors))
+	}
+
+	fmt.Printf("total %d, created %d, updated %d, failed %d, committed %t\n",
+		res.Total, res.Created, res.Updated, res.Failed, res.Committed)
+
+	if !res.Committed && !o.DryRun {
+		return errors.New("import is aborted, nothing is saved")
+	}
+
+	return nil
+}
@@ -1,58 +1,241 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/dimuls/news-storage/config"
+	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/storage/grpc"
+	"github.com/dimuls/news-storage/storage/migrate"
 	"github.com/dimuls/news-storage/storage/nats"
 	"github.com/dimuls/news-storage/storage/postgres"
+	"github.com/dimuls/news-storage/storage/service"
+	"github.com/dimuls/news-storage/storage/sqlite"
 	"github.com/sirupsen/logrus"
 )
 
-func main() {
-	log := logrus.WithField("subsystem", "main")
+type storage interface {
+	service.Storage
+	ImportNews(ctx context.Context, src entity.ImportSource,
+		o entity.ImportOptions) (entity.ImportResult, error)
+	Migrator() *migrate.Migrator
+	Migrate() error
+	Close() error
+}
+
+// newStorage creates storage by URI scheme: sqlite://path selects SQLite,
+// anything else is passed to Postgres as is.
+func newStorage(c *config.Storage) (storage, error) {
+	if strings.HasPrefix(c.URI, config.SQLiteScheme) {
+		return sqlite.NewStorage(strings.TrimPrefix(c.URI, config.SQLiteScheme),
+			c.PingTimeout.Duration())
+	}
+	return postgres.NewStorage(c.URI, c.ReplicaURIs,
+		c.MaxReplicaLag.Duration(), c.PingTimeout.Duration())
+}
+
+// reloadConfig reloads config and applies storage settings which can be
+// changed without restart. Replica lag is applied only by postgres.
+func reloadConfig(c *config.Config, s storage, ns *nats.Server,
+	gs *grpc.Server) *config.Config {
+	reloadable := []string{"storage.query_timeout"}
+
+	ps, isPostgres := s.(*postgres.Storage)
+	if isPostgres {
+		reloadable = append(reloadable, "storage.max_replica_lag")
+	}
+
+	return c.ReloadAndApply(reloadable, func(n *config.Config) {
+		ns.SetQueryTimeout(n.Storage.QueryTimeout.Duration())
+		if gs != nil {
+			gs.SetQueryTimeout(n.Storage.QueryTimeout.Duration())
+		}
+		if isPostgres {
+			ps.SetMaxReplicaLag(n.Storage.MaxReplicaLag.Duration())
+			setPrimaryReadWindow(n.Storage, ns, gs)
+		}
+	})
+}
+
+// setPrimaryReadWindow makes servers read from primary after writes while
+// replicas may not have them yet.
+func setPrimaryReadWindow(c *config.Storage, ns *nats.Server,
+	gs *grpc.Server) {
+	if len(c.ReplicaURIs) == 0 {
+		return
+	}
+
+	d := postgres.PrimaryReadWindow(c.MaxReplicaLag.Duration())
+
+	ns.SetPrimaryReadWindow(d)
+	if gs != nil {
+		gs.SetPrimaryReadWindow(d)
+	}
+}
 
-	ps, err := postgres.NewStorage(os.Getenv("POSTGRES_URI"))
+// run serves storage over NATS and gRPC until it is stopped by signal.
+// Storage is closed and started servers are stopped before run returns,
+// including on errors.
+func run(c *config.Config, log *logrus.Entry) error {
+	s, err := newStorage(c.Storage)
 	if err != nil {
-		log.WithError(err).Fatal("failed to create postgres storage")
+		return errors.New("failed to create storage: " + err.Error())
 	}
 
 	defer func() {
-		err = ps.Close()
+		err := s.Close()
 		if err != nil {
 			log.WithError(err).Error("failed to close storage")
 		}
 	}()
 
-	err = ps.Migrate()
+	err = s.Migrator().UpOrCheck(c.Storage.AutoMigrate)
 	if err != nil {
-		log.WithError(err).Fatal("failed to migrate postgres storage")
+		return errors.New("failed to prepare storage schema: " + err.Error())
 	}
 
-	ns := nats.NewServer(ps, os.Getenv("NATS_URL"),
-		os.Getenv("SUBSCRIBE_SUBJECT"))
+	natsURL := c.NATS.URL
+
+	var es *nats.EmbeddedServer
+
+	if c.NATS.EmbeddedAddr != "" {
+		es = nats.NewEmbeddedServer(c.NATS.EmbeddedAddr)
+
+		err = es.Start()
+		if err != nil {
+			return errors.New("failed to start embedded nats server: " +
+				err.Error())
+		}
+
+		natsURL = es.URL()
+	}
+
+	ns := nats.NewServer(s, natsURL, c.NATS.Subject,
+		c.Storage.QueryTimeout.Duration(), c.NATS.DrainTimeout.Duration())
+	ns.SetTrustPrincipalHeaders(c.NATS.TrustPrincipalHeaders)
 
 	err = ns.Start()
 	if err != nil {
-		logrus.WithError(err).Error("failed to start nats server")
+		if es != nil {
+			es.Stop()
+		}
+		return errors.New("failed to start nats server: " + err.Error())
 	}
 
 	log.Info("nats server started")
 
-	ss := make(chan os.Signal)
+	var gs *grpc.Server
+
+	if c.GRPC.BindAddr != "" {
+		gs = grpc.NewServer(s, c.GRPC.BindAddr,
+			c.Storage.QueryTimeout.Duration(),
+			c.GRPC.ShutdownTimeout.Duration(), grpc.TLSConfig{
+				CertFile: c.GRPC.TLSCertFile,
+				KeyFile:  c.GRPC.TLSKeyFile,
+				CAFile:   c.GRPC.TLSCAFile,
+			})
+		gs.SetEventPublisher(ns)
+		gs.SetSubscriber(ns)
+
+		err = gs.Start()
+		if err != nil {
+			ns.Stop()
+			if es != nil {
+				es.Stop()
+			}
+			return errors.New("failed to start grpc server: " + err.Error())
+		}
+
+		log.WithField("addr", gs.Addr()).Info("grpc server started")
+	}
 
-	signal.Notify(ss, syscall.SIGTERM)
+	setPrimaryReadWindow(c.Storage, ns, gs)
 
-	s := <-ss
+	ss := make(chan os.Signal, 1)
 
-	log.Infof("captured %v signal, stopping", s)
+	signal.Notify(ss, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
+
+	var sig os.Signal
+
+	for sig = range ss {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		log.Info("captured SIGHUP signal, reloading config")
+		c = reloadConfig(c, s, ns, gs)
+	}
+
+	log.Infof("captured %v signal, stopping", sig)
 
 	st := time.Now()
+	if gs != nil {
+		gs.Stop()
+	}
 	ns.Stop()
+	if es != nil {
+		es.Stop()
+	}
 	et := time.Now()
 
 	log.Infof("stopped in %g seconds, exiting",
 		et.Sub(st).Seconds())
+
+	return nil
+}
+
+func main() {
+	log := logrus.WithField("subsystem", "main")
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err := runMigrate(os.Args[0]+" migrate", os.Args[2:])
+		if err != nil && err != flag.ErrHelp {
+			log.WithError(err).Fatal("failed to run migrate command")
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		err := runImport(os.Args[0]+" import", os.Args[2:])
+		if err != nil && err != flag.ErrHelp {
+			log.WithError(err).Fatal("failed to run import command")
+		}
+		return
+	}
+
+	c, err := config.Load(os.Args[0], os.Args[1:], config.SectionStorage|
+		config.SectionNATS|config.SectionEmbeddedNATS|
+		config.SectionGRPCServer)
+	if err != nil {
+		if err == flag.ErrHelp {
+			return
+		}
+		log.WithError(err).Fatal("failed to load config")
+	}
+
+	if c.PrintConfig {
+		err = c.Print(os.Stdout)
+		if err != nil {
+			log.WithError(err).Fatal("failed to print config")
+		}
+		return
+	}
+
+	err = c.Validate()
+	if err != nil {
+		log.WithError(err).Fatal("invalid config")
+	}
+
+	logrus.SetLevel(c.LogrusLevel())
+
+	err = run(c, log)
+	if err != nil {
+		log.WithError(err).Fatal("failed to run")
+	}
 }
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dimuls/news-storage/config"
+	"github.com/dimuls/news-storage/storage/migrate"
+)
+
+const migrateUsage = "usage: migrate [flags] status | up [N] | down N | " +
+	"goto VERSION | create NAME"
+
+// migrationsDir returns directory of migrations source files relative
+// to repository root.
+func migrationsDir(storageURI string) string {
+	if strings.HasPrefix(storageURI, config.SQLiteScheme) {
+		return "storage/sqlite/migrations"
+	}
+	return "storage/postgres/migrations"
+}
+
+// runMigrate handles migrate subcommand which manages storage schema
+// separately from serving.
+func runMigrate(name string, args []string) error {
+	// Migrate has no own flags yet, but they are parsed the same way as
+	// import ones.
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+
+	c, err := loadSubcommandConfig(name, args, fs)
+	if err != nil {
+		return err
+	}
+
+	if len(c.Args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	cmd, cmdArgs := c.Args[0], c.Args[1:]
+
+	if cmd == "create" {
+		if len(cmdArgs) != 1 {
+			return errors.New(migrateUsage)
+		}
+		up, down, err := migrate.Create(migrationsDir(c.Storage.URI),
+			cmdArgs[0])
+		if err != nil {
+			return err
+		}
+		fmt.Println("created", up)
+		fmt.Println("created", down)
+		return nil
+	}
+
+	s, err := newStorage(c.Storage)
+	if err != nil {
+		return errors.New("failed to create storage: " + err.Error())
+	}
+	defer s.Close()
+
+	m := s.Migrator()
+
+	var n int
+
+	switch {
+	case cmd == "status" && len(cmdArgs) == 0:
+		ss, err := m.Status()
+		if err != nil {
+			return err
+		}
+		for _, s := range ss {
+			state := "pending"
+			if s.Applied {
+				state = "applied"
+			}
+			fmt.Printf("%s\t%s\n", state, s.ID)
+		}
+		return nil
+
+	case cmd == "up" && len(cmdArgs) <= 1:
+		if len(cmdArgs) == 1 {
+			n, err = strconv.Atoi(cmdArgs[0])
+			if err != nil || n < 1 {
+				return errors.New("N must be positive integer")
+			}
+		}
+		n, err = m.Up(n)
+
+	case cmd == "down" && len(cmdArgs) == 1:
+		n, err = strconv.Atoi(cmdArgs[0])
+		if err != nil || n < 1 {
+			return errors.New("N must be positive integer")
+		}
+		n, err = m.Down(n)
+
+	case cmd == "goto" && len(cmdArgs) == 1:
+		n, err = m.Goto(cmdArgs[0])
+
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	if err != nil {
+		// Migrations run before failure stay applied, so their number is
+		// reported with the error.
+		return errors.New(strconv.Itoa(n) +
+			" migrations run before failure: " + err.Error())
+	}
+
+	fmt.Printf("%d migrations run\n", n)
+
+	return nil
+}
@@ -0,0 +1,276 @@
+package migrate
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/Boostport/migration"
+)
+
+// Status is a migration with its state in database.
+type Status struct {
+	ID      string
+	Applied bool
+}
+
+// Migrator manages migrations from source applied by drivers created
+// with newDriver. Migration driver is closed after every operation,
+// so new one is created every time.
+type Migrator struct {
+	newDriver func() (migration.Driver, error)
+	source    migration.Source
+}
+
+func NewMigrator(newDriver func() (migration.Driver, error),
+	source migration.Source) *Migrator {
+	return &Migrator{
+		newDriver: newDriver,
+		source:    source,
+	}
+}
+
+var (
+	fileRegexp   = regexp.MustCompile(`^(\d*_.*)\.(up|down)\..*$`)
+	numberRegexp = regexp.MustCompile(`^(\d+)`)
+)
+
+func number(id string) (int64, bool) {
+	m := numberRegexp.FindStringSubmatch(id)
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(m[1], 10, 64)
+	return n, err == nil
+}
+
+// less orders migrations the same way as migration library does.
+func less(a, b string) bool {
+	an, aok := number(a)
+	bn, bok := number(b)
+	switch {
+	case aok && bok && an != bn:
+		return an < bn
+	case aok && !bok:
+		return true
+	case !aok && bok:
+		return false
+	default:
+		return a < b
+	}
+}
+
+func (m *Migrator) ids() ([]string, error) {
+	files, err := m.source.ListMigrationFiles()
+	if err != nil {
+		return nil, errors.New("failed to list migrations: " + err.Error())
+	}
+
+	seen := map[string]bool{}
+
+	var ids []string
+
+	for _, f := range files {
+		match := fileRegexp.FindStringSubmatch(f)
+		if match == nil || seen[match[1]] {
+			continue
+		}
+		seen[match[1]] = true
+		ids = append(ids, match[1])
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		return less(ids[i], ids[j])
+	})
+
+	return ids, nil
+}
+
+// Status returns all known migrations in order they are applied.
+func (m *Migrator) Status() ([]Status, error) {
+	ids, err := m.ids()
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := m.newDriver()
+	if err != nil {
+		return nil, errors.New("failed to create migration driver: " +
+			err.Error())
+	}
+	defer d.Close()
+
+	versions, err := d.Versions()
+	if err != nil {
+		return nil, errors.New("failed to get applied migrations: " +
+			err.Error())
+	}
+
+	applied := map[string]bool{}
+	for _, v := range versions {
+		applied[v] = true
+	}
+
+	ss := make([]Status, 0, len(ids))
+	for _, id := range ids {
+		ss = append(ss, Status{ID: id, Applied: applied[id]})
+	}
+
+	return ss, nil
+}
+
+// Pending returns number of not applied migrations.
+func (m *Migrator) Pending() (int, error) {
+	ss, err := m.Status()
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	for _, s := range ss {
+		if !s.Applied {
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+// UpOrCheck applies all migrations if up is set, otherwise it fails when
+// schema is behind, so that binaries don't run on outdated schema.
+func (m *Migrator) UpOrCheck(up bool) error {
+	if up {
+		_, err := m.Up(0)
+		return err
+	}
+
+	n, err := m.Pending()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return errors.New("schema is behind by " + strconv.Itoa(n) +
+			" migrations, run migrate up")
+	}
+
+	return nil
+}
+
+func (m *Migrator) migrate(direction migration.Direction, n int) (int, error) {
+	d, err := m.newDriver()
+	if err != nil {
+		return 0, errors.New("failed to create migration driver: " +
+			err.Error())
+	}
+
+	applied, err := migration.Migrate(d, m.source, direction, n)
+	if err != nil {
+		return applied, errors.New("failed to migrate: " + err.Error())
+	}
+
+	return applied, nil
+}
+
+// Up applies n next migrations or all of them if n is 0. It returns
+// number of applied migrations.
+func (m *Migrator) Up(n int) (int, error) {
+	return m.migrate(migration.Up, n)
+}
+
+// Down rolls back n last migrations or all of them if n is 0. It returns
+// number of rolled back migrations.
+func (m *Migrator) Down(n int) (int, error) {
+	return m.migrate(migration.Down, n)
+}
+
+// Goto migrates up or down so that migration id is the last applied one.
+// Id "0" rolls back all migrations.
+func (m *Migrator) Goto(id string) (int, error) {
+	ss, err := m.Status()
+	if err != nil {
+		return 0, err
+	}
+
+	target := -1
+
+	if id != "0" {
+		for i, s := range ss {
+			if s.ID == id {
+				target = i
+				break
+			}
+		}
+		if target == -1 {
+			return 0, errors.New("unknown migration " + id)
+		}
+	}
+
+	var up, down int
+
+	for i, s := range ss {
+		if i <= target && !s.Applied {
+			up++
+		}
+		if i > target && s.Applied {
+			down++
+		}
+	}
+
+	if down > 0 {
+		return m.Down(down)
+	}
+	if up > 0 {
+		return m.Up(up)
+	}
+
+	return 0, nil
+}
+
+var nameRegexp = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// Create creates empty up and down migration files named name in dir.
+// New migration gets next number after the biggest one in dir.
+func Create(dir, name string) (up, down string, err error) {
+	if !nameRegexp.MatchString(name) {
+		return "", "", errors.New(
+			"migration name must consist of a-z, 0-9 and _")
+	}
+
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", "", errors.New("failed to read migrations dir: " +
+			err.Error())
+	}
+
+	var last int64
+
+	for _, fi := range fis {
+		n, ok := number(fi.Name())
+		if ok && n > last {
+			last = n
+		}
+	}
+
+	prefix := filepath.Join(dir, strconv.FormatInt(last+1, 10)+"_"+name)
+
+	up, down = prefix+".up.sql", prefix+".down.sql"
+
+	for _, path := range []string{up, down} {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err != nil {
+			return "", "", errors.New("failed to create migration file: " +
+				err.Error())
+		}
+		err = f.Close()
+		if err != nil {
+			return "", "", errors.New("failed to close migration file: " +
+				err.Error())
+		}
+	}
+
+	return up, down, nil
+}
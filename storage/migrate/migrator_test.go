@@ -0,0 +1,151 @@
+package migrate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Boostport/migration"
+	"github.com/stretchr/testify/assert"
+)
+
+type driverMock struct {
+	versions []string
+}
+
+func (d *driverMock) Close() error {
+	return nil
+}
+
+func (d *driverMock) Migrate(m *migration.PlannedMigration) error {
+	if m.Direction == migration.Up {
+		d.versions = append(d.versions, m.ID)
+		return nil
+	}
+	for i, v := range d.versions {
+		if v == m.ID {
+			d.versions = append(d.versions[:i], d.versions[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (d *driverMock) Versions() ([]string, error) {
+	return append([]string{}, d.versions...), nil
+}
+
+func initMigrator() (*driverMock, *Migrator) {
+	d := &driverMock{}
+	return d, NewMigrator(func() (migration.Driver, error) {
+		return d, nil
+	}, migration.MemoryMigrationSource{
+		Files: map[string]string{
+			"1_init.up.sql":    "CREATE TABLE a (id INT);",
+			"1_init.down.sql":  "DROP TABLE a;",
+			"2_b.up.sql":       "CREATE TABLE b (id INT);",
+			"2_b.down.sql":     "DROP TABLE b;",
+			"10_c.up.sql":      "CREATE TABLE c (id INT);",
+			"10_c.down.sql":    "DROP TABLE c;",
+			"not_a_migration":  "",
+			"readme.down.text": "",
+		},
+	})
+}
+
+func TestMigrator_Status(t *testing.T) {
+	d, m := initMigrator()
+	d.versions = []string{"1_init"}
+
+	ss, err := m.Status()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, []Status{
+		{ID: "1_init", Applied: true},
+		{ID: "2_b"},
+		{ID: "10_c"},
+	}, ss)
+
+	n, err := m.Pending()
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, n)
+	}
+}
+
+func TestMigrator_UpDown(t *testing.T) {
+	d, m := initMigrator()
+
+	n, err := m.Up(2)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, n)
+		assert.Equal(t, []string{"1_init", "2_b"}, d.versions)
+	}
+
+	n, err = m.Down(1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, n)
+		assert.Equal(t, []string{"1_init"}, d.versions)
+	}
+}
+
+func TestMigrator_UpOrCheck(t *testing.T) {
+	d, m := initMigrator()
+
+	assert.EqualError(t, m.UpOrCheck(false),
+		"schema is behind by 3 migrations, run migrate up")
+
+	if assert.NoError(t, m.UpOrCheck(true)) {
+		assert.Len(t, d.versions, 3)
+	}
+
+	assert.NoError(t, m.UpOrCheck(false))
+}
+
+func TestMigrator_Goto(t *testing.T) {
+	d, m := initMigrator()
+
+	_, err := m.Goto("10_c")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"1_init", "2_b", "10_c"}, d.versions)
+	}
+
+	_, err = m.Goto("1_init")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"1_init"}, d.versions)
+	}
+
+	_, err = m.Goto("0")
+	if assert.NoError(t, err) {
+		assert.Empty(t, d.versions)
+	}
+
+	_, err = m.Goto("5_unknown")
+	assert.Error(t, err)
+}
+
+func TestCreate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "news-storage-migrations")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "9_init.up.sql"), nil, 0644)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	up, down, err := Create(dir, "add_tags")
+	if assert.NoError(t, err) {
+		assert.Equal(t, filepath.Join(dir, "10_add_tags.up.sql"), up)
+		assert.Equal(t, filepath.Join(dir, "10_add_tags.down.sql"), down)
+		assert.FileExists(t, up)
+		assert.FileExists(t, down)
+	}
+
+	_, _, err = Create(dir, "Bad Name")
+	assert.Error(t, err)
+}
@@ -0,0 +1,30 @@
+package nats
+
+import (
+	"context"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/storage/pb"
+	"github.com/dimuls/news-storage/storage/service"
+	"github.com/golang/protobuf/proto"
+)
+
+func (s *Server) batchGetNews(ctx context.Context, req proto.Message) (
+	proto.Message, error) {
+	return service.BatchGetNews(ctx, s.storage, req.(*pb.BatchGetNewsRequest))
+}
+
+// NewsByIDs gets news with ids by one request. News are returned in no
+// particular order, missing ones are omitted.
+func (c *Client) NewsByIDs(ctx context.Context, ids []int64) (
+	[]entity.News, error) {
+	var res pb.BatchGetNewsResponse
+
+	err := c.request(ctx, c.subSubj+batchSuffix,
+		&pb.BatchGetNewsRequest{Ids: ids}, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return pb.ToNewsList(res.News)
+}
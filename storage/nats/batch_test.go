@@ -0,0 +1,33 @@
+package nats
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/storage/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_NewsByIDs(t *testing.T) {
+	sm, s := initServer(t)
+	defer cleanServer(t, s)
+
+	c := initClient(t)
+	defer c.Close()
+
+	wantNs := manyNews(2)
+
+	sm.On("NewsByIDs", []int64{1, 2, 3}).Return(wantNs, nil)
+
+	ns, err := c.NewsByIDs(context.TODO(), []int64{1, 2, 3})
+	if assert.NoError(t, err) {
+		assert.Equal(t, wantNs, ns)
+	}
+
+	_, err = c.NewsByIDs(context.TODO(), make([]int64, service.MaxBatchIDs+1))
+	assert.Equal(t, entity.ValidationError("ids must have at most 1000 items"),
+		err)
+
+	sm.AssertExpectations(t)
+}
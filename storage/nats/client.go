@@ -7,76 +7,147 @@ import (
 	"time"
 
 	"github.com/dimuls/news-storage/entity"
-	"github.com/dimuls/news-storage/storage/nats/pb"
+	"github.com/dimuls/news-storage/storage/pb"
+	"github.com/dimuls/news-storage/storage/service"
 	"github.com/golang/protobuf/proto"
 	"github.com/nats-io/nats.go"
 )
 
 type Client struct {
-	subSubj    string
-	connection *nats.Conn
+	subSubj           string
+	connection        *nats.Conn
+	streamIdleTimeout time.Duration
 }
 
-func NewClient(natsURL, subSubj string) (*Client, error) {
-	conn, err := nats.Connect(natsURL, nats.DrainTimeout(5*time.Second))
+func NewClient(natsURL, subSubj string, drainTimeout time.Duration) (
+	*Client, error) {
+	conn, err := nats.Connect(natsURL, nats.DrainTimeout(drainTimeout))
 	if err != nil {
 		return nil, errors.New("failed to connect to nats: " + err.Error())
 	}
 	return &Client{
-		subSubj:    subSubj,
-		connection: conn,
+		subSubj:           subSubj,
+		connection:        conn,
+		streamIdleTimeout: DefaultStreamIdleTimeout,
 	}, nil
 }
 
+// SetStreamIdleTimeout changes time which streams wait for the next
+// chunk before failing. It must be called before streaming.
+func (c *Client) SetStreamIdleTimeout(d time.Duration) {
+	c.streamIdleTimeout = d
+}
+
 func (c *Client) Close() {
 	c.connection.Close()
 }
 
-func (c *Client) News(ctx context.Context, id int64) (entity.News, error) {
-	req := &pb.GetNewsRequest{
-		Id: id,
+// Headers which carry principal on whose behalf request is made and id
+// of request which correlates logs of client and server.
+const (
+	principalHeader     = "X-Principal"
+	principalRoleHeader = "X-Principal-Role"
+	requestIDHeader     = "X-Request-ID"
+)
+
+// requestMsg returns request to subj with principal and request id of
+// ctx if any.
+func requestMsg(ctx context.Context, subj string, data []byte) *nats.Msg {
+	msg := nats.NewMsg(subj)
+	msg.Data = data
+	if p, ok := entity.PrincipalFromContext(ctx); ok {
+		msg.Header.Set(principalHeader, p.Name)
+		msg.Header.Set(principalRoleHeader, p.Role.String())
+	}
+	if id, ok := entity.RequestIDFromContext(ctx); ok {
+		msg.Header.Set(requestIDHeader, id)
 	}
+	return msg
+}
+
+type response interface {
+	proto.Message
+	GetError() *pb.Error
+}
 
+// request sends req to subj and decodes reply to res. Response errors
+// are converted to entity errors when possible.
+func (c *Client) request(ctx context.Context, subj string,
+	req proto.Message, res response) error {
 	reqBytes, err := proto.Marshal(req)
 	if err != nil {
-		return entity.News{}, errors.New(
-			"failed to marshal *pb.GetNewsRequest: " + err.Error())
+		return errors.New("failed to marshal request: " + err.Error())
+	}
+
+	resMsg, err := c.connection.RequestMsgWithContext(ctx,
+		requestMsg(ctx, subj, reqBytes))
+	if err != nil {
+		return &service.RequestError{Err: err}
 	}
 
-	resMsg, err := c.connection.RequestWithContext(ctx, c.subSubj, reqBytes)
+	err = proto.Unmarshal(resMsg.Data, res)
 	if err != nil {
-		return entity.News{}, errors.New("failed to request: " + err.Error())
+		return errors.New("failed to unmarshal response: " + err.Error())
+	}
+
+	if e := res.GetError(); e != nil {
+		return responseError(e)
+	}
+
+	return nil
+}
+
+// responseError converts server error to entity error when possible.
+func responseError(e *pb.Error) error {
+	switch e.Code {
+	case http.StatusNotFound:
+		return entity.ErrNewsNotFound
+	case http.StatusBadRequest:
+		return entity.ValidationError(e.Message)
 	}
+	return &service.ResponseError{Code: e.Code, Message: e.Message}
+}
 
+func (c *Client) News(ctx context.Context, id int64) (entity.News, error) {
 	var res pb.GetNewsResponse
 
-	err = proto.Unmarshal(resMsg.Data, &res)
+	err := c.request(ctx, c.subSubj, &pb.GetNewsRequest{Id: id}, &res)
 	if err != nil {
-		return entity.News{}, errors.New("failed to unmarshal response: " +
-			err.Error())
+		return entity.News{}, err
 	}
 
-	if res.Error != nil {
-		if res.Error.Code == http.StatusNotFound {
-			return entity.News{}, entity.ErrNewsNotFound
-		}
-		return entity.News{}, errors.New("got response error: " +
-			res.Error.Message)
-	}
+	return pb.ToNews(res.News)
+}
+
+// CreateNews creates news n and returns it with assigned ID.
+func (c *Client) CreateNews(ctx context.Context, n entity.News) (
+	entity.News, error) {
+	var res pb.GetNewsResponse
 
-	if res.News == nil {
-		return entity.News{}, errors.New("unexpected nil news")
+	err := c.request(ctx, c.subSubj+createSuffix,
+		&pb.CreateNewsRequest{News: pb.FromNews(n)}, &res)
+	if err != nil {
+		return entity.News{}, err
 	}
 
-	date, err := time.ParseInLocation("2006-01-02", res.News.Date,
-		time.UTC)
+	return pb.ToNews(res.News)
+}
+
+// UpdateNews replaces news with ID n.ID by n.
+func (c *Client) UpdateNews(ctx context.Context, n entity.News) (
+	entity.News, error) {
+	var res pb.GetNewsResponse
+
+	err := c.request(ctx, c.subSubj+updateSuffix,
+		&pb.UpdateNewsRequest{News: pb.FromNews(n)}, &res)
 	if err != nil {
-		return entity.News{}, errors.New("failed to parse date: " + err.Error())
+		return entity.News{}, err
 	}
 
-	return entity.News{
-		ID:     res.News.Id,
-		Header: res.News.Header,
-		Date:   date,
-	}, nil
+	return pb.ToNews(res.News)
+}
+
+func (c *Client) DeleteNews(ctx context.Context, id int64) error {
+	return c.request(ctx, c.subSubj+deleteSuffix,
+		&pb.DeleteNewsRequest{Id: id}, &pb.DeleteNewsResponse{})
 }
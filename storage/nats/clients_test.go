@@ -3,21 +3,20 @@ package nats
 import (
 	"context"
 	"net/http"
-	"os"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/dimuls/news-storage/entity"
-	"github.com/dimuls/news-storage/storage/nats/pb"
+	"github.com/dimuls/news-storage/storage/pb"
+	"github.com/dimuls/news-storage/storage/service"
 	"github.com/golang/protobuf/proto"
 	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/assert"
 )
 
 func initClient(t *testing.T) *Client {
-	c, err := NewClient(os.Getenv("TEST_NATS_URL"),
-		os.Getenv("TEST_SUBSCRIBE_SUBJECT"))
+	c, err := NewClient(testNATSURL, testSubject, 5*time.Second)
 	if err != nil {
 		t.Fatal("failed to create client: " + err.Error())
 	}
@@ -205,3 +204,119 @@ func TestClient_News_success(t *testing.T) {
 
 	wg.Wait()
 }
+
+// respond replies to one request from subj with res.
+func respond(t *testing.T, c *Client, subj string, req proto.Message,
+	res proto.Message) <-chan struct{} {
+	sub, err := c.connection.SubscribeSync(subj)
+	if err != nil {
+		t.Fatal("failed to subscribe: " + err.Error())
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		defer sub.Unsubscribe()
+
+		msg, err := sub.NextMsg(2 * time.Second)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		err = proto.Unmarshal(msg.Data, req)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		resBytes, err := proto.Marshal(res)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.NoError(t, msg.Respond(resBytes))
+	}()
+
+	return done
+}
+
+func TestClient_ListNews_success(t *testing.T) {
+	c := initClient(t)
+	defer c.Close()
+
+	var req pb.ListNewsRequest
+
+	done := respond(t, c, c.subSubj+listSuffix, &req, &pb.NewsChunk{
+		News: []*pb.News{{Id: 1, Header: "header", Date: "2019-01-02"}},
+		Last: true,
+	})
+
+	ns, err := c.ListNews(context.TODO(), entity.NewsFilter{
+		From:  time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		Query: "query",
+		Limit: 10,
+	})
+
+	<-done
+
+	assert.Equal(t, "2019-01-01", req.From)
+	assert.Equal(t, "", req.To)
+	assert.Equal(t, "query", req.Query)
+	assert.Equal(t, int64(10), req.Limit)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, []entity.News{{
+			ID:     1,
+			Header: "header",
+			Date:   time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+		}}, ns)
+	}
+}
+
+func TestClient_CreateNews_validationError(t *testing.T) {
+	c := initClient(t)
+	defer c.Close()
+
+	var req pb.CreateNewsRequest
+
+	done := respond(t, c, c.subSubj+createSuffix, &req, &pb.GetNewsResponse{
+		Error: &pb.Error{
+			Code:    http.StatusBadRequest,
+			Message: "header is required",
+		},
+	})
+
+	_, err := c.CreateNews(context.TODO(), entity.News{
+		Date: time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+	})
+
+	<-done
+
+	assert.Equal(t, "2019-01-02", req.News.Date)
+	assert.Equal(t, entity.ValidationError("header is required"), err)
+}
+
+func TestClient_DeleteNews_serverError(t *testing.T) {
+	c := initClient(t)
+	defer c.Close()
+
+	var req pb.DeleteNewsRequest
+
+	done := respond(t, c, c.subSubj+deleteSuffix, &req,
+		&pb.DeleteNewsResponse{
+			Error: &pb.Error{
+				Code:    http.StatusInternalServerError,
+				Message: "internal server error",
+			},
+		})
+
+	err := c.DeleteNews(context.TODO(), 123)
+
+	<-done
+
+	assert.Equal(t, int64(123), req.Id)
+	assert.Equal(t, &service.ResponseError{
+		Code:    http.StatusInternalServerError,
+		Message: "internal server error",
+	}, err)
+}
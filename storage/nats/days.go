@@ -0,0 +1,29 @@
+package nats
+
+import (
+	"context"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/storage/pb"
+	"github.com/dimuls/news-storage/storage/service"
+	"github.com/golang/protobuf/proto"
+)
+
+func (s *Server) newsDays(ctx context.Context, req proto.Message) (
+	proto.Message, error) {
+	return service.NewsDays(ctx, s.storage)
+}
+
+// NewsDays gets days which have news in UTC with number of news of
+// every day. Days are ordered by date.
+func (c *Client) NewsDays(ctx context.Context) ([]entity.NewsDay, error) {
+	var res pb.NewsDaysResponse
+
+	err := c.request(ctx, c.subSubj+daysSuffix, &pb.NewsDaysRequest{},
+		&res)
+	if err != nil {
+		return nil, err
+	}
+
+	return pb.ToNewsDays(res.Days)
+}
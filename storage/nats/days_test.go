@@ -0,0 +1,42 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+var testNewsDays = []entity.NewsDay{{
+	Date:  time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+	Count: 3,
+}, {
+	Date:  time.Date(2019, 1, 5, 0, 0, 0, 0, time.UTC),
+	Count: 50001,
+}}
+
+func TestClient_NewsDays(t *testing.T) {
+	sm, s := initServer(t)
+	defer cleanServer(t, s)
+
+	c := initClient(t)
+	defer c.Close()
+
+	sm.On("NewsDays").Return(testNewsDays, nil).Once()
+
+	days, err := c.NewsDays(context.TODO())
+	if assert.NoError(t, err) {
+		assert.Equal(t, testNewsDays, days)
+	}
+
+	sm.On("NewsDays").Return([]entity.NewsDay(nil),
+		errors.New("db is down")).Once()
+
+	_, err = c.NewsDays(context.TODO())
+	assert.Error(t, err)
+
+	sm.AssertExpectations(t)
+}
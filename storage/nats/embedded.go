@@ -0,0 +1,96 @@
+package nats
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/sirupsen/logrus"
+)
+
+// EmbeddedServer is NATS server running inside the current process. It
+// lets the stack run without external NATS for local runs and tests.
+type EmbeddedServer struct {
+	addr     string
+	server   *server.Server
+	storeDir string
+	log      *logrus.Entry
+}
+
+// NewEmbeddedServer creates embedded NATS server listening on addr in
+// host:port form. Port 0 selects random free port.
+func NewEmbeddedServer(addr string) *EmbeddedServer {
+	return &EmbeddedServer{
+		addr: addr,
+		log:  logrus.WithField("subsystem", "nats_embedded_server"),
+	}
+}
+
+func (s *EmbeddedServer) Start() error {
+	host, portStr, err := net.SplitHostPort(s.addr)
+	if err != nil {
+		return errors.New("failed to parse address: " + err.Error())
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return errors.New("failed to parse port: " + err.Error())
+	}
+	if port == 0 {
+		port = server.RANDOM_PORT
+	}
+
+	// JetStream keeps key-value buckets, e.g. shared rate limits of web
+	// servers. They are temporary as the server itself.
+	storeDir, err := ioutil.TempDir("", "nats-embedded")
+	if err != nil {
+		return errors.New("failed to create store dir: " + err.Error())
+	}
+
+	ns, err := server.NewServer(&server.Options{
+		Host:      host,
+		Port:      port,
+		NoLog:     true,
+		NoSigs:    true,
+		JetStream: true,
+		StoreDir:  storeDir,
+	})
+	if err != nil {
+		os.RemoveAll(storeDir)
+		return errors.New("failed to create server: " + err.Error())
+	}
+
+	go ns.Start()
+
+	if !ns.ReadyForConnections(10 * time.Second) {
+		ns.Shutdown()
+		os.RemoveAll(storeDir)
+		return errors.New("server is not ready for connections")
+	}
+
+	s.server = ns
+	s.storeDir = storeDir
+
+	s.log.WithField("url", ns.ClientURL()).Info("started")
+
+	return nil
+}
+
+// URL returns URL for clients to connect to the started server.
+func (s *EmbeddedServer) URL() string {
+	return s.server.ClientURL()
+}
+
+func (s *EmbeddedServer) Stop() {
+	s.server.Shutdown()
+	s.server.WaitForShutdown()
+
+	err := os.RemoveAll(s.storeDir)
+	if err != nil {
+		s.log.WithError(err).Error("failed to remove store dir")
+	}
+}
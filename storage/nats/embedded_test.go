@@ -0,0 +1,28 @@
+package nats
+
+import (
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmbeddedServer_Start_badAddr(t *testing.T) {
+	s := NewEmbeddedServer("localhost")
+	assert.Error(t, s.Start())
+}
+
+func TestEmbeddedServer_Start_success(t *testing.T) {
+	s := NewEmbeddedServer("127.0.0.1:0")
+
+	err := s.Start()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer s.Stop()
+
+	conn, err := nats.Connect(s.URL())
+	if assert.NoError(t, err) {
+		conn.Close()
+	}
+}
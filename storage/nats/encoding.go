@@ -0,0 +1,81 @@
+package nats
+
+import (
+	"bytes"
+	"mime"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	contentTypeHeader = "Content-Type"
+	jsonContentType   = "application/json"
+)
+
+// encoding is encoding of server requests and responses. Requests are
+// binary protobuf unless they have JSON content type header, responses
+// are encoded as requests.
+type encoding int
+
+const (
+	encodingProto encoding = iota
+	// encodingJSON is canonical protobuf JSON mapping.
+	encodingJSON
+)
+
+// msgEncoding returns encoding of msg by its content type header.
+func msgEncoding(msg *nats.Msg) encoding {
+	if msg.Header == nil {
+		return encodingProto
+	}
+
+	mt, _, err := mime.ParseMediaType(msg.Header.Get(contentTypeHeader))
+	if err == nil && mt == jsonContentType {
+		return encodingJSON
+	}
+
+	return encodingProto
+}
+
+func (e encoding) marshal(m proto.Message) ([]byte, error) {
+	if e == encodingJSON {
+		var b bytes.Buffer
+		err := (&jsonpb.Marshaler{}).Marshal(&b, m)
+		return b.Bytes(), err
+	}
+	return proto.Marshal(m)
+}
+
+func (e encoding) unmarshal(data []byte, m proto.Message) error {
+	if e == encodingJSON {
+		return jsonpb.Unmarshal(bytes.NewReader(data), m)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+// elementSize returns size which m adds to encoded repeated field.
+func (e encoding) elementSize(m proto.Message) int {
+	if e == encodingJSON {
+		s, err := (&jsonpb.Marshaler{}).MarshalToString(m)
+		if err != nil {
+			return 0
+		}
+		// Element is separated by comma.
+		return len(s) + 1
+	}
+	// Size of element is its size with tag and length which take at
+	// most 6 bytes for message fitting in NATS payload.
+	return proto.Size(m) + 6
+}
+
+// newMsg returns message to subj with data of encoding e.
+func (e encoding) newMsg(subj string, data []byte) *nats.Msg {
+	msg := nats.NewMsg(subj)
+	msg.Data = data
+	if e == encodingJSON {
+		msg.Header.Set(contentTypeHeader, jsonContentType)
+	}
+	return msg
+}
@@ -0,0 +1,75 @@
+package nats
+
+import (
+	"errors"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/storage/pb"
+	"github.com/golang/protobuf/proto"
+	"github.com/nats-io/nats.go"
+)
+
+// PublishNewsEvent publishes e to events subject. Events aren't
+// persisted, subscribers get only ones published after they subscribed.
+func (s *Server) PublishNewsEvent(e entity.NewsEvent) {
+	s.publishEvent(e.Type, pb.FromNews(e.News))
+}
+
+func (s *Server) publishEvent(typ string, n *pb.News) {
+	data, err := proto.Marshal(&pb.NewsEvent{
+		Type: typ,
+		News: n,
+	})
+	if err != nil {
+		s.log.WithError(err).Error("failed to marshal news event")
+		return
+	}
+
+	err = s.connection.Publish(s.subSubj+eventsSuffix, data)
+	if err != nil {
+		s.log.WithError(err).Error("failed to publish news event")
+	}
+}
+
+// SubscribeNews calls fn with every news event published by servers
+// until unsubscribe is called. Malformed events are skipped.
+func (c *Client) SubscribeNews(fn func(entity.NewsEvent)) (
+	unsubscribe func() error, err error) {
+	return subscribeNews(c.connection, c.subSubj+eventsSuffix, fn)
+}
+
+// SubscribeNews calls fn with every news event published by servers of
+// the same subject, this one included, until unsubscribe is called, so
+// that server can relay them to consumers of other transports.
+func (s *Server) SubscribeNews(fn func(entity.NewsEvent)) (
+	unsubscribe func() error, err error) {
+	return subscribeNews(s.connection, s.subSubj+eventsSuffix, fn)
+}
+
+func subscribeNews(conn *nats.Conn, subj string,
+	fn func(entity.NewsEvent)) (unsubscribe func() error, err error) {
+	sub, err := conn.Subscribe(subj,
+		func(msg *nats.Msg) {
+			var e pb.NewsEvent
+
+			err := proto.Unmarshal(msg.Data, &e)
+			if err != nil {
+				return
+			}
+
+			n, err := pb.ToNews(e.News)
+			if err != nil {
+				return
+			}
+
+			fn(entity.NewsEvent{
+				Type: e.Type,
+				News: n,
+			})
+		})
+	if err != nil {
+		return nil, errors.New("failed to subscribe: " + err.Error())
+	}
+
+	return sub.Unsubscribe, nil
+}
@@ -0,0 +1,72 @@
+package nats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_SubscribeNews(t *testing.T) {
+	sm, s := initServer(t)
+	defer cleanServer(t, s)
+
+	c := initClient(t)
+	defer c.Close()
+
+	events := make(chan entity.NewsEvent, 3)
+
+	unsubscribe, err := c.SubscribeNews(func(e entity.NewsEvent) {
+		events <- e
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer unsubscribe()
+
+	n := entity.News{
+		ID:     1,
+		Header: "header",
+		Date:   time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	sm.On("CreateNews", entity.News{Header: n.Header, Date: n.Date}).Return(
+		n, nil)
+	sm.On("UpdateNews", n).Return(n, nil)
+	sm.On("DeleteNews", int64(1)).Return(nil)
+
+	_, err = c.CreateNews(context.TODO(), n)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = c.UpdateNews(context.TODO(), n)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, c.DeleteNews(context.TODO(), 1))
+
+	for _, want := range []entity.NewsEvent{
+		{Type: entity.NewsCreated, News: n},
+		{Type: entity.NewsUpdated, News: n},
+	} {
+		select {
+		case e := <-events:
+			assert.Equal(t, want, e)
+		case <-time.After(2 * time.Second):
+			t.Fatal("event isn't received")
+		}
+	}
+
+	// Deletion has no event.
+	select {
+	case e := <-events:
+		t.Errorf("unexpected event %v", e)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	sm.AssertExpectations(t)
+}
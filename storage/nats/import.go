@@ -0,0 +1,234 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/storage/pb"
+	"github.com/dimuls/news-storage/storage/service"
+	"github.com/golang/protobuf/proto"
+	"github.com/nats-io/nats.go"
+	"github.com/sirupsen/logrus"
+)
+
+// Imported news are uploaded by client in chunks, since they may not fit
+// in one NATS message. Client requests import and gets subject of
+// chunks in reply. Every chunk is request too, which server replies when
+// it's ready for the next one, so client can't send faster than news
+// are saved. The last chunk is replied with import result.
+
+var errNoChunk = errors.New("client didn't send chunk in time")
+
+// importHandler handles import request. Import can take long, so every
+// one runs in its own goroutine tracked as stream.
+func (s *Server) importHandler(msg *nats.Msg) {
+	s.streamsMu.Lock()
+	defer s.streamsMu.Unlock()
+
+	if s.stopped {
+		return
+	}
+
+	s.streams.Add(1)
+	go func() {
+		defer s.streams.Done()
+		s.importNews(msg)
+	}()
+}
+
+func (s *Server) importNews(msg *nats.Msg) {
+	ctx := s.msgContext(s.ctx, msg)
+	log := requestLog(s.log, ctx).WithField("subject",
+		s.subSubj+importSuffix)
+
+	enc := msgEncoding(msg)
+
+	var req pb.ImportNewsRequest
+
+	err := enc.unmarshal(msg.Data, &req)
+	if err != nil {
+		log.WithError(err).Error("failed to unmarshal request")
+		s.respond(log, msg, enc, &pb.ImportNewsResponse{Error: &pb.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to unmarshal request: " + err.Error(),
+		}})
+		return
+	}
+
+	imp, ok := s.storage.(service.Importer)
+	if !ok {
+		s.respond(log, msg, enc, &pb.ImportNewsResponse{Error: &pb.Error{
+			Code:    http.StatusNotImplemented,
+			Message: "storage doesn't support import",
+		}})
+		return
+	}
+
+	chunks, err := s.connection.SubscribeSync(nats.NewInbox())
+	if err != nil {
+		s.respond(log, msg, enc, &pb.ImportNewsResponse{
+			Error: s.responseError(log, errors.New(
+				"failed to subscribe to chunks: "+err.Error())),
+		})
+		return
+	}
+	defer chunks.Unsubscribe()
+
+	s.respond(log, msg, enc, &pb.ImportNewsResponse{
+		ChunkSubject: chunks.Subject,
+	})
+
+	r := &chunkReader{ctx: ctx, chunks: chunks, log: log, server: s}
+	src := service.NewChunkSource(r.next)
+
+	o := pb.ToImportOptions(&req)
+
+	res, err := imp.ImportNews(ctx, src, o)
+
+	switch src.Err() {
+	case nil:
+	case service.ErrImportCanceled, errNoChunk:
+		log.WithError(src.Err()).Debug("import is aborted")
+		return
+	default:
+		err = src.Err()
+	}
+
+	if err != nil {
+		r.reply(&pb.ImportNewsResponse{Error: s.responseError(log, err)})
+		return
+	}
+
+	if res.Committed {
+		s.writes.Add(ctx)
+		p, _ := entity.PrincipalFromContext(ctx)
+		log.WithFields(logrus.Fields{
+			"principal": p.Name,
+			"created":   res.Created,
+			"updated":   res.Updated,
+		}).Info("news imported")
+	}
+
+	r.reply(pb.FromImportResult(res))
+}
+
+// chunkReader receives chunks of import and replies them.
+type chunkReader struct {
+	ctx    context.Context
+	chunks *nats.Subscription
+	log    *logrus.Entry
+	server *Server
+
+	// msg is request of the last received chunk if it isn't replied yet.
+	msg *nats.Msg
+	// last tells whether the last chunk is received.
+	last bool
+}
+
+// next replies previous chunk, so that client sends the next one, and
+// receives it.
+func (r *chunkReader) next() (*pb.ImportChunk, error) {
+	if r.msg != nil {
+		r.reply(&pb.ImportNewsResponse{})
+	}
+
+	ctx, cancel := context.WithTimeout(r.ctx, streamAckTimeout)
+	defer cancel()
+
+	msg, err := r.chunks.NextMsgWithContext(ctx)
+	if err != nil {
+		if r.ctx.Err() == nil && ctx.Err() == context.DeadlineExceeded {
+			return nil, errNoChunk
+		}
+		return nil, errors.New("failed to receive chunk: " + err.Error())
+	}
+
+	r.msg = msg
+
+	var chunk pb.ImportChunk
+
+	err = msgEncoding(msg).unmarshal(msg.Data, &chunk)
+	if err != nil {
+		return nil, entity.ValidationError("failed to unmarshal chunk: " +
+			err.Error())
+	}
+
+	r.last = chunk.Last
+
+	return &chunk, nil
+}
+
+// reply replies the last received chunk with res. If import ended
+// before client was replied, the next chunk is waited for, since client
+// waits for reply before sending anything else.
+func (r *chunkReader) reply(res *pb.ImportNewsResponse) {
+	if r.msg == nil && !r.last {
+		ctx, cancel := context.WithTimeout(r.ctx, streamAckTimeout)
+		defer cancel()
+
+		msg, err := r.chunks.NextMsgWithContext(ctx)
+		if err != nil {
+			r.log.WithError(err).Debug("client didn't send chunk to reply")
+			return
+		}
+		r.msg = msg
+	}
+
+	if r.msg == nil || r.msg.Reply == "" {
+		return
+	}
+
+	r.server.respond(r.log, r.msg, msgEncoding(r.msg), res)
+	r.msg = nil
+}
+
+// ImportNews imports news read from src by storage of server with
+// options o. News are uploaded in chunks, invalid lines of src are
+// passed to server to be counted in result.
+func (c *Client) ImportNews(ctx context.Context, src entity.ImportSource,
+	o entity.ImportOptions) (entity.ImportResult, error) {
+	var res pb.ImportNewsResponse
+
+	startCtx, cancel := context.WithTimeout(ctx, streamAckTimeout)
+	defer cancel()
+
+	err := c.request(startCtx, c.subSubj+importSuffix,
+		pb.FromImportOptions(o), &res)
+	if err != nil {
+		return entity.ImportResult{}, err
+	}
+
+	subj := res.ChunkSubject
+	maxSize := int(c.connection.MaxPayload()) - chunkOverhead
+
+	err = service.SendChunks(src, maxSize, func(chunk *pb.ImportChunk) error {
+		// The last chunk is replied when import is done, which can take
+		// long, the others as soon as server reads them.
+		if chunk.Last {
+			return c.request(ctx, subj, chunk, &res)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, streamAckTimeout)
+		defer cancel()
+
+		return c.request(ctx, subj, chunk, &res)
+	})
+	if err != nil {
+		c.cancelImport(subj)
+		return entity.ImportResult{}, err
+	}
+
+	return pb.ToImportResult(&res), nil
+}
+
+// cancelImport asks server to abort import instead of waiting for the
+// next chunk. Failure is ignored, server aborts import by timeout then.
+func (c *Client) cancelImport(subj string) {
+	data, err := proto.Marshal(&pb.ImportChunk{Cancel: true})
+	if err != nil {
+		return
+	}
+	c.connection.Publish(subj, data)
+}
@@ -0,0 +1,187 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/storage/service"
+	"github.com/stretchr/testify/assert"
+)
+
+// sliceSource is import source of records and errors of lines, err is
+// returned after them.
+type sliceSource struct {
+	records []entity.ImportRecord
+	errs    map[int]error
+	err     error
+	i       int
+}
+
+func (s *sliceSource) Next() (entity.ImportRecord, error) {
+	if s.i == len(s.records) {
+		if s.err != nil {
+			return entity.ImportRecord{}, s.err
+		}
+		return entity.ImportRecord{}, io.EOF
+	}
+	s.i++
+	if err, ok := s.errs[s.i-1]; ok {
+		return entity.ImportRecord{}, err
+	}
+	return s.records[s.i-1], nil
+}
+
+// importStorage imports news to records and reports result of import to
+// results.
+type importStorage struct {
+	storageMock
+	options entity.ImportOptions
+	records []entity.ImportRecord
+	results chan error
+}
+
+func (s *importStorage) ImportNews(ctx context.Context,
+	src entity.ImportSource, o entity.ImportOptions) (
+	r entity.ImportResult, err error) {
+	defer func() { s.results <- err }()
+
+	s.options = o
+
+	for {
+		rec, err := src.Next()
+		if err == io.EOF {
+			break
+		}
+		if ie, ok := err.(*entity.ImportError); ok {
+			r.Total++
+			r.AddError(*ie)
+			continue
+		}
+		if err != nil {
+			return r, errors.New("failed to read news: " + err.Error())
+		}
+		r.Total++
+		r.Created++
+		s.records = append(s.records, rec)
+	}
+
+	r.Committed = !o.DryRun
+
+	return r, nil
+}
+
+func importRecords(n int) []entity.ImportRecord {
+	recs := make([]entity.ImportRecord, n)
+	for i := range recs {
+		recs[i] = entity.ImportRecord{
+			Line: i + 1,
+			News: entity.News{
+				Header:     "header " + strconv.Itoa(i),
+				Date:       time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC),
+				ExternalID: "ext-" + strconv.Itoa(i),
+			},
+		}
+	}
+	return recs
+}
+
+func TestClient_ImportNews(t *testing.T) {
+	is := &importStorage{results: make(chan error, 1)}
+
+	s := NewServer(is, testNATSURL, testSubject, 3*time.Second,
+		5*time.Second)
+	if !assert.NoError(t, s.Start()) {
+		return
+	}
+	defer s.Stop()
+
+	c := initClient(t)
+	defer c.Close()
+
+	// Records span several chunks, invalid lines are counted by server.
+	recs := importRecords(2500)
+	recs[20].News.Header = ""
+
+	src := &sliceSource{
+		records: recs,
+		errs: map[int]error{
+			10: &entity.ImportError{Line: 11, Message: "bad line"},
+		},
+	}
+
+	o := entity.ImportOptions{Atomic: true, Upsert: true}
+
+	res, err := c.ImportNews(context.TODO(), src, o)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, <-is.results)
+
+	assert.Equal(t, entity.ImportResult{
+		Total:     2500,
+		Created:   2498,
+		Failed:    2,
+		Committed: true,
+		Errors: []entity.ImportError{
+			{Line: 11, Message: "bad line"},
+			{Line: 21, Message: "header is required"},
+		},
+	}, res)
+	assert.Equal(t, o, is.options)
+
+	want := append(append([]entity.ImportRecord{}, recs[:10]...),
+		recs[11:20]...)
+	want = append(want, recs[21:]...)
+	assert.Equal(t, want, is.records)
+}
+
+func TestClient_ImportNews_sourceError(t *testing.T) {
+	is := &importStorage{results: make(chan error, 1)}
+
+	s := NewServer(is, testNATSURL, testSubject, 3*time.Second,
+		5*time.Second)
+	if !assert.NoError(t, s.Start()) {
+		return
+	}
+	defer s.Stop()
+
+	c := initClient(t)
+	defer c.Close()
+
+	src := &sliceSource{
+		records: importRecords(1500),
+		err:     errors.New("connection reset"),
+	}
+
+	_, err := c.ImportNews(context.TODO(), src, entity.ImportOptions{})
+	assert.Error(t, err)
+
+	// Import is canceled without waiting for chunk timeout.
+	select {
+	case err := <-is.results:
+		assert.Error(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("import isn't canceled")
+	}
+}
+
+func TestClient_ImportNews_unsupported(t *testing.T) {
+	_, s := initServer(t)
+	defer cleanServer(t, s)
+
+	c := initClient(t)
+	defer c.Close()
+
+	_, err := c.ImportNews(context.TODO(), &sliceSource{},
+		entity.ImportOptions{})
+	if assert.IsType(t, &service.ResponseError{}, err) {
+		assert.Equal(t, int64(http.StatusNotImplemented),
+			err.(*service.ResponseError).Code)
+	}
+}
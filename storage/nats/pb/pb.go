@@ -1,3 +0,0 @@
-package pb
-
-//go:generate sh -c "protoc --go_out=. *.proto"
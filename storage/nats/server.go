@@ -4,131 +4,389 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dimuls/news-storage/entity"
-	"github.com/dimuls/news-storage/storage/nats/pb"
+	"github.com/dimuls/news-storage/storage/pb"
+	"github.com/dimuls/news-storage/storage/service"
 	"github.com/golang/protobuf/proto"
 	"github.com/nats-io/nats.go"
 	"github.com/sirupsen/logrus"
 )
 
-type Storage interface {
-	News(ctx context.Context, id int64) (entity.News, error)
-}
+// Subjects of operations other than getting news by id are formed by
+// adding suffix to the server subject, e.g. "news.list" for "news".
+const (
+	batchSuffix  = ".batch"
+	daysSuffix   = ".days"
+	listSuffix   = ".list"
+	createSuffix = ".create"
+	updateSuffix = ".update"
+	deleteSuffix = ".delete"
+	exportSuffix = ".export"
+	importSuffix = ".import"
+	// eventsSuffix is subject of news events published by server.
+	eventsSuffix = ".events"
+)
 
 type Server struct {
-	storage Storage
-	natsURL string
-	subSubj string
+	// queryTimeout is time.Duration accessed atomically, because it can
+	// be changed while server is handling requests.
+	queryTimeout int64
+
+	storage      service.Storage
+	natsURL      string
+	subSubj      string
+	drainTimeout time.Duration
+	writes       *service.Writes
+	// trustPrincipal tells whether principal of request headers is
+	// trusted.
+	trustPrincipal bool
 
-	connection   *nats.Conn
-	subscription *nats.Subscription
+	connection    *nats.Conn
+	subscriptions []*nats.Subscription
+
+	// ctx is canceled on stop to abort streams which are tracked by
+	// streams. No streams are started after stopped is set.
+	ctx       context.Context
+	cancel    context.CancelFunc
+	streams   sync.WaitGroup
+	streamsMu sync.Mutex
+	stopped   bool
 
 	log *logrus.Entry
 }
 
-func NewServer(s Storage, natsURL, subSubj string) *Server {
+// NewServer creates server which handles requests from subSubj and its
+// operation subjects. Every request is given queryTimeout to get its
+// result from storage.
+func NewServer(s service.Storage, natsURL, subSubj string, queryTimeout,
+	drainTimeout time.Duration) *Server {
 	return &Server{
-		storage: s,
-		natsURL: natsURL,
-		subSubj: subSubj,
-		log:     logrus.WithField("subsystem", "nats_server"),
+		queryTimeout: int64(queryTimeout),
+		storage:      s,
+		natsURL:      natsURL,
+		subSubj:      subSubj,
+		drainTimeout: drainTimeout,
+		writes:       service.NewWrites(0),
+		log:          logrus.WithField("subsystem", "nats_server"),
 	}
 }
 
+// SetQueryTimeout changes query timeout of requests handled from now on.
+func (s *Server) SetQueryTimeout(d time.Duration) {
+	atomic.StoreInt64(&s.queryTimeout, int64(d))
+}
+
+// SetTrustPrincipalHeaders makes server trust principal of request
+// headers, requests are anonymous otherwise. It must be enabled only if
+// NATS allows only clients which authenticate principal to publish to
+// server subjects. It must be called before Start.
+func (s *Server) SetTrustPrincipalHeaders(trust bool) {
+	s.trustPrincipal = trust
+}
+
+// SetPrimaryReadWindow makes reads of principal within d after its write
+// be made from primary, so that principal sees the write while replicas
+// catch up. It is disabled by zero d.
+func (s *Server) SetPrimaryReadWindow(d time.Duration) {
+	s.writes.SetWindow(d)
+}
+
+// operation is a request handled on its own subject.
+type operation struct {
+	subject    string
+	newRequest func() proto.Message
+	handle     func(ctx context.Context, req proto.Message) (proto.Message, error)
+	// write operations are logged with principal who made them.
+	write bool
+	// event is type of news event published after successful operation.
+	// Response of such operation must be *pb.GetNewsResponse.
+	event string
+	// errorResponse returns response of operation with error e.
+	errorResponse func(e *pb.Error) proto.Message
+}
+
+func (s *Server) operations() []operation {
+	getErrorResponse := func(e *pb.Error) proto.Message {
+		return &pb.GetNewsResponse{Error: e}
+	}
+	return []operation{{
+		subject:       s.subSubj,
+		newRequest:    func() proto.Message { return &pb.GetNewsRequest{} },
+		handle:        s.getNews,
+		errorResponse: getErrorResponse,
+	}, {
+		subject:    s.subSubj + batchSuffix,
+		newRequest: func() proto.Message { return &pb.BatchGetNewsRequest{} },
+		handle:     s.batchGetNews,
+		errorResponse: func(e *pb.Error) proto.Message {
+			return &pb.BatchGetNewsResponse{Error: e}
+		},
+	}, {
+		subject:    s.subSubj + daysSuffix,
+		newRequest: func() proto.Message { return &pb.NewsDaysRequest{} },
+		handle:     s.newsDays,
+		errorResponse: func(e *pb.Error) proto.Message {
+			return &pb.NewsDaysResponse{Error: e}
+		},
+	}, {
+		subject:       s.subSubj + createSuffix,
+		newRequest:    func() proto.Message { return &pb.CreateNewsRequest{} },
+		handle:        s.createNews,
+		write:         true,
+		event:         entity.NewsCreated,
+		errorResponse: getErrorResponse,
+	}, {
+		subject:       s.subSubj + updateSuffix,
+		newRequest:    func() proto.Message { return &pb.UpdateNewsRequest{} },
+		handle:        s.updateNews,
+		write:         true,
+		event:         entity.NewsUpdated,
+		errorResponse: getErrorResponse,
+	}, {
+		subject:    s.subSubj + deleteSuffix,
+		newRequest: func() proto.Message { return &pb.DeleteNewsRequest{} },
+		handle:     s.deleteNews,
+		write:      true,
+		errorResponse: func(e *pb.Error) proto.Message {
+			return &pb.DeleteNewsResponse{Error: e}
+		},
+	}}
+}
+
 func (s *Server) Start() error {
-	conn, err := nats.Connect(s.natsURL, nats.DrainTimeout(5*time.Second))
+	conn, err := nats.Connect(s.natsURL, nats.DrainTimeout(s.drainTimeout))
 	if err != nil {
 		return errors.New("failed to connect to nats: " + err.Error())
 	}
 
-	sub, err := conn.Subscribe(s.subSubj, s.msgHandler)
+	// Handlers use connection and context from the first message, so
+	// they are set before subscribing.
+	s.connection = conn
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+
+	var subs []*nats.Subscription
+
+	for _, op := range s.operations() {
+		sub, err := conn.Subscribe(op.subject, s.msgHandler(op))
+		if err != nil {
+			s.cancel()
+			conn.Close()
+			return errors.New("failed to subscribe to " + op.subject +
+				": " + err.Error())
+		}
+		subs = append(subs, sub)
+	}
+
+	for _, op := range s.streamOperations() {
+		sub, err := conn.Subscribe(op.subject, s.streamHandler(op))
+		if err != nil {
+			s.cancel()
+			conn.Close()
+			return errors.New("failed to subscribe to " + op.subject +
+				": " + err.Error())
+		}
+		subs = append(subs, sub)
+	}
+
+	sub, err := conn.Subscribe(s.subSubj+importSuffix, s.importHandler)
 	if err != nil {
+		s.cancel()
 		conn.Close()
-		return errors.New("failed to subscribe: " + err.Error())
+		return errors.New("failed to subscribe to " + s.subSubj +
+			importSuffix + ": " + err.Error())
 	}
 
-	s.connection = conn
-	s.subscription = sub
+	s.subscriptions = append(subs, sub)
 
 	return nil
 }
 
 func (s *Server) Stop() {
-	err := s.subscription.Unsubscribe()
-	if err != nil {
-		s.log.WithError(err).Error("failed to unsubscribe")
+	for _, sub := range s.subscriptions {
+		err := sub.Unsubscribe()
+		if err != nil {
+			s.log.WithError(err).WithField("subject", sub.Subject).Error(
+				"failed to unsubscribe")
+		}
 	}
 
+	s.streamsMu.Lock()
+	s.stopped = true
+	s.streamsMu.Unlock()
+
+	s.cancel()
+	s.streams.Wait()
+
 	s.connection.Close()
 }
 
-func (s *Server) msgHandler(msg *nats.Msg) {
-	var req pb.GetNewsRequest
+func (s *Server) msgHandler(op operation) nats.MsgHandler {
+	return func(msg *nats.Msg) {
+		ctx, cancel := context.WithTimeout(
+			s.writes.ReadContext(s.msgContext(context.Background(), msg)),
+			time.Duration(atomic.LoadInt64(&s.queryTimeout)))
+		defer cancel()
 
-	err := proto.Unmarshal(msg.Data, &req)
-	if err != nil {
-		s.log.WithError(err).Error("failed to unmarshal request")
-		s.respondWithError(msg, http.StatusBadRequest,
-			"failed to unmarshal request: "+err.Error())
-		return
-	}
+		// Every log line of request has its id.
+		log := requestLog(s.log, ctx).WithField("subject", op.subject)
 
-	ctx, cancel := context.WithTimeout(context.TODO(), 3*time.Second)
-	defer cancel()
+		req := op.newRequest()
+		enc := msgEncoding(msg)
 
-	news, err := s.storage.News(ctx, req.Id)
-	if err != nil {
-		if err == entity.ErrNewsNotFound {
-			s.respondWithError(msg, http.StatusNotFound, err.Error())
+		err := enc.unmarshal(msg.Data, req)
+		if err != nil {
+			log.WithError(err).Error("failed to unmarshal request")
+			s.respond(log, msg, enc, op.errorResponse(&pb.Error{
+				Code:    http.StatusBadRequest,
+				Message: "failed to unmarshal request: " + err.Error(),
+			}))
 			return
 		}
-		s.log.WithError(err).Error("failed to get news from storage")
-		s.respondWithError(msg, http.StatusInternalServerError,
-			"internal server error")
-		return
+
+		res, err := op.handle(ctx, req)
+		if err != nil {
+			res = op.errorResponse(s.responseError(log, err))
+		} else if op.write {
+			s.writes.Add(ctx)
+			p, _ := entity.PrincipalFromContext(ctx)
+			log.WithField("principal", p.Name).Info("news changed")
+		}
+
+		s.respond(log, msg, enc, res)
+
+		if err == nil && op.event != "" {
+			s.publishEvent(op.event, res.(*pb.GetNewsResponse).News)
+		}
 	}
+}
 
-	res := &pb.GetNewsResponse{
-		News: &pb.News{
-			Id:     news.ID,
-			Header: news.Header,
-			Date:   news.Date.UTC().Format("2006-01-02"),
-		},
+// msgContext returns ctx with principal and request id from msg headers
+// if any. Principal is anonymous if headers aren't trusted, since any
+// NATS client could claim any principal otherwise.
+func (s *Server) msgContext(ctx context.Context,
+	msg *nats.Msg) context.Context {
+	var name, role string
+
+	if msg.Header != nil {
+		if id := msg.Header.Get(requestIDHeader); id != "" {
+			ctx = entity.ContextWithRequestID(ctx, id)
+		}
+		name = msg.Header.Get(principalHeader)
+		role = msg.Header.Get(principalRoleHeader)
 	}
 
-	resBytes, err := proto.Marshal(res)
+	if !s.trustPrincipal || name == "" {
+		return entity.ContextWithPrincipal(ctx, entity.Anonymous)
+	}
+
+	// Unknown role is kept as zero, which has no access.
+	r, _ := entity.ParseRole(role)
+
+	return entity.ContextWithPrincipal(ctx, entity.Principal{
+		Name: name,
+		Role: r,
+	})
+}
+
+// requestLog returns log with id of request of ctx if any.
+func requestLog(log *logrus.Entry, ctx context.Context) *logrus.Entry {
+	if id, ok := entity.RequestIDFromContext(ctx); ok {
+		return log.WithField("request_id", id)
+	}
+	return log
+}
+
+// responseError converts handling error to error sent to client.
+// Internal errors are logged to log of request and hidden from clients.
+func (s *Server) responseError(log *logrus.Entry, err error) *pb.Error {
+	if err == entity.ErrNewsNotFound {
+		return &pb.Error{
+			Code:    http.StatusNotFound,
+			Message: err.Error(),
+		}
+	}
+
+	if _, ok := err.(entity.ValidationError); ok {
+		return &pb.Error{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	log.WithError(err).Error("failed to handle request")
+
+	return &pb.Error{
+		Code:    http.StatusInternalServerError,
+		Message: "internal server error",
+	}
+}
+
+// respond responds to msg with res in encoding enc of request. Failures
+// are logged to log of request.
+func (s *Server) respond(log *logrus.Entry, msg *nats.Msg, enc encoding,
+	res proto.Message) {
+	resBytes, err := enc.marshal(res)
 	if err != nil {
-		s.log.WithError(err).Error("failed to marshal response")
-		s.respondWithError(msg, http.StatusInternalServerError,
-			"internal server error")
+		log.WithError(err).Error("failed to marshal response")
 		return
 	}
 
-	err = msg.Respond(resBytes)
+	err = msg.RespondMsg(enc.newMsg(msg.Reply, resBytes))
 	if err != nil {
-		s.log.WithError(err).Error("failed to respond: " + err.Error())
+		log.WithError(err).Error("failed to respond to message")
 	}
 }
 
-func (s *Server) respondWithError(msg *nats.Msg, errCode int, errMsg string) {
-	res := &pb.GetNewsResponse{
-		Error: &pb.Error{
-			Code:    int64(errCode),
-			Message: "failed to unmarshal request: " + errMsg,
-		},
+func (s *Server) getNews(ctx context.Context, req proto.Message) (
+	proto.Message, error) {
+	news, err := s.storage.News(ctx, req.(*pb.GetNewsRequest).Id)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetNewsResponse{News: pb.FromNews(news)}, nil
+}
+
+func (s *Server) createNews(ctx context.Context, req proto.Message) (
+	proto.Message, error) {
+	news, err := service.RequestNews(req.(*pb.CreateNewsRequest).News)
+	if err != nil {
+		return nil, err
 	}
 
-	resBytes, err := proto.Marshal(res)
+	news.ID = 0
+
+	news, err = s.storage.CreateNews(ctx, news)
 	if err != nil {
-		s.log.WithError(err).Error(
-			"failed to marshal error response")
-		return
+		return nil, err
 	}
 
-	err = msg.Respond(resBytes)
+	return &pb.GetNewsResponse{News: pb.FromNews(news)}, nil
+}
+
+func (s *Server) updateNews(ctx context.Context, req proto.Message) (
+	proto.Message, error) {
+	news, err := service.RequestNews(req.(*pb.UpdateNewsRequest).News)
+	if err != nil {
+		return nil, err
+	}
+
+	news, err = s.storage.UpdateNews(ctx, news)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.GetNewsResponse{News: pb.FromNews(news)}, nil
+}
+
+func (s *Server) deleteNews(ctx context.Context, req proto.Message) (
+	proto.Message, error) {
+	err := s.storage.DeleteNews(ctx, req.(*pb.DeleteNewsRequest).Id)
 	if err != nil {
-		s.log.WithError(err).Error("failed to respond to message")
+		return nil, err
 	}
+	return &pb.DeleteNewsResponse{}, nil
 }
@@ -3,6 +3,7 @@ package nats
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
@@ -10,16 +11,40 @@ import (
 	"time"
 
 	"github.com/dimuls/news-storage/entity"
-	"github.com/dimuls/news-storage/storage/nats/pb"
+	"github.com/dimuls/news-storage/storage/pb"
+	"github.com/dimuls/news-storage/storage/service"
+	"github.com/golang/protobuf/jsonpb"
 	"github.com/golang/protobuf/proto"
+	"github.com/nats-io/nats.go"
 	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
+const testSubject = "test.news"
+
+var testNATSURL string
+
 func TestMain(m *testing.M) {
 	logrus.SetOutput(ioutil.Discard)
-	os.Exit(m.Run())
+
+	ns := NewEmbeddedServer("127.0.0.1:0")
+
+	err := ns.Start()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to start embedded nats server: "+
+			err.Error())
+		os.Exit(1)
+	}
+
+	testNATSURL = ns.URL()
+
+	code := m.Run()
+
+	ns.Stop()
+
+	os.Exit(code)
 }
 
 type storageMock struct {
@@ -31,10 +56,57 @@ func (s *storageMock) News(ctx context.Context, id int64) (entity.News, error) {
 	return args.Get(0).(entity.News), args.Error(1)
 }
 
+func (s *storageMock) NewsByIDs(ctx context.Context, ids []int64) (
+	[]entity.News, error) {
+	args := s.Called(ids)
+	return args.Get(0).([]entity.News), args.Error(1)
+}
+
+func (s *storageMock) NewsDays(ctx context.Context) ([]entity.NewsDay,
+	error) {
+	args := s.Called()
+	return args.Get(0).([]entity.NewsDay), args.Error(1)
+}
+
+func (s *storageMock) ListNews(ctx context.Context, f entity.NewsFilter) (
+	[]entity.News, error) {
+	args := s.Called(f)
+	return args.Get(0).([]entity.News), args.Error(1)
+}
+
+func (s *storageMock) CreateNews(ctx context.Context, n entity.News) (
+	entity.News, error) {
+	args := s.Called(n)
+	return args.Get(0).(entity.News), args.Error(1)
+}
+
+func (s *storageMock) UpdateNews(ctx context.Context, n entity.News) (
+	entity.News, error) {
+	args := s.Called(n)
+	return args.Get(0).(entity.News), args.Error(1)
+}
+
+func (s *storageMock) DeleteNews(ctx context.Context, id int64) error {
+	args := s.Called(id)
+	return args.Error(0)
+}
+
+func (s *storageMock) ExportNews(ctx context.Context, f entity.NewsFilter,
+	fn func(entity.News) error) error {
+	args := s.Called(f)
+	for _, n := range args.Get(0).([]entity.News) {
+		err := fn(n)
+		if err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
 func initServer(t *testing.T) (*storageMock, *Server) {
 	sm := &storageMock{}
-	s := NewServer(sm, os.Getenv("TEST_NATS_URL"),
-		os.Getenv("TEST_SUBSCRIBE_SUBJECT"))
+	s := NewServer(sm, testNATSURL, testSubject, 3*time.Second,
+		5*time.Second)
 	err := s.Start()
 	if err != nil {
 		t.Fatal("failed to start replier: " + err.Error())
@@ -124,3 +196,413 @@ func TestReplier_msgHandler_success(t *testing.T) {
 	assert.Equal(t, wantN.Header, res.News.Header)
 	assert.Equal(t, wantN.Date.UTC().Format("2016-01-02"), res.News.Date)
 }
+
+func request(t *testing.T, s *Server, subj string, req proto.Message,
+	res proto.Message) {
+	reqBytes, err := proto.Marshal(req)
+	if err != nil {
+		t.Fatal("failed to marshal request: " + err.Error())
+	}
+
+	resMsg, err := s.connection.Request(subj, reqBytes, 1*time.Second)
+	if err != nil {
+		t.Fatal("failed to request: " + err.Error())
+	}
+
+	err = proto.Unmarshal(resMsg.Data, res)
+	if err != nil {
+		t.Fatal("failed to unmarshal response: " + err.Error())
+	}
+}
+
+func TestServer_listNews(t *testing.T) {
+	sm, s := initServer(t)
+	defer cleanServer(t, s)
+
+	c := initClient(t)
+	defer c.Close()
+
+	wantNs := []entity.News{{
+		ID:     1,
+		Header: "header",
+		Date:   time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+	}}
+
+	sm.On("ListNews", entity.NewsFilter{
+		From:  time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:    time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC).Add(-1),
+		Query: "query",
+		Limit: service.DefaultListLimit,
+	}).Return(wantNs, nil)
+
+	ns, err := c.ListNews(context.TODO(), entity.NewsFilter{
+		From:  time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:    time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+		Query: "query",
+	})
+
+	sm.AssertExpectations(t)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, wantNs, ns)
+	}
+}
+
+func TestServer_listNews_invalid(t *testing.T) {
+	_, s := initServer(t)
+	defer cleanServer(t, s)
+
+	c := initClient(t)
+	defer c.Close()
+
+	it, err := c.stream(context.TODO(), c.subSubj+listSuffix,
+		&pb.ListNewsRequest{From: "yesterday"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.False(t, it.Next())
+	assert.IsType(t, entity.ValidationError(""), it.Err())
+}
+
+func TestServer_createNews(t *testing.T) {
+	sm, s := initServer(t)
+	defer cleanServer(t, s)
+
+	testDate := time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	sm.On("CreateNews", entity.News{
+		Header: "header",
+		Date:   testDate,
+	}).Return(entity.News{
+		ID:     1,
+		Header: "header",
+		Date:   testDate,
+	}, nil)
+
+	var res pb.GetNewsResponse
+
+	request(t, s, s.subSubj+createSuffix, &pb.CreateNewsRequest{
+		News: &pb.News{Id: 5, Header: "header", Date: "2019-01-02"},
+	}, &res)
+
+	sm.AssertExpectations(t)
+
+	assert.Nil(t, res.Error)
+	assert.Equal(t, &pb.News{Id: 1, Header: "header", Date: "2019-01-02",
+		PublishedAt: "2019-01-02T00:00:00Z"}, res.News)
+}
+
+func TestServer_createNews_invalid(t *testing.T) {
+	_, s := initServer(t)
+	defer cleanServer(t, s)
+
+	var res pb.GetNewsResponse
+
+	request(t, s, s.subSubj+createSuffix, &pb.CreateNewsRequest{
+		News: &pb.News{Date: "2019-01-02"},
+	}, &res)
+
+	if assert.NotNil(t, res.Error) {
+		assert.Equal(t, int64(http.StatusBadRequest), res.Error.Code)
+		assert.Equal(t, "header is required", res.Error.Message)
+	}
+}
+
+func TestServer_updateNews_notFound(t *testing.T) {
+	sm, s := initServer(t)
+	defer cleanServer(t, s)
+
+	n := entity.News{
+		ID:     1,
+		Header: "header",
+		Date:   time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	sm.On("UpdateNews", n).Return(entity.News{}, entity.ErrNewsNotFound)
+
+	var res pb.GetNewsResponse
+
+	request(t, s, s.subSubj+updateSuffix, &pb.UpdateNewsRequest{
+		News: &pb.News{Id: 1, Header: "header", Date: "2019-01-02"},
+	}, &res)
+
+	sm.AssertExpectations(t)
+
+	if assert.NotNil(t, res.Error) {
+		assert.Equal(t, int64(http.StatusNotFound), res.Error.Code)
+	}
+}
+
+func TestServer_deleteNews(t *testing.T) {
+	sm, s := initServer(t)
+	defer cleanServer(t, s)
+
+	sm.On("DeleteNews", int64(1)).Return(nil)
+	sm.On("DeleteNews", int64(2)).Return(errors.New("error"))
+
+	var res pb.DeleteNewsResponse
+
+	request(t, s, s.subSubj+deleteSuffix, &pb.DeleteNewsRequest{Id: 1}, &res)
+	assert.Nil(t, res.Error)
+
+	request(t, s, s.subSubj+deleteSuffix, &pb.DeleteNewsRequest{Id: 2}, &res)
+	if assert.NotNil(t, res.Error) {
+		assert.Equal(t, int64(http.StatusInternalServerError), res.Error.Code)
+		assert.Equal(t, "internal server error", res.Error.Message)
+	}
+
+	sm.AssertExpectations(t)
+}
+
+// requestJSON sends JSON request to subj and returns response.
+func requestJSON(t *testing.T, s *Server, subj, req string) *nats.Msg {
+	msg := nats.NewMsg(subj)
+	msg.Header.Set("Content-Type", "application/json")
+	msg.Data = []byte(req)
+
+	res, err := s.connection.RequestMsg(msg, 1*time.Second)
+	if err != nil {
+		t.Fatal("failed to request: " + err.Error())
+	}
+
+	return res
+}
+
+func TestServer_json(t *testing.T) {
+	sm, s := initServer(t)
+	defer cleanServer(t, s)
+
+	sm.On("News", int64(1)).Return(entity.News{
+		ID:         1,
+		Header:     "header",
+		Date:       time.Date(2019, 1, 2, 12, 30, 0, 0, time.UTC),
+		ExternalID: "ext",
+	}, nil)
+
+	res := requestJSON(t, s, s.subSubj, `{"id":"1"}`)
+
+	sm.AssertExpectations(t)
+
+	assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+	assert.JSONEq(t, `{"news":{"id":"1","header":"header",`+
+		`"date":"2019-01-02","externalId":"ext",`+
+		`"publishedAt":"2019-01-02T12:30:00Z"}}`, string(res.Data))
+
+	// Protobuf is default and isn't marked by header.
+	var pbRes pb.GetNewsResponse
+
+	request(t, s, s.subSubj, &pb.GetNewsRequest{Id: 1}, &pbRes)
+	assert.Equal(t, "ext", pbRes.News.ExternalId)
+}
+
+func TestServer_json_malformed(t *testing.T) {
+	_, s := initServer(t)
+	defer cleanServer(t, s)
+
+	res := requestJSON(t, s, s.subSubj+deleteSuffix, `{"id":`)
+
+	assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+
+	var delRes pb.DeleteNewsResponse
+
+	err := jsonpb.UnmarshalString(string(res.Data), &delRes)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	if assert.NotNil(t, delRes.Error) {
+		assert.Equal(t, int64(http.StatusBadRequest), delRes.Error.Code)
+	}
+}
+
+func TestServer_json_stream(t *testing.T) {
+	sm, s := initServer(t)
+	defer cleanServer(t, s)
+
+	sm.On("ListNews", entity.NewsFilter{
+		Query: "query",
+		Limit: 1,
+	}).Return([]entity.News{{
+		ID:     1,
+		Header: "header",
+		Date:   time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+	}}, nil)
+
+	res := requestJSON(t, s, s.subSubj+listSuffix,
+		`{"query":"query","limit":"1"}`)
+
+	sm.AssertExpectations(t)
+
+	assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+	assert.JSONEq(t, `{"news":[{"id":"1","header":"header",`+
+		`"date":"2019-01-02","publishedAt":"2019-01-02T00:00:00Z"}],`+
+		`"last":true}`, string(res.Data))
+}
+
+// principalStorage remembers principal of the last deleting request.
+type principalStorage struct {
+	storageMock
+	principal chan entity.Principal
+}
+
+func (s *principalStorage) DeleteNews(ctx context.Context, id int64) error {
+	p, _ := entity.PrincipalFromContext(ctx)
+	s.principal <- p
+	return nil
+}
+
+func TestServer_principal(t *testing.T) {
+	ps := &principalStorage{principal: make(chan entity.Principal, 1)}
+
+	s := NewServer(ps, testNATSURL, testSubject, 3*time.Second,
+		5*time.Second)
+	s.SetTrustPrincipalHeaders(true)
+	if !assert.NoError(t, s.Start()) {
+		return
+	}
+	defer cleanServer(t, s)
+
+	c := initClient(t)
+	defer c.Close()
+
+	want := entity.Principal{Name: "alice", Role: entity.RoleEditor}
+
+	err := c.DeleteNews(entity.ContextWithPrincipal(context.TODO(), want), 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, want, <-ps.principal)
+	}
+
+	err = c.DeleteNews(context.TODO(), 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, entity.Anonymous, <-ps.principal)
+	}
+}
+
+func TestServer_principal_untrusted(t *testing.T) {
+	ps := &principalStorage{principal: make(chan entity.Principal, 1)}
+
+	s := NewServer(ps, testNATSURL, testSubject, 3*time.Second,
+		5*time.Second)
+	if !assert.NoError(t, s.Start()) {
+		return
+	}
+	defer cleanServer(t, s)
+
+	c := initClient(t)
+	defer c.Close()
+
+	// Any NATS client can set headers, so they aren't trusted by default.
+	ctx := entity.ContextWithPrincipal(context.TODO(), entity.Principal{
+		Name: "alice",
+		Role: entity.RoleAdmin,
+	})
+
+	err := c.DeleteNews(ctx, 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, entity.Anonymous, <-ps.principal)
+	}
+}
+
+// primaryStorage remembers whether the last getting request required
+// read from primary.
+type primaryStorage struct {
+	storageMock
+	primary chan bool
+}
+
+func (s *primaryStorage) News(ctx context.Context, id int64) (entity.News,
+	error) {
+	s.primary <- entity.PrimaryReadFromContext(ctx)
+	return entity.News{ID: id, Header: "header", Date: time.Now()}, nil
+}
+
+func (s *primaryStorage) DeleteNews(ctx context.Context, id int64) error {
+	return nil
+}
+
+func TestServer_primaryRead(t *testing.T) {
+	ps := &primaryStorage{primary: make(chan bool, 1)}
+
+	s := NewServer(ps, testNATSURL, testSubject, 3*time.Second,
+		5*time.Second)
+	s.SetPrimaryReadWindow(time.Minute)
+	s.SetTrustPrincipalHeaders(true)
+	if !assert.NoError(t, s.Start()) {
+		return
+	}
+	defer cleanServer(t, s)
+
+	c := initClient(t)
+	defer c.Close()
+
+	alice := entity.ContextWithPrincipal(context.TODO(),
+		entity.Principal{Name: "alice", Role: entity.RoleEditor})
+	bob := entity.ContextWithPrincipal(context.TODO(),
+		entity.Principal{Name: "bob", Role: entity.RoleEditor})
+
+	_, err := c.News(alice, 1)
+	if assert.NoError(t, err) {
+		assert.False(t, <-ps.primary)
+	}
+
+	assert.NoError(t, c.DeleteNews(alice, 1))
+
+	_, err = c.News(alice, 1)
+	if assert.NoError(t, err) {
+		assert.True(t, <-ps.primary)
+	}
+
+	_, err = c.News(bob, 1)
+	if assert.NoError(t, err) {
+		assert.False(t, <-ps.primary)
+	}
+}
+
+// requestIDStorage remembers request id of the last deleting request and
+// fails it.
+type requestIDStorage struct {
+	storageMock
+	requestID chan string
+}
+
+func (s *requestIDStorage) DeleteNews(ctx context.Context, id int64) error {
+	rid, _ := entity.RequestIDFromContext(ctx)
+	s.requestID <- rid
+	return errors.New("db is down")
+}
+
+func TestServer_requestID(t *testing.T) {
+	hook := test.NewGlobal()
+	defer logrus.StandardLogger().ReplaceHooks(logrus.LevelHooks{})
+
+	rs := &requestIDStorage{requestID: make(chan string, 1)}
+
+	s := NewServer(rs, testNATSURL, testSubject, 3*time.Second,
+		5*time.Second)
+	if !assert.NoError(t, s.Start()) {
+		return
+	}
+	defer cleanServer(t, s)
+
+	c := initClient(t)
+	defer c.Close()
+
+	ctx := entity.ContextWithRequestID(context.TODO(), "req-1")
+
+	err := c.DeleteNews(ctx, 1)
+	assert.Error(t, err)
+	assert.Equal(t, "req-1", <-rs.requestID)
+
+	// Error is logged before response is sent.
+	e := hook.LastEntry()
+	if assert.NotNil(t, e) {
+		assert.Equal(t, "failed to handle request", e.Message)
+		assert.Equal(t, "req-1", e.Data["request_id"])
+		assert.Equal(t, testSubject+deleteSuffix, e.Data["subject"])
+	}
+
+	err = c.DeleteNews(context.TODO(), 1)
+	assert.Error(t, err)
+	assert.Equal(t, "", <-rs.requestID)
+}
@@ -0,0 +1,515 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/storage/pb"
+	"github.com/dimuls/news-storage/storage/service"
+	"github.com/golang/protobuf/proto"
+	"github.com/nats-io/nats.go"
+)
+
+// Results which may not fit in one NATS message are streamed: server
+// sends sequenced news chunks to reply subject, the last one is marked
+// and carries error if any. Server sends at most streamWindow chunks
+// which aren't acknowledged by client yet, so slow clients aren't
+// flooded, and aborts stream if client doesn't acknowledge chunk in
+// streamAckTimeout.
+const (
+	streamWindow     = 8
+	streamAckTimeout = 30 * time.Second
+	// maxChunkNews is maximum number of news in one chunk.
+	maxChunkNews = 1000
+	// chunkOverhead is reserved for chunk fields other than news.
+	chunkOverhead = 256
+	// DefaultStreamIdleTimeout is time client waits for the next chunk.
+	// It's longer than ack timeout, since server may wait for storage
+	// between chunks.
+	DefaultStreamIdleTimeout = 2 * streamAckTimeout
+)
+
+// streamOperation is a request replied with stream of news.
+type streamOperation struct {
+	subject    string
+	newRequest func() proto.Message
+	// stream calls fn for every news of reply.
+	stream func(ctx context.Context, req proto.Message,
+		fn func(entity.News) error) error
+}
+
+func (s *Server) streamOperations() []streamOperation {
+	return []streamOperation{{
+		subject:    s.subSubj + listSuffix,
+		newRequest: func() proto.Message { return &pb.ListNewsRequest{} },
+		stream:     s.listNews,
+	}, {
+		subject:    s.subSubj + exportSuffix,
+		newRequest: func() proto.Message { return &pb.ExportNewsRequest{} },
+		stream:     s.exportNews,
+	}}
+}
+
+// streamHandler returns handler which streams reply of op. Streams can
+// take long, so every one runs in its own goroutine.
+func (s *Server) streamHandler(op streamOperation) nats.MsgHandler {
+	return func(msg *nats.Msg) {
+		s.streamsMu.Lock()
+		defer s.streamsMu.Unlock()
+
+		if s.stopped {
+			return
+		}
+
+		s.streams.Add(1)
+		go func() {
+			defer s.streams.Done()
+			s.stream(op, msg)
+		}()
+	}
+}
+
+func (s *Server) stream(op streamOperation, msg *nats.Msg) {
+	ctx := s.writes.ReadContext(s.msgContext(s.ctx, msg))
+	log := requestLog(s.log, ctx).WithField("subject", op.subject)
+
+	enc := msgEncoding(msg)
+
+	sw, err := newStreamWriter(ctx, s.connection, msg.Reply, enc)
+	if err != nil {
+		log.WithError(err).Error("failed to start stream")
+		return
+	}
+
+	req := op.newRequest()
+
+	err = enc.unmarshal(msg.Data, req)
+	if err != nil {
+		log.WithError(err).Error("failed to unmarshal request")
+		err = entity.ValidationError("failed to unmarshal request: " +
+			err.Error())
+	} else {
+		err = op.stream(ctx, req, sw.add)
+	}
+
+	var e *pb.Error
+
+	switch err {
+	case nil:
+	case errStreamCanceled, errNoAck, errServerStopped:
+		log.WithError(err).Debug("stream is aborted")
+		sw.close()
+		return
+	default:
+		e = s.responseError(log, err)
+	}
+
+	err = sw.finish(e)
+	switch err {
+	case nil:
+	case errStreamCanceled, errNoAck, errServerStopped:
+		log.WithError(err).Debug("stream is aborted")
+	default:
+		log.WithError(err).Error("failed to finish stream")
+	}
+}
+
+func (s *Server) listNews(ctx context.Context, req proto.Message,
+	fn func(entity.News) error) error {
+	return service.ListNews(ctx, s.storage,
+		time.Duration(atomic.LoadInt64(&s.queryTimeout)),
+		req.(*pb.ListNewsRequest), fn)
+}
+
+func (s *Server) exportNews(ctx context.Context, req proto.Message,
+	fn func(entity.News) error) error {
+	return service.ExportNews(ctx, s.storage, req.(*pb.ExportNewsRequest), fn)
+}
+
+var (
+	errStreamCanceled = errors.New("stream is canceled by client")
+	errNoAck          = errors.New("client didn't acknowledge chunk in time")
+	errServerStopped  = errors.New("server is stopped")
+	errNoChunkSent    = errors.New("server didn't send chunk in time")
+)
+
+// streamWriter collects news to chunks and publishes them to reply
+// subject keeping window of not acknowledged chunks. Chunks are encoded
+// as request. Waiting for acks is aborted when ctx is done.
+type streamWriter struct {
+	ctx        context.Context
+	connection *nats.Conn
+	subject    string
+	encoding   encoding
+	acks       *nats.Subscription
+	maxSize    int
+
+	chunk pb.NewsChunk
+	size  int
+	// acked is number of acknowledged chunks.
+	acked int64
+}
+
+func newStreamWriter(ctx context.Context, conn *nats.Conn, subject string,
+	enc encoding) (*streamWriter, error) {
+	if subject == "" {
+		return nil, errors.New("request has no reply subject")
+	}
+
+	acks, err := conn.SubscribeSync(nats.NewInbox())
+	if err != nil {
+		return nil, errors.New("failed to subscribe to acks: " +
+			err.Error())
+	}
+
+	return &streamWriter{
+		ctx:        ctx,
+		connection: conn,
+		subject:    subject,
+		encoding:   enc,
+		acks:       acks,
+		maxSize:    int(conn.MaxPayload()) - chunkOverhead,
+	}, nil
+}
+
+func (w *streamWriter) add(n entity.News) error {
+	pbN := pb.FromNews(n)
+	size := w.encoding.elementSize(pbN)
+
+	// Such news can't be published even in its own chunk.
+	if size > w.maxSize {
+		return errors.New("news " + strconv.FormatInt(n.ID, 10) +
+			" exceeds chunk size")
+	}
+
+	if len(w.chunk.News) > 0 && (w.size+size > w.maxSize ||
+		len(w.chunk.News) == maxChunkNews) {
+		err := w.publish()
+		if err != nil {
+			return err
+		}
+	}
+
+	w.chunk.News = append(w.chunk.News, pbN)
+	w.size += size
+
+	return nil
+}
+
+// waitAcks waits until there is room in window for one more chunk.
+func (w *streamWriter) waitAcks() error {
+	for w.chunk.Seq-w.acked >= streamWindow {
+		msg, err := w.nextAck()
+		if err != nil {
+			return err
+		}
+
+		var ack pb.StreamAck
+
+		err = msgEncoding(msg).unmarshal(msg.Data, &ack)
+		if err != nil {
+			return errors.New("failed to unmarshal ack: " + err.Error())
+		}
+
+		if ack.Cancel {
+			return errStreamCanceled
+		}
+
+		if ack.Seq+1 > w.acked {
+			w.acked = ack.Seq + 1
+		}
+	}
+
+	return nil
+}
+
+// nextAck receives ack waiting for it up to ack timeout.
+func (w *streamWriter) nextAck() (*nats.Msg, error) {
+	ctx, cancel := context.WithTimeout(w.ctx, streamAckTimeout)
+	defer cancel()
+
+	msg, err := w.acks.NextMsgWithContext(ctx)
+	if err != nil {
+		switch {
+		case w.ctx.Err() != nil:
+			return nil, errServerStopped
+		case ctx.Err() == context.DeadlineExceeded:
+			return nil, errNoAck
+		}
+		return nil, errors.New("failed to receive ack: " + err.Error())
+	}
+
+	return msg, nil
+}
+
+func (w *streamWriter) publish() error {
+	err := w.waitAcks()
+	if err != nil {
+		return err
+	}
+
+	if !w.chunk.Last {
+		w.chunk.AckSubject = w.acks.Subject
+	}
+
+	data, err := w.encoding.marshal(&w.chunk)
+	if err != nil {
+		return errors.New("failed to marshal chunk: " + err.Error())
+	}
+
+	err = w.connection.PublishMsg(w.encoding.newMsg(w.subject, data))
+	if err != nil {
+		return errors.New("failed to publish chunk " +
+			strconv.FormatInt(w.chunk.Seq, 10) + ": " + err.Error())
+	}
+
+	w.chunk = pb.NewsChunk{Seq: w.chunk.Seq + 1}
+	w.size = 0
+
+	return nil
+}
+
+// finish publishes the last chunk with error e if any and closes writer.
+func (w *streamWriter) finish(e *pb.Error) error {
+	defer w.close()
+	w.chunk.Last = true
+	w.chunk.Error = e
+	return w.publish()
+}
+
+func (w *streamWriter) close() {
+	w.acks.Unsubscribe()
+}
+
+// NewsIterator iterates over news of streamed reply. It must be closed
+// if it isn't iterated to the end. Iteration fails if server sends no
+// chunk in idle timeout, so that it doesn't hang if server dies.
+type NewsIterator struct {
+	ctx         context.Context
+	connection  *nats.Conn
+	sub         *nats.Subscription
+	idleTimeout time.Duration
+
+	chunk *pb.NewsChunk
+	i     int
+	seq   int64
+	news  entity.News
+	err   error
+	done  bool
+}
+
+// stream sends req to subj and returns iterator over streamed reply.
+func (c *Client) stream(ctx context.Context, subj string,
+	req proto.Message) (*NewsIterator, error) {
+	reqBytes, err := proto.Marshal(req)
+	if err != nil {
+		return nil, errors.New("failed to marshal request: " + err.Error())
+	}
+
+	inbox := nats.NewInbox()
+
+	sub, err := c.connection.SubscribeSync(inbox)
+	if err != nil {
+		return nil, &service.RequestError{Err: err}
+	}
+
+	msg := requestMsg(ctx, subj, reqBytes)
+	msg.Reply = inbox
+
+	err = c.connection.PublishMsg(msg)
+	if err != nil {
+		sub.Unsubscribe()
+		return nil, &service.RequestError{Err: err}
+	}
+
+	return &NewsIterator{
+		ctx:         ctx,
+		connection:  c.connection,
+		sub:         sub,
+		idleTimeout: c.streamIdleTimeout,
+	}, nil
+}
+
+// Next advances iterator to the next news, which is returned by News.
+// It returns false at the end of stream or on error returned by Err.
+func (it *NewsIterator) Next() bool {
+	if it.done {
+		return false
+	}
+
+	for it.chunk == nil || it.i == len(it.chunk.News) {
+		if it.chunk != nil {
+			if it.chunk.Error != nil {
+				it.finish(responseError(it.chunk.Error))
+				return false
+			}
+			if it.chunk.Last {
+				it.finish(nil)
+				return false
+			}
+			err := it.ack(false)
+			if err != nil {
+				it.finish(err)
+				return false
+			}
+		}
+
+		err := it.receive()
+		if err != nil {
+			it.finish(err)
+			return false
+		}
+	}
+
+	n, err := pb.ToNews(it.chunk.News[it.i])
+	if err != nil {
+		it.finish(err)
+		return false
+	}
+
+	it.i++
+	it.news = n
+
+	return true
+}
+
+// receive receives the next chunk.
+func (it *NewsIterator) receive() error {
+	ctx, cancel := context.WithTimeout(it.ctx, it.idleTimeout)
+	defer cancel()
+
+	msg, err := it.sub.NextMsgWithContext(ctx)
+	if err != nil {
+		if it.ctx.Err() == nil && ctx.Err() == context.DeadlineExceeded {
+			err = errNoChunkSent
+		}
+		return &service.RequestError{Err: err}
+	}
+
+	var chunk pb.NewsChunk
+
+	err = proto.Unmarshal(msg.Data, &chunk)
+	if err != nil {
+		return errors.New("failed to unmarshal chunk: " + err.Error())
+	}
+
+	if chunk.Seq != it.seq {
+		return errors.New("got chunk " + strconv.FormatInt(chunk.Seq, 10) +
+			" instead of " + strconv.FormatInt(it.seq, 10))
+	}
+
+	it.chunk = &chunk
+	it.i = 0
+	it.seq++
+
+	return nil
+}
+
+// ack acknowledges current chunk or cancels stream.
+func (it *NewsIterator) ack(cancel bool) error {
+	if it.chunk == nil || it.chunk.AckSubject == "" {
+		return nil
+	}
+
+	data, err := proto.Marshal(&pb.StreamAck{
+		Seq:    it.chunk.Seq,
+		Cancel: cancel,
+	})
+	if err != nil {
+		return errors.New("failed to marshal ack: " + err.Error())
+	}
+
+	err = it.connection.Publish(it.chunk.AckSubject, data)
+	if err != nil {
+		return &service.RequestError{Err: err}
+	}
+
+	return nil
+}
+
+func (it *NewsIterator) finish(err error) {
+	it.err = err
+	it.done = true
+	it.sub.Unsubscribe()
+}
+
+func (it *NewsIterator) News() entity.News {
+	return it.news
+}
+
+func (it *NewsIterator) Err() error {
+	return it.err
+}
+
+// Close stops iteration and cancels stream if it isn't finished.
+func (it *NewsIterator) Close() {
+	if it.done {
+		return
+	}
+	if it.chunk != nil && !it.chunk.Last {
+		it.ack(true)
+	}
+	it.finish(nil)
+}
+
+// StreamNews returns iterator over news selected by filter f. It is
+// ListNews which doesn't collect news in memory.
+func (c *Client) StreamNews(ctx context.Context, f entity.NewsFilter) (
+	*NewsIterator, error) {
+	return c.stream(ctx, c.subSubj+listSuffix, pb.FromFilter(f))
+}
+
+// ListNews lists news selected by filter f. News are searched by header
+// if f.Query is not empty.
+func (c *Client) ListNews(ctx context.Context, f entity.NewsFilter) (
+	[]entity.News, error) {
+	it, err := c.StreamNews(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	ns := []entity.News{}
+
+	for it.Next() {
+		ns = append(ns, it.News())
+	}
+
+	return ns, it.Err()
+}
+
+// StreamExport returns iterator over news matched by dates and query of
+// f in date order.
+func (c *Client) StreamExport(ctx context.Context, f entity.NewsFilter) (
+	*NewsIterator, error) {
+	pbF := pb.FromFilter(f)
+	return c.stream(ctx, c.subSubj+exportSuffix, &pb.ExportNewsRequest{
+		From:  pbF.From,
+		To:    pbF.To,
+		Query: pbF.Query,
+	})
+}
+
+// ExportNews calls fn for every news matched by dates and query of f in
+// date order.
+func (c *Client) ExportNews(ctx context.Context, f entity.NewsFilter,
+	fn func(entity.News) error) error {
+	it, err := c.StreamExport(ctx, f)
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	for it.Next() {
+		err = fn(it.News())
+		if err != nil {
+			return err
+		}
+	}
+
+	return it.Err()
+}
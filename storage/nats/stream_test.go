@@ -0,0 +1,254 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/storage/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportNews(t *testing.T) {
+	sm, s := initServer(t)
+	defer cleanServer(t, s)
+
+	c := initClient(t)
+	defer c.Close()
+
+	// Big headers make chunks limited by payload size, not news count.
+	var ns []entity.News
+	for i := 1; i <= 3000; i++ {
+		ns = append(ns, entity.News{
+			ID:     int64(i),
+			Header: strings.Repeat("h", 1000),
+			Date:   time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+		})
+	}
+
+	f := entity.NewsFilter{
+		From: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC).Add(-1),
+	}
+
+	sm.On("ExportNews", f).Return(ns, nil)
+
+	var got []entity.News
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+
+	err := c.ExportNews(ctx, entity.NewsFilter{
+		From: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+	}, func(n entity.News) error {
+		got = append(got, n)
+		return nil
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, ns, got)
+	}
+
+	sm.AssertExpectations(t)
+}
+
+func TestExportNews_storageError(t *testing.T) {
+	sm, s := initServer(t)
+	defer cleanServer(t, s)
+
+	c := initClient(t)
+	defer c.Close()
+
+	ns := []entity.News{{
+		ID:     1,
+		Header: "header",
+		Date:   time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+	}}
+
+	sm.On("ExportNews", entity.NewsFilter{}).Return(ns, errors.New("error"))
+
+	var got []entity.News
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+
+	err := c.ExportNews(ctx, entity.NewsFilter{},
+		func(n entity.News) error {
+			got = append(got, n)
+			return nil
+		})
+
+	assert.Equal(t, &service.ResponseError{
+		Code:    500,
+		Message: "internal server error",
+	}, err)
+	assert.Equal(t, ns, got)
+}
+
+func manyNews(n int) []entity.News {
+	ns := make([]entity.News, 0, n)
+	for i := 1; i <= n; i++ {
+		ns = append(ns, entity.News{
+			ID:     int64(i),
+			Header: "header",
+			Date:   time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+		})
+	}
+	return ns
+}
+
+// TestStreamNews_flowControl checks stream of more chunks than fit in
+// window.
+func TestStreamNews_flowControl(t *testing.T) {
+	sm, s := initServer(t)
+	defer cleanServer(t, s)
+
+	c := initClient(t)
+	defer c.Close()
+
+	ns := manyNews(3 * streamWindow * maxChunkNews)
+
+	sm.On("ExportNews", entity.NewsFilter{}).Return(ns, nil)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Second)
+	defer cancel()
+
+	it, err := c.StreamExport(ctx, entity.NewsFilter{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer it.Close()
+
+	var n int
+
+	for it.Next() {
+		n++
+		assert.Equal(t, int64(n), it.News().ID)
+		if n%maxChunkNews == 0 {
+			// Slow client gets no more than window of chunks.
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	assert.NoError(t, it.Err())
+	assert.Equal(t, len(ns), n)
+}
+
+func TestStreamNews_cancel(t *testing.T) {
+	sm, s := initServer(t)
+	defer cleanServer(t, s)
+
+	c := initClient(t)
+	defer c.Close()
+
+	sm.On("ExportNews", entity.NewsFilter{}).Return(
+		manyNews(3*streamWindow*maxChunkNews), nil)
+
+	it, err := c.StreamExport(context.TODO(), entity.NewsFilter{})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, it.Next())
+
+	it.Close()
+
+	assert.False(t, it.Next())
+	assert.NoError(t, it.Err())
+
+	done := make(chan struct{})
+	go func() {
+		s.streams.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Error("stream isn't canceled")
+	}
+}
+
+func TestExportNews_tooBig(t *testing.T) {
+	sm, s := initServer(t)
+	defer cleanServer(t, s)
+
+	c := initClient(t)
+	defer c.Close()
+
+	sm.On("ExportNews", entity.NewsFilter{}).Return([]entity.News{{
+		ID:     1,
+		Header: strings.Repeat("h", int(c.connection.MaxPayload())),
+		Date:   time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+	}}, nil)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+
+	err := c.ExportNews(ctx, entity.NewsFilter{},
+		func(n entity.News) error {
+			return nil
+		})
+
+	assert.Equal(t, &service.ResponseError{
+		Code:    500,
+		Message: "internal server error",
+	}, err)
+}
+
+// TestStreamNews_stop checks that stop doesn't wait for acks of client
+// which stalled.
+func TestStreamNews_stop(t *testing.T) {
+	sm, s := initServer(t)
+
+	c := initClient(t)
+	defer c.Close()
+
+	sm.On("ExportNews", entity.NewsFilter{}).Return(
+		manyNews(3*streamWindow*maxChunkNews), nil)
+
+	it, err := c.StreamExport(context.TODO(), entity.NewsFilter{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer it.Close()
+
+	assert.True(t, it.Next())
+
+	done := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Error("server isn't stopped")
+	}
+}
+
+func TestStreamNews_idleTimeout(t *testing.T) {
+	c := initClient(t)
+	defer c.Close()
+
+	// Server which receives request and dies before replying.
+	sub, err := c.connection.SubscribeSync(testSubject + exportSuffix)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer sub.Unsubscribe()
+
+	c.SetStreamIdleTimeout(100 * time.Millisecond)
+
+	it, err := c.StreamExport(context.TODO(), entity.NewsFilter{})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.False(t, it.Next())
+	assert.Equal(t, &service.RequestError{Err: errNoChunkSent}, it.Err())
+}
@@ -0,0 +1,236 @@
+package pb
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+)
+
+// dateLayout is layout of dates in protocol messages.
+const dateLayout = "2006-01-02"
+
+// FormatDate formats date of t in UTC, zero time is empty date.
+func FormatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(dateLayout)
+}
+
+// ParseDate parses date, empty date is zero time.
+func ParseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation(dateLayout, s, time.UTC)
+}
+
+// timeLayout is layout of publication time in protocol messages.
+const timeLayout = time.RFC3339Nano
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(timeLayout)
+}
+
+// FromNews converts news to protocol message.
+func FromNews(n entity.News) *News {
+	return &News{
+		Id:          n.ID,
+		Header:      n.Header,
+		Date:        FormatDate(n.Date),
+		ExternalId:  n.ExternalID,
+		PublishedAt: formatTime(n.Date),
+	}
+}
+
+// ToNews converts protocol message to news.
+func ToNews(n *News) (entity.News, error) {
+	if n == nil {
+		return entity.News{}, errors.New("unexpected nil news")
+	}
+
+	// Peers which don't know publication time send only date.
+	if n.PublishedAt != "" {
+		date, err := time.Parse(timeLayout, n.PublishedAt)
+		if err != nil {
+			return entity.News{}, errors.New(
+				"failed to parse published_at: " + err.Error())
+		}
+
+		return entity.News{
+			ID:         n.Id,
+			Header:     n.Header,
+			Date:       date.UTC(),
+			ExternalID: n.ExternalId,
+		}, nil
+	}
+
+	date, err := ParseDate(n.Date)
+	if err != nil {
+		return entity.News{}, errors.New("failed to parse date: " +
+			err.Error())
+	}
+
+	return entity.News{
+		ID:         n.Id,
+		Header:     n.Header,
+		Date:       date,
+		ExternalID: n.ExternalId,
+	}, nil
+}
+
+// ToNewsList converts protocol messages to news.
+func ToNewsList(pbNs []*News) ([]entity.News, error) {
+	ns := make([]entity.News, 0, len(pbNs))
+	for _, pbN := range pbNs {
+		n, err := ToNews(pbN)
+		if err != nil {
+			return nil, err
+		}
+		ns = append(ns, n)
+	}
+	return ns, nil
+}
+
+// ToNewsDays converts protocol messages to news days.
+func ToNewsDays(pbDays []*NewsDay) ([]entity.NewsDay, error) {
+	days := make([]entity.NewsDay, 0, len(pbDays))
+	for _, d := range pbDays {
+		date, err := ParseDate(d.Date)
+		if err != nil {
+			return nil, errors.New("failed to parse date: " + err.Error())
+		}
+		days = append(days, entity.NewsDay{Date: date, Count: d.Count})
+	}
+	return days, nil
+}
+
+// FromFilter converts news filter to list request.
+func FromFilter(f entity.NewsFilter) *ListNewsRequest {
+	return &ListNewsRequest{
+		From:   FormatDate(f.From),
+		To:     FormatDate(f.To),
+		Query:  f.Query,
+		Offset: f.Offset,
+		Limit:  f.Limit,
+	}
+}
+
+// ToFilter converts list request to news filter.
+func ToFilter(r *ListNewsRequest) (entity.NewsFilter, error) {
+	from, err := ParseDate(r.From)
+	if err != nil {
+		return entity.NewsFilter{}, errors.New("failed to parse from: " +
+			err.Error())
+	}
+
+	to, err := ParseDate(r.To)
+	if err != nil {
+		return entity.NewsFilter{}, errors.New("failed to parse to: " +
+			err.Error())
+	}
+
+	// To is a day, so it must include news from the whole day.
+	if !to.IsZero() {
+		to = to.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	return entity.NewsFilter{
+		From:   from,
+		To:     to,
+		Query:  r.Query,
+		Offset: r.Offset,
+		Limit:  r.Limit,
+	}, nil
+}
+
+// FromImportOptions converts import options to import request.
+func FromImportOptions(o entity.ImportOptions) *ImportNewsRequest {
+	return &ImportNewsRequest{
+		DryRun: o.DryRun,
+		Atomic: o.Atomic,
+		Upsert: o.Upsert,
+	}
+}
+
+// ToImportOptions converts import request to import options.
+func ToImportOptions(r *ImportNewsRequest) entity.ImportOptions {
+	return entity.ImportOptions{
+		DryRun: r.GetDryRun(),
+		Atomic: r.GetAtomic(),
+		Upsert: r.GetUpsert(),
+	}
+}
+
+// FromImportError converts error of imported line to protocol message.
+func FromImportError(e *entity.ImportError) *ImportRecord {
+	return &ImportRecord{Line: int64(e.Line), Error: e.Message}
+}
+
+// FromImportRecord converts imported record to protocol message.
+func FromImportRecord(r entity.ImportRecord) *ImportRecord {
+	return &ImportRecord{Line: int64(r.Line), News: FromNews(r.News)}
+}
+
+// ToImportRecord converts protocol message to imported record. Error of
+// line and malformed news are returned as *entity.ImportError.
+func ToImportRecord(r *ImportRecord) (entity.ImportRecord, error) {
+	line := int(r.GetLine())
+
+	if r.GetError() != "" {
+		return entity.ImportRecord{}, &entity.ImportError{
+			Line:    line,
+			Message: r.Error,
+		}
+	}
+
+	n, err := ToNews(r.GetNews())
+	if err != nil {
+		return entity.ImportRecord{}, &entity.ImportError{
+			Line:    line,
+			Message: err.Error(),
+		}
+	}
+
+	return entity.ImportRecord{Line: line, News: n}, nil
+}
+
+// FromImportResult converts import result to import response.
+func FromImportResult(r entity.ImportResult) *ImportNewsResponse {
+	res := &ImportNewsResponse{
+		Total:     int64(r.Total),
+		Created:   int64(r.Created),
+		Updated:   int64(r.Updated),
+		Failed:    int64(r.Failed),
+		Committed: r.Committed,
+	}
+	for _, e := range r.Errors {
+		res.Errors = append(res.Errors, &ImportError{
+			Line:    int64(e.Line),
+			Message: e.Message,
+		})
+	}
+	return res
+}
+
+// ToImportResult converts import response to import result.
+func ToImportResult(r *ImportNewsResponse) entity.ImportResult {
+	res := entity.ImportResult{
+		Total:     int(r.Total),
+		Created:   int(r.Created),
+		Updated:   int(r.Updated),
+		Failed:    int(r.Failed),
+		Committed: r.Committed,
+	}
+	for _, e := range r.Errors {
+		res.Errors = append(res.Errors, entity.ImportError{
+			Line:    int(e.Line),
+			Message: e.Message,
+		})
+	}
+	return res
+}
@@ -0,0 +1,3 @@
+package pb
+
+//go:generate sh -c "protoc --go_out=plugins=grpc:. *.proto"
@@ -0,0 +1,46 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/jmoiron/sqlx"
+)
+
+// NewsDays returns days which have news in UTC with number of news of
+// every day. Days are ordered by date.
+func (s *Storage) NewsDays(ctx context.Context) ([]entity.NewsDay, error) {
+	ctx, cancel := s.withQueryTimeout(ctx)
+	defer cancel()
+
+	var rows []struct {
+		Day   string `db:"day"`
+		Count int64  `db:"count"`
+	}
+
+	err := s.read(ctx, func(db *sqlx.DB) error {
+		rows = nil
+		return db.SelectContext(ctx, &rows, `
+			SELECT to_char(date AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS day,
+				count(*) AS count
+			FROM news WHERE date IS NOT NULL
+			GROUP BY day ORDER BY day;
+		`)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	days := make([]entity.NewsDay, 0, len(rows))
+	for _, r := range rows {
+		d, err := time.ParseInLocation("2006-01-02", r.Day, time.UTC)
+		if err != nil {
+			return nil, errors.New("failed to parse day: " + err.Error())
+		}
+		days = append(days, entity.NewsDay{Date: d, Count: r.Count})
+	}
+
+	return days, nil
+}
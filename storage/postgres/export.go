@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"github.com/dimuls/news-storage/entity"
+)
+
+// exportBatchSize is number of news fetched from export cursor at once.
+const exportBatchSize = 1000
+
+// ExportNews calls fn for every news matched by dates and query of f in
+// date order. News are fetched in batches from server-side cursor, so
+// memory usage doesn't depend on number of exported news. Export reads
+// from healthy replica if there is one, but doesn't fall back to primary
+// because news may be already passed to fn.
+func (s *Storage) ExportNews(ctx context.Context, f entity.NewsFilter,
+	fn func(entity.News) error) error {
+	db := s.db
+	if r := s.replica(ctx); r != nil {
+		db = r.db
+	}
+
+	// Cursor lives until the end of transaction.
+	tx, err := db.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return errors.New("failed to begin transaction: " + err.Error())
+	}
+	defer tx.Rollback()
+
+	var args queryArgs
+
+	_, err = tx.ExecContext(ctx, "DECLARE news_export NO SCROLL CURSOR FOR "+
+		selectNews(f, &args)+" ORDER BY date, id", args...)
+	if err != nil {
+		return errors.New("failed to declare cursor: " + err.Error())
+	}
+
+	fetch := "FETCH " + strconv.Itoa(exportBatchSize) + " FROM news_export"
+
+	for {
+		var ns []entity.News
+
+		err = tx.SelectContext(ctx, &ns, fetch)
+		if err != nil {
+			return errors.New("failed to fetch news: " + err.Error())
+		}
+
+		if len(ns) == 0 {
+			return nil
+		}
+
+		for _, n := range ns {
+			err = fn(n)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
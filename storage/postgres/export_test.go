@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorage_ExportNews(t *testing.T) {
+	s := initStorage(t)
+	defer cleanStorage(t, s)
+
+	// More news than fit in one batch.
+	for i := 0; i < 2500; i++ {
+		_, err := s.CreateNews(context.TODO(), entity.News{
+			Header: "header",
+			Date:   time.Date(2019, 1, 1+i%10, 0, 0, 0, 0, time.UTC),
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+	}
+
+	var (
+		n    int
+		last entity.News
+	)
+
+	err := s.ExportNews(context.TODO(), entity.NewsFilter{
+		From: time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC),
+	}, func(news entity.News) error {
+		n++
+		assert.False(t, news.Date.Before(last.Date))
+		last = news
+		return nil
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2000, n)
+	}
+
+	stop := errors.New("stop")
+
+	err = s.ExportNews(context.TODO(), entity.NewsFilter{},
+		func(news entity.News) error {
+			return stop
+		})
+	assert.Equal(t, stop, err)
+}
@@ -0,0 +1,266 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// ImportNews imports news from src in one transaction. News are copied
+// to temporary table with COPY first, then checked for external ID
+// conflicts and moved to news table, so big imports are fast.
+func (s *Storage) ImportNews(ctx context.Context, src entity.ImportSource,
+	o entity.ImportOptions) (r entity.ImportResult, err error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return r, errors.New("failed to begin transaction: " + err.Error())
+	}
+	defer func() {
+		if !r.Committed {
+			tx.Rollback()
+		}
+	}()
+
+	_, err = tx.ExecContext(ctx, `
+		CREATE TEMPORARY TABLE news_import (
+			line INTEGER NOT NULL,
+			external_id TEXT NOT NULL,
+			header TEXT NOT NULL,
+			date TIMESTAMP WITH TIME ZONE NOT NULL
+		) ON COMMIT DROP;
+	`)
+	if err != nil {
+		return r, errors.New("failed to create import table: " +
+			err.Error())
+	}
+
+	err = copyNews(ctx, tx, src, &r)
+	if err != nil {
+		return r, err
+	}
+
+	// Temporary tables have no statistics, without them conflicts are
+	// checked by nested loops which are slow for big imports.
+	_, err = tx.ExecContext(ctx, `ANALYZE news_import;`)
+	if err != nil {
+		return r, errors.New("failed to analyze import table: " +
+			err.Error())
+	}
+
+	err = rejectConflicts(ctx, tx, o.Upsert, &r)
+	if err != nil {
+		return r, err
+	}
+
+	if !o.Atomic || r.Failed == 0 {
+		if o.Upsert {
+			err = upsertNews(ctx, tx, &r)
+		} else {
+			err = insertNews(ctx, tx, &r)
+		}
+		if err != nil {
+			return r, err
+		}
+	}
+
+	sort.SliceStable(r.Errors, func(i, j int) bool {
+		return r.Errors[i].Line < r.Errors[j].Line
+	})
+
+	// Atomic import fails if news conflicting with concurrent changes
+	// are skipped too.
+	if o.Atomic && r.Failed > 0 {
+		r.Created, r.Updated = 0, 0
+		return r, nil
+	}
+
+	if o.DryRun {
+		return r, nil
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return r, errors.New("failed to commit: " + err.Error())
+	}
+
+	r.Committed = true
+
+	return r, nil
+}
+
+// copyNews copies news from src to import table and adds invalid lines
+// to r.
+func copyNews(ctx context.Context, tx *sqlx.Tx, src entity.ImportSource,
+	r *entity.ImportResult) error {
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("news_import",
+		"line", "external_id", "header", "date"))
+	if err != nil {
+		return errors.New("failed to start copy: " + err.Error())
+	}
+	defer stmt.Close()
+
+	for {
+		rec, err := src.Next()
+		if err == io.EOF {
+			break
+		}
+		if ie, ok := err.(*entity.ImportError); ok {
+			r.Total++
+			r.AddError(*ie)
+			continue
+		}
+		if err != nil {
+			return errors.New("failed to read news: " + err.Error())
+		}
+
+		r.Total++
+
+		_, err = stmt.ExecContext(ctx, rec.Line, rec.News.ExternalID,
+			rec.News.Header, rec.News.Date)
+		if err != nil {
+			return errors.New("failed to copy news: " + err.Error())
+		}
+	}
+
+	_, err = stmt.ExecContext(ctx)
+	if err != nil {
+		return errors.New("failed to finish copy: " + err.Error())
+	}
+
+	return nil
+}
+
+// conflictCheck is a query removing news with conflicting external ID
+// from import table and returning them.
+type conflictCheck struct {
+	query   string
+	message string
+}
+
+var (
+	repeatedCheck = conflictCheck{
+		query: `
+			DELETE FROM news_import i
+			WHERE external_id <> '' AND EXISTS (
+				SELECT 1 FROM news_import d
+				WHERE d.external_id = i.external_id AND d.line < i.line
+			)
+			RETURNING line, external_id;
+		`,
+		message: "external id is repeated in import: ",
+	}
+	existingCheck = conflictCheck{
+		query: `
+			DELETE FROM news_import i
+			WHERE external_id <> '' AND EXISTS (
+				SELECT 1 FROM news n WHERE n.external_id = i.external_id
+			)
+			RETURNING line, external_id;
+		`,
+		message: "news with external id already exists: ",
+	}
+)
+
+// rejectConflicts removes news with external ID repeated in import or,
+// if not upsert, existing in news table from import table and adds them
+// to r as line errors.
+func rejectConflicts(ctx context.Context, tx *sqlx.Tx, upsert bool,
+	r *entity.ImportResult) error {
+	checks := []conflictCheck{repeatedCheck}
+	if !upsert {
+		checks = append(checks, existingCheck)
+	}
+
+	for _, c := range checks {
+		var conflicts []struct {
+			Line       int    `db:"line"`
+			ExternalID string `db:"external_id"`
+		}
+
+		err := tx.SelectContext(ctx, &conflicts, c.query)
+		if err != nil {
+			return errors.New("failed to check external ids: " +
+				err.Error())
+		}
+
+		for _, cf := range conflicts {
+			r.AddError(entity.ImportError{
+				Line:    cf.Line,
+				Message: c.message + cf.ExternalID,
+			})
+		}
+	}
+
+	return nil
+}
+
+// insertNews moves news from import table to news table. News which were
+// created with the same external ID by concurrent transaction after
+// conflicts check are skipped and added to r as line errors.
+func insertNews(ctx context.Context, tx *sqlx.Tx,
+	r *entity.ImportResult) error {
+	var skipped []struct {
+		Line       int    `db:"line"`
+		ExternalID string `db:"external_id"`
+	}
+
+	err := tx.SelectContext(ctx, &skipped, `
+		WITH created AS (
+			INSERT INTO news (external_id, header, date)
+			SELECT external_id, header, date FROM news_import
+			ORDER BY line
+			ON CONFLICT (external_id) WHERE external_id <> '' DO NOTHING
+			RETURNING external_id
+		)
+		SELECT line, external_id FROM news_import i
+		WHERE external_id <> '' AND NOT EXISTS (
+			SELECT 1 FROM created c WHERE c.external_id = i.external_id
+		);
+	`)
+	if err != nil {
+		return errors.New("failed to insert news: " + err.Error())
+	}
+
+	for _, s := range skipped {
+		r.AddError(entity.ImportError{
+			Line:    s.Line,
+			Message: existingCheck.message + s.ExternalID,
+		})
+	}
+
+	// Import table has news which aren't failed.
+	r.Created = r.Total - r.Failed
+
+	return nil
+}
+
+// upsertNews moves news from import table to news table updating ones
+// with the same external ID.
+func upsertNews(ctx context.Context, tx *sqlx.Tx,
+	r *entity.ImportResult) error {
+	// Inserted row has no deleting transaction, while updated one has
+	// the current one.
+	err := tx.QueryRowxContext(ctx, `
+		WITH changed AS (
+			INSERT INTO news (external_id, header, date)
+			SELECT external_id, header, date FROM news_import
+			ORDER BY line
+			ON CONFLICT (external_id) WHERE external_id <> '' DO UPDATE
+			SET header = excluded.header, date = excluded.date
+			RETURNING xmax = 0 AS created
+		)
+		SELECT count(*) FILTER (WHERE created),
+			count(*) FILTER (WHERE NOT created)
+		FROM changed;
+	`).Scan(&r.Created, &r.Updated)
+	if err != nil {
+		return errors.New("failed to upsert news: " + err.Error())
+	}
+
+	return nil
+}
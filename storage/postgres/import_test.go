@@ -0,0 +1,162 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/newsio"
+	"github.com/stretchr/testify/assert"
+)
+
+const testImport = `{"external_id":"a","header":"first","date":"2019-01-01"}
+{"external_id":"b","header":"","date":"2019-01-02"}
+{"external_id":"c","header":"third","date":"2019-01-03"}
+{"external_id":"a","header":"repeated","date":"2019-01-04"}
+{"header":"no external id","date":"2019-01-05"}
+`
+
+func importNews(t *testing.T, s *Storage, data string,
+	o entity.ImportOptions) entity.ImportResult {
+	src, err := newsio.NewReader(strings.NewReader(data), newsio.FormatJSONL)
+	if err != nil {
+		t.Fatal("failed to create reader: " + err.Error())
+	}
+
+	r, err := s.ImportNews(context.TODO(), src, o)
+	if err != nil {
+		t.Fatal("failed to import: " + err.Error())
+	}
+
+	return r
+}
+
+func countNews(t *testing.T, s *Storage) int {
+	ns, err := s.ListNews(context.TODO(), entity.NewsFilter{})
+	if err != nil {
+		t.Fatal("failed to list news: " + err.Error())
+	}
+	return len(ns)
+}
+
+func TestStorage_ImportNews(t *testing.T) {
+	s := initStorage(t)
+	defer cleanStorage(t, s)
+
+	r := importNews(t, s, testImport, entity.ImportOptions{})
+
+	assert.Equal(t, entity.ImportResult{
+		Total:     5,
+		Created:   3,
+		Failed:    2,
+		Committed: true,
+		Errors: []entity.ImportError{{
+			Line:    2,
+			Message: "header is required",
+		}, {
+			Line:    4,
+			Message: "external id is repeated in import: a",
+		}},
+	}, r)
+
+	assert.Equal(t, 3, countNews(t, s))
+
+	// Existing external IDs are errors without upsert.
+	r = importNews(t, s, testImport, entity.ImportOptions{})
+	assert.Equal(t, 1, r.Created)
+	assert.Equal(t, 4, r.Failed)
+	assert.Equal(t, 4, countNews(t, s))
+}
+
+func TestStorage_ImportNews_upsert(t *testing.T) {
+	s := initStorage(t)
+	defer cleanStorage(t, s)
+
+	importNews(t, s, testImport, entity.ImportOptions{})
+
+	r := importNews(t, s,
+		`{"external_id":"a","header":"updated","date":"2019-02-01"}`+"\n"+
+			`{"external_id":"d","header":"fourth","date":"2019-02-02"}`+"\n",
+		entity.ImportOptions{Upsert: true})
+
+	assert.Equal(t, entity.ImportResult{
+		Total:     2,
+		Created:   1,
+		Updated:   1,
+		Committed: true,
+	}, r)
+
+	ns, err := s.ListNews(context.TODO(), entity.NewsFilter{Query: "updated"})
+	if assert.NoError(t, err) && assert.Len(t, ns, 1) {
+		assert.Equal(t, "a", ns[0].ExternalID)
+		assert.True(t, ns[0].Date.Equal(
+			time.Date(2019, 2, 1, 0, 0, 0, 0, time.UTC)))
+	}
+
+	assert.Equal(t, 4, countNews(t, s))
+}
+
+func TestStorage_ImportNews_dryRunAndAtomic(t *testing.T) {
+	s := initStorage(t)
+	defer cleanStorage(t, s)
+
+	r := importNews(t, s, testImport, entity.ImportOptions{DryRun: true})
+	assert.Equal(t, 3, r.Created)
+	assert.Equal(t, 2, r.Failed)
+	assert.False(t, r.Committed)
+	assert.Equal(t, 0, countNews(t, s))
+
+	r = importNews(t, s, testImport, entity.ImportOptions{Atomic: true})
+	assert.Equal(t, 0, r.Created)
+	assert.Equal(t, 2, r.Failed)
+	assert.False(t, r.Committed)
+	assert.Equal(t, 0, countNews(t, s))
+
+	// Storage stays usable after rolled back imports.
+	r = importNews(t, s, testImport, entity.ImportOptions{})
+	assert.True(t, r.Committed)
+	assert.Equal(t, 3, countNews(t, s))
+}
+
+// TestStorage_ImportNews_concurrent checks news created with the same
+// external id by concurrent transaction after conflicts check.
+func TestStorage_ImportNews_concurrent(t *testing.T) {
+	s := initStorage(t)
+	defer cleanStorage(t, s)
+
+	tx, err := s.db.Begin()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO news (external_id, header, date)
+		VALUES ('a', 'concurrent', NOW());
+	`)
+	if !assert.NoError(t, err) {
+		tx.Rollback()
+		return
+	}
+
+	rs := make(chan entity.ImportResult, 1)
+	go func() {
+		rs <- importNews(t, s, testImport, entity.ImportOptions{})
+	}()
+
+	// Import waits for transaction when inserting conflicting news.
+	time.Sleep(500 * time.Millisecond)
+
+	assert.NoError(t, tx.Commit())
+
+	r := <-rs
+	assert.True(t, r.Committed)
+	assert.Equal(t, 2, r.Created)
+	assert.Equal(t, 3, r.Failed)
+	assert.Contains(t, r.Errors, entity.ImportError{
+		Line:    1,
+		Message: "news with external id already exists: a",
+	})
+	assert.Equal(t, 3, countNews(t, s))
+}
@@ -0,0 +1,3 @@
+DROP INDEX news_external_id_idx;
+
+ALTER TABLE news DROP COLUMN external_id;
@@ -0,0 +1,197 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const replicaCheckInterval = 5 * time.Second
+
+// PrimaryReadWindow returns time after write in which replicas may not
+// have it yet: replica lagging up to maxReplicaLag is read from until
+// the next check. Reads which follow a write within it should be made
+// with context of entity.ContextWithPrimaryRead.
+func PrimaryReadWindow(maxReplicaLag time.Duration) time.Duration {
+	return maxReplicaLag + replicaCheckInterval
+}
+
+type replica struct {
+	index   int
+	db      *sqlx.DB
+	healthy int32
+}
+
+func (r *replica) isHealthy() bool {
+	return atomic.LoadInt32(&r.healthy) == 1
+}
+
+func (r *replica) setHealthy(healthy bool) {
+	var v int32
+	if healthy {
+		v = 1
+	}
+	atomic.StoreInt32(&r.healthy, v)
+}
+
+// lag returns replication lag of replica. Replica which replayed WAL up
+// to primaryLSN, current WAL position of primary, is up to date even if
+// primary had no writes for a long time. Otherwise lag is time since the
+// last replayed transaction, so that replica which lost connection to
+// primary becomes stale. Primary LSN is null if it's unknown.
+func (r *replica) lag(ctx context.Context, primaryLSN sql.NullString) (
+	time.Duration, error) {
+	var lag sql.NullFloat64
+	err := r.db.QueryRowxContext(ctx, `
+		SELECT CASE
+			WHEN NOT pg_is_in_recovery() THEN 0
+			WHEN pg_last_wal_replay_lsn() >= $1::pg_lsn THEN 0
+			ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())
+		END;
+	`, primaryLSN).Scan(&lag)
+	if err != nil {
+		return 0, err
+	}
+	if !lag.Valid {
+		return 0, errors.New("replica has replayed no transactions")
+	}
+	return time.Duration(lag.Float64 * float64(time.Second)), nil
+}
+
+// replica returns next healthy replica in round robin order or nil if
+// there is no healthy replicas or ctx requires read from primary.
+func (s *Storage) replica(ctx context.Context) *replica {
+	if len(s.replicas) == 0 || entity.PrimaryReadFromContext(ctx) {
+		return nil
+	}
+
+	next := atomic.AddUint32(&s.nextReplica, 1)
+
+	for i := range s.replicas {
+		r := s.replicas[(int(next)+i)%len(s.replicas)]
+		if r.isHealthy() {
+			return r
+		}
+	}
+
+	return nil
+}
+
+// read runs read only query f on healthy replica and falls back to
+// primary if there is no one or query on replica failed. Replica is
+// taken out of rotation only if connection to it failed, since other
+// errors would fail on any database.
+func (s *Storage) read(ctx context.Context, f func(db *sqlx.DB) error) error {
+	r := s.replica(ctx)
+	if r == nil {
+		return f(s.db)
+	}
+
+	err := f(r.db)
+	if err == nil || err == sql.ErrNoRows || ctx.Err() != nil {
+		return err
+	}
+
+	s.logger(ctx).WithError(err).WithField("replica", r.index).Warn(
+		"failed to query replica, falling back to primary")
+
+	if connectionError(err) {
+		r.setHealthy(false)
+	}
+
+	return f(s.db)
+}
+
+// connectionError reports whether err is failure of connection to
+// database or of database itself rather than of query.
+func connectionError(err error) bool {
+	if err == driver.ErrBadConn || err == io.EOF ||
+		err == io.ErrUnexpectedEOF {
+		return true
+	}
+
+	if _, ok := err.(net.Error); ok {
+		return true
+	}
+
+	if pe, ok := err.(*pq.Error); ok {
+		// Connection exceptions and shutdown of database.
+		return pe.Code.Class() == "08" || pe.Code == "57P01" ||
+			pe.Code == "57P02" || pe.Code == "57P03"
+	}
+
+	return false
+}
+
+// SetMaxReplicaLag changes max lag of replica to read from it. It is
+// applied on the next replicas check.
+func (s *Storage) SetMaxReplicaLag(d time.Duration) {
+	atomic.StoreInt64(&s.maxReplicaLag, int64(d))
+}
+
+func (s *Storage) checkReplicas() {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		replicaCheckInterval)
+	var primaryLSN sql.NullString
+	err := s.db.QueryRowxContext(ctx, `
+		SELECT pg_current_wal_lsn()::text;
+	`).Scan(&primaryLSN)
+	cancel()
+	if err != nil {
+		// Replicas are checked by time of their last transaction then.
+		s.log.WithError(err).Warn("failed to get WAL position of primary")
+	}
+
+	for _, r := range s.replicas {
+		ctx, cancel := context.WithTimeout(context.Background(),
+			replicaCheckInterval)
+		lag, err := r.lag(ctx, primaryLSN)
+		cancel()
+
+		log := s.log.WithField("replica", r.index)
+
+		switch {
+		case err != nil:
+			if r.isHealthy() {
+				log.WithError(err).Warn("replica is unhealthy")
+			}
+			r.setHealthy(false)
+		case lag > time.Duration(atomic.LoadInt64(&s.maxReplicaLag)):
+			if r.isHealthy() {
+				log.WithField("lag", lag.String()).Warn(
+					"replica is lagging")
+			}
+			r.setHealthy(false)
+		default:
+			if !r.isHealthy() {
+				log.Info("replica is healthy")
+			}
+			r.setHealthy(true)
+		}
+	}
+}
+
+func (s *Storage) watchReplicas() {
+	defer s.wg.Done()
+
+	t := time.NewTicker(replicaCheckInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-t.C:
+			s.checkReplicas()
+		}
+	}
+}
@@ -4,28 +4,52 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Boostport/migration"
 	"github.com/Boostport/migration/driver/postgres"
 	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/storage/migrate"
 	"github.com/gobuffalo/packr"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
 )
 
 type Storage struct {
+	// maxReplicaLag is time.Duration accessed atomically, because it can
+	// be changed while replicas are checked.
+	maxReplicaLag int64
+	// queryTimeout is time.Duration accessed atomically too.
+	queryTimeout int64
+
 	uri string
 	db  *sqlx.DB
+
+	replicas    []*replica
+	nextReplica uint32
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+
+	log *logrus.Entry
 }
 
-func NewStorage(postgresURI string) (*Storage, error) {
+// NewStorage creates storage connected to primary postgresURI. Read only
+// queries are sent to healthy replicas from replicaURIs which lag behind
+// primary no more than maxReplicaLag.
+func NewStorage(postgresURI string, replicaURIs []string, maxReplicaLag,
+	pingTimeout time.Duration) (*Storage, error) {
 	db, err := sqlx.Connect("postgres", postgresURI)
 	if err != nil {
 		return nil, errors.New("failed to connect: " + err.Error())
 	}
 
-	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.TODO(), pingTimeout)
 	defer cancel()
 
 	err = db.PingContext(ctx)
@@ -33,44 +57,231 @@ func NewStorage(postgresURI string) (*Storage, error) {
 		return nil, errors.New("failed to ping: " + err.Error())
 	}
 
-	return &Storage{
-		uri: postgresURI,
-		db:  db,
-	}, nil
+	s := &Storage{
+		maxReplicaLag: int64(maxReplicaLag),
+		uri:           postgresURI,
+		db:            db,
+		stop:          make(chan struct{}),
+		log:           logrus.WithField("subsystem", "postgres_storage"),
+	}
+
+	for i, uri := range replicaURIs {
+		// Replica can be down at start, so don't fail on it and let
+		// the watcher bring it in when it is healthy.
+		rdb, err := sqlx.Open("postgres", uri)
+		if err != nil {
+			s.closeReplicas()
+			db.Close()
+			return nil, errors.New("failed to open replica: " + err.Error())
+		}
+		s.replicas = append(s.replicas, &replica{index: i, db: rdb})
+	}
+
+	if len(s.replicas) > 0 {
+		s.checkReplicas()
+		s.wg.Add(1)
+		go s.watchReplicas()
+	}
+
+	return s, nil
 }
 
 //go:generate packr
 
 const migrationsPath = "./migrations"
 
-func (s *Storage) Migrate() error {
-	packrSource := &migration.PackrMigrationSource{
+// Migrator returns migrator of storage schema.
+func (s *Storage) Migrator() *migrate.Migrator {
+	return migrate.NewMigrator(func() (migration.Driver, error) {
+		return postgres.New(s.uri)
+	}, &migration.PackrMigrationSource{
 		Box: packr.NewBox(migrationsPath),
+	})
+}
+
+// Migrate applies all not applied migrations.
+func (s *Storage) Migrate() error {
+	_, err := s.Migrator().Up(0)
+	return err
+}
+
+func (s *Storage) Close() error {
+	close(s.stop)
+	s.wg.Wait()
+	s.closeReplicas()
+	return s.db.Close()
+}
+
+// SetQueryTimeout limits time of queries other than export and import,
+// zero removes the limit. Transport servers limit requests themselves,
+// so it's needed only when storage is used directly.
+func (s *Storage) SetQueryTimeout(d time.Duration) {
+	atomic.StoreInt64(&s.queryTimeout, int64(d))
+}
+
+// withQueryTimeout returns ctx limited by query timeout if it is set.
+func (s *Storage) withQueryTimeout(ctx context.Context) (context.Context,
+	context.CancelFunc) {
+	d := time.Duration(atomic.LoadInt64(&s.queryTimeout))
+	if d == 0 {
+		return context.WithCancel(ctx)
 	}
+	return context.WithTimeout(ctx, d)
+}
 
-	d, err := postgres.New(s.uri)
-	if err != nil {
-		return errors.New("failed to create migration driver: " + err.Error())
+// logger returns log of request of ctx, it has request id if ctx has
+// one.
+func (s *Storage) logger(ctx context.Context) *logrus.Entry {
+	if id, ok := entity.RequestIDFromContext(ctx); ok {
+		return s.log.WithField("request_id", id)
 	}
+	return s.log
+}
 
-	_, err = migration.Migrate(d, packrSource, migration.Up, 0)
-	if err != nil {
-		return errors.New("failed to migrate: " + err.Error())
+func (s *Storage) closeReplicas() {
+	for _, r := range s.replicas {
+		err := r.db.Close()
+		if err != nil {
+			s.log.WithError(err).WithField("replica", r.index).Error(
+				"failed to close replica")
+		}
 	}
+}
 
-	return nil
+func (s *Storage) News(ctx context.Context, id int64) (n entity.News, err error) {
+	ctx, cancel := s.withQueryTimeout(ctx)
+	defer cancel()
+
+	err = s.read(ctx, func(db *sqlx.DB) error {
+		return db.QueryRowxContext(ctx, `
+			SELECT * FROM news WHERE id = $1;
+		`, id).StructScan(&n)
+	})
+	if err == sql.ErrNoRows {
+		err = entity.ErrNewsNotFound
+	}
+	return
 }
 
-func (s *Storage) Close() error {
-	return s.db.Close()
+// NewsByIDs returns news with ids in no particular order. Missing news
+// are omitted.
+func (s *Storage) NewsByIDs(ctx context.Context, ids []int64) (
+	ns []entity.News, err error) {
+	ctx, cancel := s.withQueryTimeout(ctx)
+	defer cancel()
+
+	err = s.read(ctx, func(db *sqlx.DB) error {
+		ns = nil
+		return db.SelectContext(ctx, &ns, `
+			SELECT * FROM news WHERE id = ANY($1);
+		`, pq.Array(ids))
+	})
+	return
 }
 
-func (s *Storage) News(ctx context.Context, id int64) (n entity.News, err error) {
+// queryArgs collects query arguments and returns their placeholders.
+type queryArgs []interface{}
+
+func (a *queryArgs) add(v interface{}) string {
+	*a = append(*a, v)
+	return "$" + strconv.Itoa(len(*a))
+}
+
+// selectNews returns query selecting news matched by dates and query
+// of f, its arguments are added to args.
+func selectNews(f entity.NewsFilter, args *queryArgs) string {
+	var where []string
+
+	if !f.From.IsZero() {
+		where = append(where, "date >= "+args.add(f.From))
+	}
+	if !f.To.IsZero() {
+		where = append(where, "date <= "+args.add(f.To))
+	}
+	if f.Query != "" {
+		where = append(where,
+			"header ILIKE "+args.add("%"+escapeLike(f.Query)+"%"))
+	}
+
+	q := "SELECT * FROM news"
+	if len(where) > 0 {
+		q += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	return q
+}
+
+func (s *Storage) ListNews(ctx context.Context, f entity.NewsFilter) (
+	ns []entity.News, err error) {
+	ctx, cancel := s.withQueryTimeout(ctx)
+	defer cancel()
+
+	var args queryArgs
+
+	q := selectNews(f, &args) + " ORDER BY date DESC, id DESC"
+	if f.Limit > 0 {
+		q += " LIMIT " + args.add(f.Limit)
+	}
+	if f.Offset > 0 {
+		q += " OFFSET " + args.add(f.Offset)
+	}
+
+	err = s.read(ctx, func(db *sqlx.DB) error {
+		ns = nil
+		return db.SelectContext(ctx, &ns, q, args...)
+	})
+	return
+}
+
+// escapeLike escapes LIKE pattern special characters in s.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func (s *Storage) CreateNews(ctx context.Context, n entity.News) (
+	entity.News, error) {
+	ctx, cancel := s.withQueryTimeout(ctx)
+	defer cancel()
+
+	var created entity.News
+	err := s.db.QueryRowxContext(ctx, `
+		INSERT INTO news (header, date) VALUES ($1, $2) RETURNING *;
+	`, n.Header, n.Date).StructScan(&created)
+	return created, err
+}
+
+func (s *Storage) UpdateNews(ctx context.Context, n entity.News) (
+	updated entity.News, err error) {
+	ctx, cancel := s.withQueryTimeout(ctx)
+	defer cancel()
+
 	err = s.db.QueryRowxContext(ctx, `
-		SELECT * FROM news WHERE id = $1;
-	`, id).StructScan(&n)
+		UPDATE news SET header = $2, date = $3 WHERE id = $1 RETURNING *;
+	`, n.ID, n.Header, n.Date).StructScan(&updated)
 	if err == sql.ErrNoRows {
 		err = entity.ErrNewsNotFound
 	}
 	return
 }
+
+func (s *Storage) DeleteNews(ctx context.Context, id int64) error {
+	ctx, cancel := s.withQueryTimeout(ctx)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, `
+		DELETE FROM news WHERE id = $1;
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return entity.ErrNewsNotFound
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"os"
+	"sort"
 	"testing"
 	"time"
 
@@ -15,7 +16,8 @@ import (
 )
 
 func initStorage(t *testing.T) *Storage {
-	s, err := NewStorage(os.Getenv("TEST_POSTGRES_URI"))
+	s, err := NewStorage(os.Getenv("TEST_POSTGRES_URI"), nil, 0,
+		10*time.Second)
 	if err != nil {
 		t.Fatal("failed to create store: " + err.Error())
 	}
@@ -99,3 +101,179 @@ func TestStorage_News_success(t *testing.T) {
 		t.Log(cmp.Diff(wantN, gotN))
 	}
 }
+
+func TestStorage_NewsByIDs(t *testing.T) {
+	s := initStorage(t)
+	defer cleanStorage(t, s)
+
+	var want []entity.News
+
+	for _, h := range []string{"first", "second", "third"} {
+		n, err := s.CreateNews(context.TODO(), entity.News{
+			Header: h,
+			Date:   time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+		want = append(want, n)
+	}
+
+	got, err := s.NewsByIDs(context.TODO(),
+		[]int64{want[2].ID, want[0].ID, 1000})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	sort.Slice(got, func(i, j int) bool { return got[i].ID < got[j].ID })
+
+	if !assert.True(t, cmp.Equal([]entity.News{want[0], want[2]}, got)) {
+		t.Log(cmp.Diff([]entity.News{want[0], want[2]}, got))
+	}
+}
+
+func TestStorage_ListNews(t *testing.T) {
+	s := initStorage(t)
+	defer cleanStorage(t, s)
+
+	day := func(d int) time.Time {
+		return time.Date(2019, 1, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	var all []entity.News
+
+	for i, h := range []string{"first apple", "second", "third Apple",
+		"fourth 100%"} {
+		n, err := s.CreateNews(context.TODO(), entity.News{
+			Header: h,
+			Date:   day(i + 1),
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+		all = append([]entity.News{n}, all...)
+	}
+
+	for name, tc := range map[string]struct {
+		filter entity.NewsFilter
+		want   []entity.News
+	}{
+		"all": {
+			want: all,
+		},
+		"dates": {
+			filter: entity.NewsFilter{From: day(2), To: day(3)},
+			want:   all[1:3],
+		},
+		"query": {
+			filter: entity.NewsFilter{Query: "apple"},
+			want:   []entity.News{all[1], all[3]},
+		},
+		"escaped query": {
+			filter: entity.NewsFilter{Query: "0%"},
+			want:   all[:1],
+		},
+		"page": {
+			filter: entity.NewsFilter{Offset: 1, Limit: 2},
+			want:   all[1:3],
+		},
+	} {
+		got, err := s.ListNews(context.TODO(), tc.filter)
+		if !assert.NoError(t, err, name) {
+			continue
+		}
+		if !assert.True(t, cmp.Equal(tc.want, got), name) {
+			t.Log(cmp.Diff(tc.want, got))
+		}
+	}
+}
+
+func TestStorage_CreateUpdateDeleteNews(t *testing.T) {
+	s := initStorage(t)
+	defer cleanStorage(t, s)
+
+	testDate := time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	n, err := s.CreateNews(context.TODO(), entity.News{
+		Header: "header",
+		Date:   testDate,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotZero(t, n.ID)
+
+	n.Header = "new header"
+
+	updated, err := s.UpdateNews(context.TODO(), n)
+	if assert.NoError(t, err) {
+		assert.True(t, cmp.Equal(n, updated))
+	}
+
+	got, err := s.News(context.TODO(), n.ID)
+	if assert.NoError(t, err) {
+		assert.True(t, cmp.Equal(n, got))
+	}
+
+	err = s.DeleteNews(context.TODO(), n.ID)
+	assert.NoError(t, err)
+
+	_, err = s.News(context.TODO(), n.ID)
+	assert.Equal(t, entity.ErrNewsNotFound, err)
+
+	_, err = s.UpdateNews(context.TODO(), n)
+	assert.Equal(t, entity.ErrNewsNotFound, err)
+
+	err = s.DeleteNews(context.TODO(), n.ID)
+	assert.Equal(t, entity.ErrNewsNotFound, err)
+}
+
+func TestStorage_NewsDays(t *testing.T) {
+	s := initStorage(t)
+	defer cleanStorage(t, s)
+
+	msk := time.FixedZone("MSK", 3*60*60)
+
+	for _, d := range []time.Time{
+		time.Date(2019, 1, 3, 12, 0, 0, 0, time.UTC),
+		time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+		time.Date(2019, 1, 2, 23, 59, 59, 0, time.UTC),
+		// It's 2019-01-01 in UTC.
+		time.Date(2019, 1, 2, 2, 0, 0, 0, msk),
+	} {
+		_, err := s.CreateNews(context.TODO(), entity.News{
+			Header: "header",
+			Date:   d,
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+	}
+
+	days, err := s.NewsDays(context.TODO())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, []entity.NewsDay{
+		{Date: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), Count: 1},
+		{Date: time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC), Count: 2},
+		{Date: time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC), Count: 1},
+	}, days)
+}
+
+func TestStorage_queryTimeout(t *testing.T) {
+	s := initStorage(t)
+	defer cleanStorage(t, s)
+
+	s.SetQueryTimeout(time.Nanosecond)
+
+	_, err := s.News(context.TODO(), 1)
+	assert.Error(t, err)
+	assert.NotEqual(t, entity.ErrNewsNotFound, err)
+
+	s.SetQueryTimeout(0)
+
+	_, err = s.News(context.TODO(), 1)
+	assert.Equal(t, entity.ErrNewsNotFound, err)
+}
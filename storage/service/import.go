@@ -0,0 +1,155 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/storage/pb"
+	"github.com/golang/protobuf/proto"
+)
+
+// maxChunkRecords is maximum number of records in one chunk of import.
+const maxChunkRecords = 1000
+
+// ErrImportCanceled is returned by ChunkSource when client cancels
+// import.
+var ErrImportCanceled = errors.New("import is canceled by client")
+
+// ChunkSource is import source which reads records of chunks uploaded by
+// client. Records are validated as news of create request, invalid ones
+// are line errors.
+type ChunkSource struct {
+	next func() (*pb.ImportChunk, error)
+
+	chunk *pb.ImportChunk
+	i     int
+	seq   int64
+	err   error
+}
+
+// NewChunkSource creates source which gets chunks by next.
+func NewChunkSource(next func() (*pb.ImportChunk, error)) *ChunkSource {
+	return &ChunkSource{next: next}
+}
+
+func (s *ChunkSource) Next() (entity.ImportRecord, error) {
+	if s.err != nil {
+		return entity.ImportRecord{}, s.err
+	}
+
+	for s.chunk == nil || s.i == len(s.chunk.Records) {
+		if s.chunk != nil && s.chunk.Last {
+			return entity.ImportRecord{}, io.EOF
+		}
+
+		err := s.receive()
+		if err != nil {
+			s.err = err
+			return entity.ImportRecord{}, err
+		}
+	}
+
+	r := s.chunk.Records[s.i]
+	s.i++
+
+	rec, err := pb.ToImportRecord(r)
+	if err != nil {
+		return entity.ImportRecord{}, err
+	}
+
+	err = rec.News.Validate()
+	if err != nil {
+		return entity.ImportRecord{}, &entity.ImportError{
+			Line:    rec.Line,
+			Message: err.Error(),
+		}
+	}
+	rec.News.ID = 0
+
+	return rec, nil
+}
+
+func (s *ChunkSource) receive() error {
+	chunk, err := s.next()
+	if err != nil {
+		return err
+	}
+
+	if chunk.Cancel {
+		return ErrImportCanceled
+	}
+
+	if chunk.Seq != s.seq {
+		return entity.ValidationError("got chunk " +
+			strconv.FormatInt(chunk.Seq, 10) + " instead of " +
+			strconv.FormatInt(s.seq, 10))
+	}
+
+	s.chunk = chunk
+	s.i = 0
+	s.seq++
+
+	return nil
+}
+
+// Err returns error which stopped reading of chunks if any. Storages
+// wrap errors of source, so it tells failures of client from failures
+// of storage.
+func (s *ChunkSource) Err() error {
+	return s.err
+}
+
+// SendChunks reads records of src to chunks of at most maxSize bytes and
+// passes them to send in order, the last one is marked.
+func SendChunks(src entity.ImportSource, maxSize int,
+	send func(*pb.ImportChunk) error) error {
+	var (
+		chunk pb.ImportChunk
+		size  int
+	)
+
+	for {
+		rec, err := src.Next()
+		if err == io.EOF {
+			break
+		}
+
+		var r *pb.ImportRecord
+
+		if ie, ok := err.(*entity.ImportError); ok {
+			r = pb.FromImportError(ie)
+		} else if err != nil {
+			return errors.New("failed to read news: " + err.Error())
+		} else {
+			r = pb.FromImportRecord(rec)
+		}
+
+		// Record takes at most 6 bytes more with tag and length.
+		rSize := proto.Size(r) + 6
+
+		// Such record can't be sent even in its own chunk.
+		if rSize > maxSize {
+			return errors.New("news of line " +
+				strconv.FormatInt(r.Line, 10) + " exceeds chunk size")
+		}
+
+		if len(chunk.Records) > 0 && (size+rSize > maxSize ||
+			len(chunk.Records) == maxChunkRecords) {
+			err = send(&chunk)
+			if err != nil {
+				return err
+			}
+			chunk = pb.ImportChunk{Seq: chunk.Seq + 1}
+			size = 0
+		}
+
+		chunk.Records = append(chunk.Records, r)
+		size += rSize
+	}
+
+	chunk.Last = true
+
+	return send(&chunk)
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/storage/pb"
+)
+
+// Limits of requests.
+const (
+	DefaultListLimit = 20
+	MaxListLimit     = 1000
+	// MaxBatchIDs is maximum number of news got by one batch request.
+	MaxBatchIDs = MaxListLimit
+)
+
+// RequestNews validates news from create or update request.
+func RequestNews(n *pb.News) (entity.News, error) {
+	news, err := pb.ToNews(n)
+	if err != nil {
+		return entity.News{}, entity.ValidationError(err.Error())
+	}
+
+	err = news.Validate()
+	if err != nil {
+		return entity.News{}, err
+	}
+
+	return news, nil
+}
+
+// BatchGetNews gets news by ids of r from st.
+func BatchGetNews(ctx context.Context, st Storage,
+	r *pb.BatchGetNewsRequest) (*pb.BatchGetNewsResponse, error) {
+	if len(r.Ids) > MaxBatchIDs {
+		return nil, entity.ValidationError("ids must have at most " +
+			strconv.Itoa(MaxBatchIDs) + " items")
+	}
+
+	ns, err := st.NewsByIDs(ctx, r.Ids)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &pb.BatchGetNewsResponse{}
+	for _, n := range ns {
+		res.News = append(res.News, pb.FromNews(n))
+	}
+
+	return res, nil
+}
+
+// NewsDays gets days which have news from st.
+func NewsDays(ctx context.Context, st Storage) (*pb.NewsDaysResponse,
+	error) {
+	days, err := st.NewsDays(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &pb.NewsDaysResponse{}
+	for _, d := range days {
+		res.Days = append(res.Days, &pb.NewsDay{
+			Date:  pb.FormatDate(d.Date),
+			Count: d.Count,
+		})
+	}
+
+	return res, nil
+}
+
+// ListNews validates list request r and calls fn for every listed news.
+// Limit is defaulted and capped, the query is given queryTimeout.
+func ListNews(ctx context.Context, st Storage, queryTimeout time.Duration,
+	r *pb.ListNewsRequest, fn func(entity.News) error) error {
+	f, err := pb.ToFilter(r)
+	if err != nil {
+		return entity.ValidationError(err.Error())
+	}
+
+	switch {
+	case f.Offset < 0:
+		return entity.ValidationError("offset must be non-negative")
+	case f.Limit < 0:
+		return entity.ValidationError("limit must be non-negative")
+	case f.Limit == 0:
+		f.Limit = DefaultListLimit
+	case f.Limit > MaxListLimit:
+		f.Limit = MaxListLimit
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	ns, err := st.ListNews(queryCtx, f)
+	if err != nil {
+		return err
+	}
+
+	for _, n := range ns {
+		err = fn(n)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ExportNews validates export request r and calls fn for every exported
+// news. Export isn't limited by query timeout, since it can take long.
+func ExportNews(ctx context.Context, st Storage, r *pb.ExportNewsRequest,
+	fn func(entity.News) error) error {
+	f, err := pb.ToFilter(&pb.ListNewsRequest{
+		From:  r.From,
+		To:    r.To,
+		Query: r.Query,
+	})
+	if err != nil {
+		return entity.ValidationError(err.Error())
+	}
+
+	return st.ExportNews(ctx, f, fn)
+}
@@ -0,0 +1,54 @@
+// Package service implements storage operations independently of
+// transport, so that NATS and gRPC servers validate requests and limit
+// results the same way, and their clients return the same errors.
+package service
+
+import (
+	"context"
+
+	"github.com/dimuls/news-storage/entity"
+)
+
+type Storage interface {
+	News(ctx context.Context, id int64) (entity.News, error)
+	NewsByIDs(ctx context.Context, ids []int64) ([]entity.News, error)
+	NewsDays(ctx context.Context) ([]entity.NewsDay, error)
+	ListNews(ctx context.Context, f entity.NewsFilter) ([]entity.News, error)
+	CreateNews(ctx context.Context, n entity.News) (entity.News, error)
+	UpdateNews(ctx context.Context, n entity.News) (entity.News, error)
+	DeleteNews(ctx context.Context, id int64) error
+	ExportNews(ctx context.Context, f entity.NewsFilter,
+		fn func(entity.News) error) error
+}
+
+// Importer imports news in bulk. Servers serve import requests if their
+// storage implements it.
+type Importer interface {
+	ImportNews(ctx context.Context, src entity.ImportSource,
+		o entity.ImportOptions) (entity.ImportResult, error)
+}
+
+// EventPublisher publishes news events to subscribers of storage.
+type EventPublisher interface {
+	PublishNewsEvent(e entity.NewsEvent)
+}
+
+// ResponseError is an error returned by server.
+type ResponseError struct {
+	Code    int64
+	Message string
+}
+
+func (e *ResponseError) Error() string {
+	return "got response error: " + e.Message
+}
+
+// RequestError is returned when request isn't delivered to server or
+// its response isn't received.
+type RequestError struct {
+	Err error
+}
+
+func (e *RequestError) Error() string {
+	return "failed to request: " + e.Err.Error()
+}
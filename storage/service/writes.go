@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+)
+
+// Writes remembers when principals wrote the last time, so that their
+// reads which follow a write are made from primary and see it while
+// replicas catch up. Writes of requests without principal are shared.
+type Writes struct {
+	// window is time.Duration accessed atomically, because it can be
+	// changed while servers are handling requests.
+	window int64
+
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+// NewWrites creates writes which make reads within window after write
+// of the same principal primary ones. Zero window disables it.
+func NewWrites(window time.Duration) *Writes {
+	return &Writes{
+		window: int64(window),
+		last:   map[string]time.Time{},
+	}
+}
+
+// SetWindow changes window of reads from primary after write.
+func (w *Writes) SetWindow(d time.Duration) {
+	atomic.StoreInt64(&w.window, int64(d))
+}
+
+// Add remembers write of principal of ctx. Writes which are out of
+// window are forgotten.
+func (w *Writes) Add(ctx context.Context) {
+	window := time.Duration(atomic.LoadInt64(&w.window))
+	if window == 0 {
+		return
+	}
+
+	p, _ := entity.PrincipalFromContext(ctx)
+	now := time.Now()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for name, t := range w.last {
+		if now.Sub(t) > window {
+			delete(w.last, name)
+		}
+	}
+
+	w.last[p.Name] = now
+}
+
+// ReadContext returns ctx which requires read from primary if principal
+// of ctx wrote within window.
+func (w *Writes) ReadContext(ctx context.Context) context.Context {
+	window := time.Duration(atomic.LoadInt64(&w.window))
+	if window == 0 {
+		return ctx
+	}
+
+	p, _ := entity.PrincipalFromContext(ctx)
+
+	w.mu.Lock()
+	t, ok := w.last[p.Name]
+	w.mu.Unlock()
+
+	if ok && time.Since(t) <= window {
+		return entity.ContextWithPrimaryRead(ctx)
+	}
+
+	return ctx
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrites(t *testing.T) {
+	w := NewWrites(time.Minute)
+
+	alice := entity.ContextWithPrincipal(context.TODO(),
+		entity.Principal{Name: "alice"})
+	bob := entity.ContextWithPrincipal(context.TODO(),
+		entity.Principal{Name: "bob"})
+
+	assert.False(t, entity.PrimaryReadFromContext(w.ReadContext(alice)))
+
+	w.Add(alice)
+
+	assert.True(t, entity.PrimaryReadFromContext(w.ReadContext(alice)))
+	assert.False(t, entity.PrimaryReadFromContext(w.ReadContext(bob)))
+
+	w.SetWindow(time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	assert.False(t, entity.PrimaryReadFromContext(w.ReadContext(alice)))
+}
+
+func TestWrites_disabled(t *testing.T) {
+	w := NewWrites(0)
+
+	w.Add(context.TODO())
+
+	assert.False(t, entity.PrimaryReadFromContext(
+		w.ReadContext(context.TODO())))
+}
@@ -0,0 +1,40 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/dimuls/news-storage/entity"
+)
+
+// NewsDays returns days which have news in UTC with number of news of
+// every day. Days are ordered by date.
+func (s *Storage) NewsDays(ctx context.Context) ([]entity.NewsDay, error) {
+	var rows []struct {
+		Day   string `db:"day"`
+		Count int64  `db:"count"`
+	}
+
+	// Dates are stored as text with time zone, date function converts
+	// them to UTC.
+	err := s.db.SelectContext(ctx, &rows, `
+		SELECT date(date) AS day, count(*) AS count
+		FROM news WHERE date IS NOT NULL
+		GROUP BY day ORDER BY day;
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	days := make([]entity.NewsDay, 0, len(rows))
+	for _, r := range rows {
+		d, err := time.ParseInLocation("2006-01-02", r.Day, time.UTC)
+		if err != nil {
+			return nil, errors.New("failed to parse day: " + err.Error())
+		}
+		days = append(days, entity.NewsDay{Date: d, Count: r.Count})
+	}
+
+	return days, nil
+}
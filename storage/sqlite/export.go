@@ -0,0 +1,53 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/dimuls/news-storage/entity"
+)
+
+// exportPageSize is number of news read at once by export.
+const exportPageSize = 1000
+
+// ExportNews calls fn for every news matched by dates and query of f in
+// date order. News are read in pages after date and id of the last read
+// news, so memory usage doesn't depend on number of exported news. Page
+// is read before fn is called, so that the only connection of storage
+// isn't held while slow consumer handles news.
+func (s *Storage) ExportNews(ctx context.Context, f entity.NewsFilter,
+	fn func(entity.News) error) error {
+	var last *entity.News
+
+	for {
+		var args queryArgs
+
+		q := "SELECT * FROM (" + selectNews(f, &args) + ")"
+		if last != nil {
+			q += " WHERE (date, id) > (" + args.add(last.Date.UTC()) +
+				", " + args.add(last.ID) + ")"
+		}
+		q += " ORDER BY date, id LIMIT " + strconv.Itoa(exportPageSize)
+
+		var ns []entity.News
+
+		err := s.db.SelectContext(ctx, &ns, q, args...)
+		if err != nil {
+			return errors.New("failed to select news: " + err.Error())
+		}
+
+		for _, n := range ns {
+			err = fn(n)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(ns) < exportPageSize {
+			return nil
+		}
+
+		last = &ns[len(ns)-1]
+	}
+}
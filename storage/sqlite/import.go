@@ -0,0 +1,229 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+
+	"github.com/dimuls/news-storage/entity"
+	"github.com/jmoiron/sqlx"
+)
+
+// ImportNews imports news from src in one transaction. News are inserted
+// to temporary table first, then checked for external ID conflicts and
+// moved to news table.
+func (s *Storage) ImportNews(ctx context.Context, src entity.ImportSource,
+	o entity.ImportOptions) (r entity.ImportResult, err error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return r, errors.New("failed to begin transaction: " + err.Error())
+	}
+	defer func() {
+		if !r.Committed {
+			tx.Rollback()
+		}
+	}()
+
+	// Table is created in transaction, so rollback drops it.
+	_, err = tx.ExecContext(ctx, `
+		CREATE TEMPORARY TABLE news_import (
+			line INTEGER NOT NULL,
+			external_id TEXT NOT NULL,
+			header TEXT NOT NULL,
+			date TIMESTAMP NOT NULL
+		);
+	`)
+	if err != nil {
+		return r, errors.New("failed to create import table: " +
+			err.Error())
+	}
+
+	err = insertNews(ctx, tx, src, &r)
+	if err != nil {
+		return r, err
+	}
+
+	err = rejectConflicts(ctx, tx, o.Upsert, &r)
+	if err != nil {
+		return r, err
+	}
+
+	sort.SliceStable(r.Errors, func(i, j int) bool {
+		return r.Errors[i].Line < r.Errors[j].Line
+	})
+
+	if o.Atomic && r.Failed > 0 {
+		return r, nil
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE news SET
+			header = (
+				SELECT header FROM news_import
+				WHERE news_import.external_id = news.external_id
+			),
+			date = (
+				SELECT date FROM news_import
+				WHERE news_import.external_id = news.external_id
+			)
+		WHERE external_id <> ''
+			AND external_id IN (SELECT external_id FROM news_import);
+	`)
+	if err != nil {
+		return r, errors.New("failed to update news: " + err.Error())
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return r, errors.New("failed to get updated news count: " +
+			err.Error())
+	}
+
+	res, err = tx.ExecContext(ctx, `
+		INSERT INTO news (external_id, header, date)
+		SELECT external_id, header, date FROM news_import
+		WHERE news_import.external_id = '' OR NOT EXISTS (
+			SELECT 1 FROM news
+			WHERE news.external_id = news_import.external_id
+		)
+		ORDER BY line;
+	`)
+	if err != nil {
+		return r, errors.New("failed to insert news: " + err.Error())
+	}
+
+	created, err := res.RowsAffected()
+	if err != nil {
+		return r, errors.New("failed to get created news count: " +
+			err.Error())
+	}
+
+	r.Created, r.Updated = int(created), int(updated)
+
+	if o.DryRun {
+		return r, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `DROP TABLE news_import;`)
+	if err != nil {
+		return r, errors.New("failed to drop import table: " + err.Error())
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return r, errors.New("failed to commit: " + err.Error())
+	}
+
+	r.Committed = true
+
+	return r, nil
+}
+
+// insertNews inserts news from src to import table and adds invalid
+// lines to r.
+func insertNews(ctx context.Context, tx *sqlx.Tx, src entity.ImportSource,
+	r *entity.ImportResult) error {
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO news_import (line, external_id, header, date)
+		VALUES ($1, $2, $3, $4);
+	`)
+	if err != nil {
+		return errors.New("failed to prepare insert: " + err.Error())
+	}
+	defer stmt.Close()
+
+	for {
+		rec, err := src.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if ie, ok := err.(*entity.ImportError); ok {
+			r.Total++
+			r.AddError(*ie)
+			continue
+		}
+		if err != nil {
+			return errors.New("failed to read news: " + err.Error())
+		}
+
+		r.Total++
+
+		_, err = stmt.ExecContext(ctx, rec.Line, rec.News.ExternalID,
+			rec.News.Header, rec.News.Date.UTC())
+		if err != nil {
+			return errors.New("failed to insert news: " + err.Error())
+		}
+	}
+}
+
+// conflictCheck selects news with conflicting external ID in import
+// table, which are removed from it by delete.
+type conflictCheck struct {
+	where   string
+	message string
+}
+
+var (
+	repeatedCheck = conflictCheck{
+		where: `
+			external_id <> '' AND EXISTS (
+				SELECT 1 FROM news_import d
+				WHERE d.external_id = news_import.external_id
+					AND d.line < news_import.line
+			)
+		`,
+		message: "external id is repeated in import: ",
+	}
+	existingCheck = conflictCheck{
+		where: `
+			external_id <> '' AND EXISTS (
+				SELECT 1 FROM news
+				WHERE news.external_id = news_import.external_id
+			)
+		`,
+		message: "news with external id already exists: ",
+	}
+)
+
+// rejectConflicts removes news with external ID repeated in import or,
+// if not upsert, existing in news table from import table and adds them
+// to r as line errors.
+func rejectConflicts(ctx context.Context, tx *sqlx.Tx, upsert bool,
+	r *entity.ImportResult) error {
+	checks := []conflictCheck{repeatedCheck}
+	if !upsert {
+		checks = append(checks, existingCheck)
+	}
+
+	for _, c := range checks {
+		var conflicts []struct {
+			Line       int    `db:"line"`
+			ExternalID string `db:"external_id"`
+		}
+
+		// SQLite has no DELETE ... RETURNING, so conflicts are selected
+		// before delete.
+		err := tx.SelectContext(ctx, &conflicts, `
+			SELECT line, external_id FROM news_import WHERE `+c.where)
+		if err != nil {
+			return errors.New("failed to check external ids: " +
+				err.Error())
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM news_import WHERE `+c.where)
+		if err != nil {
+			return errors.New("failed to remove conflicts: " + err.Error())
+		}
+
+		for _, cf := range conflicts {
+			r.AddError(entity.ImportError{
+				Line:    cf.Line,
+				Message: c.message + cf.ExternalID,
+			})
+		}
+	}
+
+	return nil
+}
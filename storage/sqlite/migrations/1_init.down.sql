@@ -0,0 +1 @@
+DROP TABLE news;
\ No newline at end of file
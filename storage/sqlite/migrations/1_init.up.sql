@@ -0,0 +1,5 @@
+CREATE TABLE news (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  header TEXT NOT NULL,
+  date TIMESTAMP
+);
\ No newline at end of file
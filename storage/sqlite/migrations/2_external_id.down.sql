@@ -0,0 +1,14 @@
+DROP INDEX news_external_id_idx;
+
+-- SQLite can't drop columns, so table is recreated without it.
+CREATE TABLE news_old (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  header TEXT NOT NULL,
+  date TIMESTAMP
+);
+
+INSERT INTO news_old (id, header, date) SELECT id, header, date FROM news;
+
+DROP TABLE news;
+
+ALTER TABLE news_old RENAME TO news;
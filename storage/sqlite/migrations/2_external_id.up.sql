@@ -0,0 +1,4 @@
+ALTER TABLE news ADD COLUMN external_id TEXT NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX news_external_id_idx ON news (external_id)
+  WHERE external_id <> '';
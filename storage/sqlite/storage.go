@@ -0,0 +1,215 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Boostport/migration"
+	"github.com/Boostport/migration/driver/sqlite"
+	"github.com/dimuls/news-storage/entity"
+	"github.com/dimuls/news-storage/storage/migrate"
+	"github.com/gobuffalo/packr"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type Storage struct {
+	path string
+	db   *sqlx.DB
+}
+
+func NewStorage(path string, pingTimeout time.Duration) (*Storage, error) {
+	db, err := sqlx.Connect("sqlite3", path)
+	if err != nil {
+		return nil, errors.New("failed to connect: " + err.Error())
+	}
+
+	// SQLite allows only one writer at a time, so share a single
+	// connection instead of failing with "database is locked".
+	db.SetMaxOpenConns(1)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), pingTimeout)
+	defer cancel()
+
+	err = db.PingContext(ctx)
+	if err != nil {
+		return nil, errors.New("failed to ping: " + err.Error())
+	}
+
+	return &Storage{
+		path: path,
+		db:   db,
+	}, nil
+}
+
+//go:generate packr
+
+const migrationsPath = "./migrations"
+
+// Migrator returns migrator of storage schema. Migrator opens its own
+// connection to database, so in-memory databases can't be migrated.
+func (s *Storage) Migrator() *migrate.Migrator {
+	return migrate.NewMigrator(func() (migration.Driver, error) {
+		return sqlite.New(s.path, true)
+	}, &migration.PackrMigrationSource{
+		Box: packr.NewBox(migrationsPath),
+	})
+}
+
+// Migrate applies all not applied migrations.
+func (s *Storage) Migrate() error {
+	_, err := s.Migrator().Up(0)
+	return err
+}
+
+func (s *Storage) Close() error {
+	return s.db.Close()
+}
+
+func (s *Storage) News(ctx context.Context, id int64) (n entity.News, err error) {
+	err = s.db.QueryRowxContext(ctx, `
+		SELECT * FROM news WHERE id = $1;
+	`, id).StructScan(&n)
+	if err == sql.ErrNoRows {
+		err = entity.ErrNewsNotFound
+	}
+	return
+}
+
+// NewsByIDs returns news with ids in no particular order. Missing news
+// are omitted.
+func (s *Storage) NewsByIDs(ctx context.Context, ids []int64) (
+	ns []entity.News, err error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var (
+		args         queryArgs
+		placeholders []string
+	)
+
+	for _, id := range ids {
+		placeholders = append(placeholders, args.add(id))
+	}
+
+	err = s.db.SelectContext(ctx, &ns, "SELECT * FROM news WHERE id IN ("+
+		strings.Join(placeholders, ", ")+")", args...)
+	return
+}
+
+// queryArgs collects query arguments and returns their placeholders.
+type queryArgs []interface{}
+
+func (a *queryArgs) add(v interface{}) string {
+	*a = append(*a, v)
+	return "$" + strconv.Itoa(len(*a))
+}
+
+// selectNews returns query selecting news matched by dates and query
+// of f, its arguments are added to args.
+func selectNews(f entity.NewsFilter, args *queryArgs) string {
+	var where []string
+
+	// Dates are stored as text, so they are compared in UTC.
+	if !f.From.IsZero() {
+		where = append(where, "date >= "+args.add(f.From.UTC()))
+	}
+	if !f.To.IsZero() {
+		where = append(where, "date <= "+args.add(f.To.UTC()))
+	}
+	if f.Query != "" {
+		// LIKE is case insensitive for ASCII in SQLite.
+		where = append(where, "header LIKE "+
+			args.add("%"+escapeLike(f.Query)+"%")+` ESCAPE '\'`)
+	}
+
+	q := "SELECT * FROM news"
+	if len(where) > 0 {
+		q += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	return q
+}
+
+func (s *Storage) ListNews(ctx context.Context, f entity.NewsFilter) (
+	ns []entity.News, err error) {
+	var args queryArgs
+
+	q := selectNews(f, &args) + " ORDER BY date DESC, id DESC"
+
+	// SQLite doesn't allow OFFSET without LIMIT, -1 means no limit.
+	limit := int64(-1)
+	if f.Limit > 0 {
+		limit = f.Limit
+	}
+	q += " LIMIT " + args.add(limit) + " OFFSET " + args.add(f.Offset)
+
+	err = s.db.SelectContext(ctx, &ns, q, args...)
+	return
+}
+
+// escapeLike escapes LIKE pattern special characters in s.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func (s *Storage) CreateNews(ctx context.Context, n entity.News) (
+	entity.News, error) {
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO news (header, date) VALUES ($1, $2);
+	`, n.Header, n.Date.UTC())
+	if err != nil {
+		return entity.News{}, err
+	}
+
+	n.ID, err = res.LastInsertId()
+	if err != nil {
+		return entity.News{}, err
+	}
+
+	return s.News(ctx, n.ID)
+}
+
+func (s *Storage) UpdateNews(ctx context.Context, n entity.News) (
+	entity.News, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE news SET header = $1, date = $2 WHERE id = $3;
+	`, n.Header, n.Date.UTC(), n.ID)
+	if err != nil {
+		return entity.News{}, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return entity.News{}, err
+	}
+	if affected == 0 {
+		return entity.News{}, entity.ErrNewsNotFound
+	}
+
+	return s.News(ctx, n.ID)
+}
+
+func (s *Storage) DeleteNews(ctx context.Context, id int64) error {
+	res, err := s.db.ExecContext(ctx, `
+		DELETE FROM news WHERE id = $1;
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return entity.ErrNewsNotFound
+	}
+
+	return nil
+}